@@ -2,18 +2,27 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/tienanr/docurift/internal/analyzer"
 	"github.com/tienanr/docurift/internal/config"
 	"github.com/vulcand/oxy/forward"
+	"github.com/vulcand/oxy/utils"
 )
 
 var (
@@ -22,6 +31,9 @@ var (
 	date    = "unknown"
 )
 
+// shutdownTimeout is how long active requests may take to finish on shutdown
+const shutdownTimeout = 10 * time.Second
+
 // customResponseWriter captures the response for logging
 type customResponseWriter struct {
 	http.ResponseWriter
@@ -41,12 +53,101 @@ func (w *customResponseWriter) Write(b []byte) (int, error) {
 
 func printUsage() {
 	fmt.Printf("DocuRift - Automatic API Documentation Generator\n\n")
-	fmt.Printf("Usage: docurift -config <config-file>\n\n")
+	fmt.Printf("Usage: docurift [-config <config-file>] [options]\n")
+	fmt.Printf("       docurift mock [-state analyzer.json] [-port 9999]\n")
+	fmt.Printf("       docurift validate -config <config-file> [-skip-ports] [-json]\n\n")
 	fmt.Printf("Options:\n")
-	fmt.Printf("  -config string    Path to configuration file (required)\n")
-	fmt.Printf("  -version         Show version information\n")
-	fmt.Printf("\nExample:\n")
+	fmt.Printf("  -config string       Path to configuration file\n")
+	fmt.Printf("  -proxy-port int      Proxy port, overrides proxy.port\n")
+	fmt.Printf("  -analyzer-port int   Analyzer port, overrides analyzer.port\n")
+	fmt.Printf("  -backend-url string  Backend URL, overrides proxy.backend-url\n")
+	fmt.Printf("  -max-examples int    Examples kept per field, overrides analyzer.max-examples\n")
+	fmt.Printf("  -quiet               Only log warnings and errors\n")
+	fmt.Printf("  -check               Check the configuration, backend, storage and ports, then exit\n")
+	fmt.Printf("  -watch               Reload the configuration file when it changes\n")
+	fmt.Printf("  -version             Show version information\n")
+	fmt.Printf("\nThe %s, %s, %s and %s\n", config.EnvProxyPort, config.EnvAnalyzerPort, config.EnvBackendURL, config.EnvMaxExamples)
+	fmt.Printf("environment variables override the configuration file and are overridden by flags.\n")
+	fmt.Printf("Send SIGHUP, or use -watch, to reload the configuration file while running.\n")
+	fmt.Printf("\nExamples:\n")
 	fmt.Printf("  docurift -config config.yaml\n")
+	fmt.Printf("  docurift -config config.yaml -backend-url http://api:8080\n")
+	fmt.Printf("  docurift -proxy-port 9876 -analyzer-port 9877 -backend-url http://localhost:8080 -max-examples 10\n")
+	fmt.Printf("  docurift mock -state ./data/analyzer.json -port 9999\n")
+	fmt.Printf("  docurift validate -config config.yaml -json\n")
+}
+
+// newLogger creates the logger for the given level and format (text or json).
+// Quiet mode only logs warnings and errors.
+func newLogger(level, format string, quiet bool) *slog.Logger {
+	var logLevel slog.Level
+	if err := logLevel.UnmarshalText([]byte(level)); err != nil {
+		logLevel = slog.LevelInfo
+	}
+	if quiet && logLevel < slog.LevelWarn {
+		logLevel = slog.LevelWarn
+	}
+
+	options := &slog.HandlerOptions{Level: logLevel}
+	if format == "json" {
+		return slog.New(slog.NewJSONHandler(os.Stderr, options))
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, options))
+}
+
+// configureAnalyzer applies the analyzer settings that can change while
+// running, on startup and on every configuration reload. Settings that can
+// fail are applied first, so a failed reload changes nothing.
+func configureAnalyzer(a *analyzer.Analyzer, cfg *config.Config) error {
+	if err := a.SetSensitivePatterns(cfg.Analyzer.SensitivePatterns); err != nil {
+		return err
+	}
+	a.SetMaxExamples(cfg.Analyzer.MaxExamples)
+	a.SetSampling(cfg.Analyzer.Sampling)
+	a.SetSampleRate(*cfg.Analyzer.SampleRate)
+	a.SetEnumThreshold(cfg.Analyzer.EnumThreshold)
+	// Validated when loading the configuration, an empty value disables deprecation
+	deprecateAfter, _ := time.ParseDuration(cfg.Analyzer.DeprecateAfter)
+	a.SetDeprecateAfter(deprecateAfter)
+	a.SetResponseDescriptions(cfg.Analyzer.ResponseDescriptions)
+	a.SetRedactedFields(cfg.Analyzer.RedactedFields)
+	a.SetRedactionStrategy(cfg.Analyzer.Redaction.Strategy, cfg.Analyzer.Redaction.MaskLength, cfg.Analyzer.Redaction.HashSalt)
+	a.SetAutoRedactPII(cfg.Analyzer.AutoRedactPII)
+	a.SetProcessingLimits(cfg.Analyzer.Limits.MaxDepth, cfg.Analyzer.Limits.MaxPaths, cfg.Analyzer.Limits.MaxArrayItems)
+	a.SetCaptureErrors(cfg.Analyzer.CaptureErrors)
+	a.SetTraceHeaders(cfg.Analyzer.TraceHeaders)
+	a.SetHeaderFilters(cfg.Analyzer.ExcludedHeaders, cfg.Analyzer.IncludedHeaders)
+	a.SetNoBodyPaths(cfg.Analyzer.NoBodyPaths)
+	a.SetRedactCookies(*cfg.Analyzer.RedactCookies)
+	a.SetLenientJSON(cfg.Analyzer.LenientJSON)
+	a.SetServerURL(cfg.Analyzer.PublicURL)
+	a.SetPostmanBaseURL(cfg.Analyzer.Postman.BaseURL)
+	a.SetUseRefs(cfg.Analyzer.OpenAPI.UseRefs)
+	a.SetWebhook(analyzer.WebhookOptions{
+		URL:         cfg.Notifications.WebhookURL,
+		Events:      cfg.Notifications.Events,
+		MinSeverity: cfg.Notifications.MinSeverity,
+		Debounce:    time.Duration(cfg.Notifications.Debounce) * time.Second,
+	})
+	var servers []analyzer.APIServer
+	for _, server := range cfg.Analyzer.OpenAPI.Servers {
+		servers = append(servers, analyzer.APIServer{URL: server.URL, Description: server.Description})
+	}
+	a.SetServers(servers)
+
+	info := analyzer.Info{
+		Title:       cfg.Analyzer.OpenAPI.Info.Title,
+		Version:     cfg.Analyzer.OpenAPI.Info.Version,
+		Description: cfg.Analyzer.OpenAPI.Info.Description,
+	}
+	if email := cfg.Analyzer.OpenAPI.Info.Contact.Email; email != "" {
+		info.Contact = &analyzer.Contact{Email: email}
+	}
+	if name := cfg.Analyzer.OpenAPI.Info.License.Name; name != "" {
+		info.License = &analyzer.License{Name: name}
+	}
+	a.SetInfo(info)
+	return nil
 }
 
 // checkPortAvailable checks if a port is available for use
@@ -61,9 +162,28 @@ func checkPortAvailable(port int, service string) error {
 }
 
 func main() {
+	// Subcommands have flags of their own
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "mock":
+			runMock(os.Args[2:])
+			return
+		case "validate":
+			os.Exit(runValidate(os.Args[2:]))
+		}
+	}
+
 	// Define command line flags
 	configPath := flag.String("config", "", "Path to configuration file")
 	showVersion := flag.Bool("version", false, "Show version information")
+	quiet := flag.Bool("quiet", false, "Only log warnings and errors")
+	check := flag.Bool("check", false, "Check the configuration, backend, storage and ports, then exit")
+	watch := flag.Bool("watch", false, "Reload the configuration file when it changes")
+	var overrides config.Overrides
+	flag.IntVar(&overrides.ProxyPort, "proxy-port", 0, "Proxy port, overrides proxy.port")
+	flag.IntVar(&overrides.AnalyzerPort, "analyzer-port", 0, "Analyzer port, overrides analyzer.port")
+	flag.StringVar(&overrides.BackendURL, "backend-url", "", "Backend URL, overrides proxy.backend-url")
+	flag.IntVar(&overrides.MaxExamples, "max-examples", 0, "Examples kept per field, overrides analyzer.max-examples")
 
 	// Parse flags
 	flag.Parse()
@@ -74,21 +194,27 @@ func main() {
 		return
 	}
 
-	// Show usage if no arguments provided
-	if len(os.Args) == 1 {
-		printUsage()
-		return
-	}
-
-	// Load configuration
-	if *configPath == "" {
-		log.Fatal("Configuration file path is required")
+	// Dry run: report whether DocuRift could start with this configuration
+	if *check {
+		report := validateConfig(*configPath, overrides, configChecks{Ports: true, Backend: true, Storage: true})
+		os.Exit(report.exit(false))
 	}
 
-	cfg, err := config.LoadConfig(*configPath)
+	// Load configuration, which may come from flags and the environment only
+	cfg, err := config.LoadConfigWithOverrides(*configPath, overrides)
 	if err != nil {
+		// Show usage if no arguments provided and the environment isn't enough
+		if len(os.Args) == 1 {
+			printUsage()
+			return
+		}
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
+	slog.SetDefault(newLogger(cfg.Logging.Level, cfg.Logging.Format, *quiet))
+
+	// The configuration is replaced as a whole when it is reloaded
+	var currentConfig atomic.Pointer[config.Config]
+	currentConfig.Store(cfg)
 
 	// Check if ports are available
 	if err := checkPortAvailable(cfg.Proxy.Port, "proxy"); err != nil {
@@ -98,21 +224,55 @@ func main() {
 		log.Fatalf("Invalid configuration: %v", err)
 	}
 
-	log.Printf("Starting DocuRift with proxy port %d and analyzer port %d", cfg.Proxy.Port, cfg.Analyzer.Port)
+	slog.Info("Starting DocuRift", "proxyPort", cfg.Proxy.Port, "analyzerPort", cfg.Analyzer.Port)
+
+	if cfg.Proxy.HealthCheck.Enabled || cfg.Proxy.RequireBackend {
+		if err := checkBackendAtStartup(cfg); err != nil {
+			if cfg.Proxy.RequireBackend {
+				log.Fatalf("Backend check failed: %v", err)
+			}
+			slog.Warn("Backend check failed, requests will fail until it is reachable", "error", err)
+		}
+	}
 
 	// Initialize analyzer with configuration
-	analyzerInstance := analyzer.NewAnalyzer(cfg.Analyzer.Storage.Path, cfg.Analyzer.Storage.Frequency)
-	analyzerInstance.SetMaxExamples(cfg.Analyzer.MaxExamples)
-	analyzerInstance.SetRedactedFields(cfg.Analyzer.RedactedFields)
+	storage := cfg.Analyzer.Storage
+	store, err := analyzer.OpenStateStore(analyzer.StorageOptions{
+		Type: storage.Type,
+		Path: storage.Path,
+		S3: analyzer.S3Config{
+			Bucket:   storage.Bucket,
+			Prefix:   storage.Prefix,
+			Region:   storage.Region,
+			Endpoint: storage.Endpoint,
+		},
+	})
+	if err != nil {
+		log.Fatalf("Failed to open storage: %v", err)
+	}
+	analyzerInstance := analyzer.NewAnalyzerWithStore(store, cfg.Analyzer.Storage.Frequency)
+	if err := configureAnalyzer(analyzerInstance, cfg); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
 	analyzerInstance.SetProxyConfig(cfg.Proxy.Port, cfg.Proxy.BackendURL)
+	if cfg.Proxy.HealthCheck.Enabled {
+		analyzerInstance.SetBackendHealthCheck(cfg.Proxy.HealthCheck.Path)
+	}
 	analyzerInstance.SetAnalyzerPort(cfg.Analyzer.Port)
+	analyzerInstance.SetBuildInfo(analyzer.BuildInfo{Version: version, Commit: commit, Date: date})
+	auth := analyzer.AuthConfig{
+		Token:       cfg.Analyzer.Auth.Token,
+		Username:    cfg.Analyzer.Auth.Username,
+		Password:    cfg.Analyzer.Auth.Password,
+		ExemptPaths: cfg.Analyzer.Auth.ExemptPaths,
+	}
 	analyzerServer := analyzer.NewServer(analyzerInstance)
+	analyzerServer.SetAuth(auth)
 
 	// Start analyzer server in a goroutine
 	go func() {
 		addr := fmt.Sprintf(":%d", cfg.Analyzer.Port)
-		log.Printf("Starting analyzer server on %s", addr)
-		if err := analyzerServer.Start(addr); err != nil {
+		if err := analyzerServer.Start(addr); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Fatalf("Failed to start analyzer server: %v", err)
 		}
 	}()
@@ -123,13 +283,33 @@ func main() {
 		log.Fatalf("Invalid backend URL: %v", err)
 	}
 
-	log.Printf("Using backend URL: %s", backendURLParsed.String())
+	slog.Info("Using backend", "url", backendURLParsed.String())
+
+	// Record failed forwards before answering with the default 502/504 response
+	errorHandler := utils.ErrorHandlerFunc(func(w http.ResponseWriter, req *http.Request, err error) {
+		analyzerInstance.AddProxyError(req.Method, req.URL.Path, err)
+		utils.DefaultHandler.ServeHTTP(w, req, err)
+	})
 
-	fwd, err := forward.New(forward.PassHostHeader(true))
+	if cfg.Proxy.HTTP2 {
+		slog.Info("Forwarding requests to the backend over HTTP/2")
+	}
+	fwd, err := forward.New(
+		forward.PassHostHeader(true),
+		forward.ErrorHandler(errorHandler),
+		forward.RoundTripper(analyzer.NewBackendTransport(cfg.Proxy.HTTP2)),
+	)
 	if err != nil {
 		log.Fatalf("Failed to create forwarder: %v", err)
 	}
 
+	// Send captured requests to a central analyzer instead of analyzing them here
+	var remote *analyzer.RemoteClient
+	if cfg.Analyzer.RemoteURL != "" {
+		slog.Info("Sending captured requests to remote analyzer", "url", cfg.Analyzer.RemoteURL)
+		remote = analyzer.NewRemoteClient(cfg.Analyzer.RemoteURL, auth)
+	}
+
 	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		// Capture request body
 		var reqBody []byte
@@ -141,31 +321,94 @@ func main() {
 		req.URL.Scheme = backendURLParsed.Scheme
 		req.URL.Host = backendURLParsed.Host
 
-		log.Printf("→ Forwarding request: %s %s", req.Method, req.URL.String())
-
 		crw := &customResponseWriter{ResponseWriter: w, statusCode: 200}
 		fwd.ServeHTTP(crw, req)
+		analyzerInstance.RecordProxied(crw.statusCode)
 
-		// Log response after it's been written
-		log.Printf("← Response status: %d\n← Body: %s", crw.statusCode, crw.buf.String())
+		// Log response after it's been written; bodies are only logged at debug
+		// level, redacted like the documentation
+		slog.Info("Proxied request", "method", req.Method, "url", req.URL.String(), "status", crw.statusCode)
+		if slog.Default().Enabled(req.Context(), slog.LevelDebug) {
+			maxBodyLength := currentConfig.Load().Logging.MaxBodyLength
+			slog.Debug("Proxied request bodies", "method", req.Method, "url", req.URL.String(),
+				"requestBody", analyzerInstance.RedactBody(reqBody, maxBodyLength),
+				"responseBody", analyzerInstance.RedactBody(crw.buf.Bytes(), maxBodyLength))
+		}
+
+		resp := &http.Response{
+			StatusCode: crw.statusCode,
+			Header:     crw.Header(),
+		}
+		if remote != nil {
+			remote.Add(analyzer.NewIngestRecord(req, reqBody, resp, crw.buf.Bytes()))
+			return
+		}
 
-		// Process request/response with analyzer
+		// Process request/response with analyzer, unless left out by sampling
+		if !analyzerInstance.ShouldAnalyze(req.Method, req.URL.String()) {
+			return
+		}
 		analyzerInstance.ProcessRequest(
 			req.Method,
 			req.URL.String(),
 			req,
-			&http.Response{
-				StatusCode: crw.statusCode,
-				Header:     crw.Header(),
-			},
+			resp,
 			reqBody,
 			crw.buf.Bytes(),
 		)
 	})
 
-	addr := fmt.Sprintf(":%d", cfg.Proxy.Port)
-	log.Printf("Starting proxy server on %s", addr)
-	if err := http.ListenAndServe(addr, handler); err != nil {
-		log.Fatalf("Failed to start proxy server: %v", err)
+	proxyServer := &http.Server{Addr: fmt.Sprintf(":%d", cfg.Proxy.Port), Handler: handler}
+	go func() {
+		slog.Info("Starting proxy server", "addr", proxyServer.Addr)
+		if err := proxyServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start proxy server: %v", err)
+		}
+	}()
+
+	// Reload the configuration on SIGHUP, and when the file changes with -watch
+	var reloads sync.Mutex
+	reload := func() {
+		reloads.Lock()
+		defer reloads.Unlock()
+		reloadConfig(analyzerInstance, *configPath, overrides, &currentConfig, *quiet)
+	}
+	stopWatching := make(chan struct{})
+	defer close(stopWatching)
+	if *watch {
+		if *configPath == "" {
+			slog.Warn("Ignoring -watch without a configuration file")
+		} else {
+			slog.Info("Watching configuration file for changes", "path", *configPath)
+			go watchConfig(*configPath, configWatchInterval, stopWatching, reload)
+		}
+	}
+
+	// Shut down gracefully on SIGINT or SIGTERM: stop accepting requests, let
+	// active ones finish and save the collected data
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	for {
+		if sig := <-signals; sig != syscall.SIGHUP {
+			break
+		}
+		reload()
+	}
+	slog.Info("Shutting down")
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := proxyServer.Shutdown(ctx); err != nil {
+		slog.Warn("Failed to shut down proxy server", "error", err)
+	}
+	if remote != nil {
+		remote.Close()
+	}
+	if err := analyzerServer.Shutdown(ctx); err != nil {
+		slog.Warn("Failed to shut down analyzer server", "error", err)
+	}
+	if err := analyzerInstance.Save(); err != nil {
+		slog.Warn("Failed to save analyzer state", "error", err)
 	}
+	analyzerInstance.Stop()
 }
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/tienanr/docurift/internal/analyzer"
+)
+
+// runMock serves the examples of a saved analyzer state as a mock backend
+func runMock(args []string) {
+	flags := flag.NewFlagSet("mock", flag.ExitOnError)
+	statePath := flags.String("state", "analyzer.json", "Path to the analyzer.json state file")
+	port := flags.Int("port", 9999, "Mock server port")
+	quiet := flags.Bool("quiet", false, "Only log warnings and errors")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: docurift mock [-state analyzer.json] [-port 9999]\n\n")
+		fmt.Fprintf(flags.Output(), "Answers requests with the examples recorded in a saved analyzer state.\n\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	slog.SetDefault(newLogger("info", "text", *quiet))
+
+	endpoints, err := analyzer.LoadStateFile(*statePath)
+	if err != nil {
+		log.Fatalf("Failed to load analyzer state: %v", err)
+	}
+	if err := checkPortAvailable(*port, "mock"); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	mockServer := analyzer.NewMockServer(endpoints)
+	server := &http.Server{
+		Addr: fmt.Sprintf(":%d", *port),
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			crw := &customResponseWriter{ResponseWriter: w, statusCode: 200}
+			mockServer.ServeHTTP(crw, req)
+			slog.Info("Mocked request", "method", req.Method, "url", req.URL.String(), "status", crw.statusCode)
+		}),
+	}
+	go func() {
+		slog.Info("Starting mock server", "addr", server.Addr, "state", *statePath, "endpoints", len(endpoints))
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start mock server: %v", err)
+		}
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+	slog.Info("Shutting down")
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Warn("Failed to shut down mock server", "error", err)
+	}
+}
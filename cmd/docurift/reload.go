@@ -0,0 +1,74 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/tienanr/docurift/internal/analyzer"
+	"github.com/tienanr/docurift/internal/config"
+)
+
+// configWatchInterval is how often the configuration file is checked for
+// changes with -watch
+const configWatchInterval = 2 * time.Second
+
+// reloadConfig loads the configuration file again and applies the settings
+// that can change while running. Settings that need a restart keep their
+// current values, and an invalid file leaves the running configuration as is.
+// It reports whether the configuration was reloaded.
+func reloadConfig(a *analyzer.Analyzer, configPath string, overrides config.Overrides, current *atomic.Pointer[config.Config], quiet bool) bool {
+	next, err := config.LoadConfigWithOverrides(configPath, overrides)
+	if err != nil {
+		slog.Warn("Failed to reload configuration, keeping the current one", "error", err)
+		return false
+	}
+	if changed := next.RestoreStatic(current.Load()); len(changed) > 0 {
+		slog.Warn("Ignoring changed settings that require a restart", "settings", changed)
+	}
+	if err := configureAnalyzer(a, next); err != nil {
+		slog.Warn("Failed to reload configuration, keeping the current one", "error", err)
+		return false
+	}
+	slog.SetDefault(newLogger(next.Logging.Level, next.Logging.Format, quiet))
+	current.Store(next)
+	a.RecordReload()
+	slog.Info("Reloaded configuration")
+	return true
+}
+
+// watchConfig calls reload whenever the modification time or size of the
+// configuration file changes, checking every interval until stop is closed.
+// A file caught while being written fails validation and is reloaded again
+// once the write completes and changes its modification time.
+func watchConfig(path string, interval time.Duration, stop <-chan struct{}, reload func()) {
+	modTime, size := fileVersion(path)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			nextModTime, nextSize := fileVersion(path)
+			if nextModTime.Equal(modTime) && nextSize == size {
+				continue
+			}
+			modTime, size = nextModTime, nextSize
+			if !modTime.IsZero() {
+				reload()
+			}
+		}
+	}
+}
+
+// fileVersion returns the modification time and size of a file, which are
+// zero if it doesn't exist, e.g. while an editor replaces it
+func fileVersion(path string) (time.Time, int64) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, 0
+	}
+	return info.ModTime(), info.Size()
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tienanr/docurift/internal/analyzer"
+	"github.com/tienanr/docurift/internal/config"
+)
+
+func TestWatchConfig(t *testing.T) {
+	path := writeConfig(t, 9876, 9877, "http://localhost:8080", t.TempDir())
+	cfg, err := config.LoadConfig(path)
+	require.NoError(t, err)
+	var current atomic.Pointer[config.Config]
+	current.Store(cfg)
+
+	a := analyzer.NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	require.NoError(t, configureAnalyzer(a, cfg))
+	body := []byte(`{"password":"hunter2"}`)
+	require.Contains(t, a.RedactBody(body, 0), "hunter2")
+
+	reloaded := make(chan bool, 10)
+	stop := make(chan struct{})
+	defer close(stop)
+	go watchConfig(path, 10*time.Millisecond, stop, func() {
+		reloaded <- reloadConfig(a, path, config.Overrides{}, &current, true)
+	})
+	waitReload := func() bool {
+		select {
+		case ok := <-reloaded:
+			return ok
+		case <-time.After(5 * time.Second):
+			t.Fatal("Configuration was not reloaded")
+			return false
+		}
+	}
+
+	// A partially written file is rejected and the running configuration kept
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	time.Sleep(50 * time.Millisecond) // Let the watcher record the current version
+	require.NoError(t, os.WriteFile(path, []byte("proxy:\n    port: [\n"), 0644))
+	assert.False(t, waitReload())
+	assert.Contains(t, a.RedactBody(body, 0), "hunter2")
+
+	// Redacted fields apply immediately, the port only on restart
+	updated := strings.Replace(string(data), "port: 9876", "port: 9000", 1) + "    redacted-fields: [password]\n"
+	require.NoError(t, os.WriteFile(path, []byte(updated), 0644))
+	assert.True(t, waitReload())
+	assert.NotContains(t, a.RedactBody(body, 0), "hunter2")
+	assert.Equal(t, []string{"password"}, current.Load().Analyzer.RedactedFields)
+	assert.Equal(t, 9876, current.Load().Proxy.Port)
+
+	// A complete but invalid file is rejected as a whole
+	invalid := updated + "    sampling: bogus\n"
+	invalid = strings.Replace(invalid, "redacted-fields: [password]", "redacted-fields: []", 1)
+	require.NoError(t, os.WriteFile(path, []byte(invalid), 0644))
+	assert.False(t, waitReload())
+	assert.NotContains(t, a.RedactBody(body, 0), "hunter2")
+	assert.Equal(t, []string{"password"}, current.Load().Analyzer.RedactedFields)
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tienanr/docurift/internal/analyzer"
+	"github.com/tienanr/docurift/internal/config"
+)
+
+// backendCheckTimeout is how long the backend may take to accept a connection
+// when checking the configuration
+const backendCheckTimeout = 3 * time.Second
+
+// configChecks selects the checks run besides loading the configuration
+type configChecks struct {
+	Ports   bool // Whether the proxy and analyzer ports are available
+	Backend bool // Whether the backend URL is valid and accepts connections
+	Storage bool // Whether the state can be written to the storage path
+}
+
+// configCheck is the outcome of one check of a configuration
+type configCheck struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// configReport is the outcome of validating a configuration
+type configReport struct {
+	Config string        `json:"config"`
+	Valid  bool          `json:"valid"`
+	Checks []configCheck `json:"checks"`
+}
+
+// add records the outcome of a check, which failed if err is not nil
+func (r *configReport) add(name string, err error) {
+	check := configCheck{Name: name, OK: err == nil}
+	if err != nil {
+		check.Error = err.Error()
+		r.Valid = false
+	}
+	r.Checks = append(r.Checks, check)
+}
+
+// write prints the report as text, or as JSON for machine consumption
+func (r *configReport) write(w io.Writer, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r)
+	}
+	for _, check := range r.Checks {
+		if check.OK {
+			fmt.Fprintf(w, "PASS  %s\n", check.Name)
+		} else {
+			fmt.Fprintf(w, "FAIL  %s: %s\n", check.Name, check.Error)
+		}
+	}
+	if r.Valid {
+		_, err := fmt.Fprintf(w, "\nConfiguration %s is valid\n", r.Config)
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\nConfiguration %s is invalid\n", r.Config)
+	return err
+}
+
+// validateConfig loads and validates a configuration, including the cross
+// field checks of internal/config, then runs the selected checks
+func validateConfig(configPath string, overrides config.Overrides, checks configChecks) *configReport {
+	report := &configReport{Config: configPath, Valid: true}
+	if configPath == "" {
+		report.Config = "from flags and environment"
+	}
+
+	cfg, err := config.LoadConfigWithOverrides(configPath, overrides)
+	report.add("configuration", err)
+	if err != nil {
+		return report
+	}
+
+	if checks.Backend {
+		report.add("backend", checkBackend(cfg.Proxy.BackendURL))
+	}
+	if checks.Storage {
+		report.add("storage", checkStorageWritable(cfg))
+	}
+	if checks.Ports {
+		report.add("proxy port", checkPortAvailable(cfg.Proxy.Port, "proxy"))
+		report.add("analyzer port", checkPortAvailable(cfg.Analyzer.Port, "analyzer"))
+	}
+	return report
+}
+
+// checkBackend checks that the backend URL is an absolute HTTP URL whose host
+// accepts connections
+func checkBackend(backendURL string) error {
+	parsed, err := url.Parse(backendURL)
+	if err != nil {
+		return fmt.Errorf("invalid backend URL: %w", err)
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("backend URL %q must be an absolute http or https URL", backendURL)
+	}
+	port := parsed.Port()
+	if port == "" {
+		port = "80"
+		if parsed.Scheme == "https" {
+			port = "443"
+		}
+	}
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(parsed.Hostname(), port), backendCheckTimeout)
+	if err != nil {
+		return fmt.Errorf("backend %s is unreachable: %w", backendURL, err)
+	}
+	return conn.Close()
+}
+
+// checkBackendAtStartup probes the backend health path before accepting
+// traffic, so a wrong backend URL is reported clearly instead of as a stream
+// of 502 responses
+func checkBackendAtStartup(cfg *config.Config) error {
+	health := analyzer.ProbeBackend(cfg.Proxy.BackendURL, cfg.Proxy.HealthCheck.Path)
+	if !health.Reachable {
+		return fmt.Errorf("backend %s is unreachable: %s", health.URL, health.Error)
+	}
+	return nil
+}
+
+// checkStorageWritable checks that a file can be created where the state is
+// saved. Object storage is not checked, as that would need credentials.
+func checkStorageWritable(cfg *config.Config) error {
+	storage := cfg.Analyzer.Storage
+	dir := storage.Path
+	switch storage.Type {
+	case analyzer.StorageTypeS3:
+		return nil
+	case analyzer.StorageTypeSQLite:
+		dir = filepath.Dir(storage.Path)
+	}
+
+	file, err := os.CreateTemp(dir, ".docurift-check-*")
+	if err != nil {
+		return fmt.Errorf("storage path %s is not writable: %w", dir, err)
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
+
+// runValidate validates a configuration without starting DocuRift and returns
+// the exit code: 0 if it is valid and 1 otherwise
+func runValidate(args []string) int {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	configPath := flags.String("config", "", "Path to configuration file")
+	skipPorts := flags.Bool("skip-ports", false, "Don't check that the proxy and analyzer ports are available")
+	asJSON := flags.Bool("json", false, "Print the report as JSON")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: docurift validate -config <config-file> [-skip-ports] [-json]\n\n")
+		fmt.Fprintf(flags.Output(), "Validates a configuration without starting DocuRift. Environment overrides apply.\n\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	report := validateConfig(*configPath, config.Overrides{}, configChecks{Ports: !*skipPorts})
+	return report.exit(*asJSON)
+}
+
+// exit prints the report and returns the exit code: 0 if the configuration
+// is valid and 1 otherwise
+func (r *configReport) exit(asJSON bool) int {
+	if err := r.write(os.Stdout, asJSON); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to print the report: %v\n", err)
+		return 1
+	}
+	if !r.Valid {
+		return 1
+	}
+	return 0
+}
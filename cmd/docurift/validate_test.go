@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tienanr/docurift/internal/config"
+)
+
+// freePort returns a port that was available when it was chosen
+func freePort(t *testing.T) int {
+	ln, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+// writeConfig writes a configuration file and returns its path
+func writeConfig(t *testing.T, proxyPort, analyzerPort int, backendURL, storagePath string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := fmt.Sprintf(`
+proxy:
+    port: %d
+    backend-url: %s
+analyzer:
+    port: %d
+    max-examples: 10
+    storage:
+        path: %s
+`, proxyPort, backendURL, analyzerPort, storagePath)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+// failedChecks returns the names of the failed checks of a report
+func failedChecks(report *configReport) []string {
+	var failed []string
+	for _, check := range report.Checks {
+		if !check.OK {
+			failed = append(failed, check.Name)
+		}
+	}
+	return failed
+}
+
+func TestValidateConfig(t *testing.T) {
+	backend := httptest.NewServer(http.NotFoundHandler())
+	defer backend.Close()
+	all := configChecks{Ports: true, Backend: true, Storage: true}
+
+	t.Run("valid", func(t *testing.T) {
+		path := writeConfig(t, freePort(t), freePort(t), backend.URL, t.TempDir())
+		report := validateConfig(path, config.Overrides{}, all)
+		assert.True(t, report.Valid)
+		assert.Empty(t, failedChecks(report))
+		assert.Len(t, report.Checks, 5)
+
+		var output bytes.Buffer
+		require.NoError(t, report.write(&output, false))
+		assert.Contains(t, output.String(), "PASS  backend\n")
+		assert.Contains(t, output.String(), "Configuration "+path+" is valid\n")
+	})
+
+	t.Run("invalid configuration", func(t *testing.T) {
+		port := freePort(t)
+		path := writeConfig(t, port, port, backend.URL, t.TempDir())
+		report := validateConfig(path, config.Overrides{}, all)
+		assert.False(t, report.Valid)
+		require.Len(t, report.Checks, 1, "no other check runs without a configuration")
+		assert.Equal(t, fmt.Sprintf("proxy and analyzer cannot use the same port (%d)", port), report.Checks[0].Error)
+	})
+
+	t.Run("unreachable backend", func(t *testing.T) {
+		path := writeConfig(t, freePort(t), freePort(t), fmt.Sprintf("http://127.0.0.1:%d", freePort(t)), t.TempDir())
+		report := validateConfig(path, config.Overrides{}, all)
+		assert.False(t, report.Valid)
+		assert.Equal(t, []string{"backend"}, failedChecks(report))
+
+		path = writeConfig(t, freePort(t), freePort(t), "localhost:8080", t.TempDir())
+		assert.Equal(t, []string{"backend"}, failedChecks(validateConfig(path, config.Overrides{}, all)))
+	})
+
+	t.Run("storage not writable", func(t *testing.T) {
+		path := writeConfig(t, freePort(t), freePort(t), backend.URL, filepath.Join(t.TempDir(), "missing"))
+		report := validateConfig(path, config.Overrides{}, all)
+		assert.Equal(t, []string{"storage"}, failedChecks(report))
+	})
+
+	t.Run("port in use", func(t *testing.T) {
+		ln, err := net.Listen("tcp", ":0")
+		require.NoError(t, err)
+		defer ln.Close()
+		path := writeConfig(t, ln.Addr().(*net.TCPAddr).Port, freePort(t), backend.URL, t.TempDir())
+		report := validateConfig(path, config.Overrides{}, all)
+		assert.Equal(t, []string{"proxy port"}, failedChecks(report))
+
+		// Ports are only checked when asked to
+		assert.True(t, validateConfig(path, config.Overrides{}, configChecks{Backend: true, Storage: true}).Valid)
+
+		var output bytes.Buffer
+		require.NoError(t, report.write(&output, true))
+		var decoded configReport
+		require.NoError(t, json.Unmarshal(output.Bytes(), &decoded))
+		assert.Equal(t, *report, decoded)
+	})
+}
+
+func TestCheckBackendAtStartup(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	cfg, err := config.LoadConfig(writeConfig(t, 9876, 9877, backend.URL, t.TempDir()))
+	require.NoError(t, err)
+
+	assert.NoError(t, checkBackendAtStartup(cfg))
+
+	cfg.Proxy.HealthCheck.Path = "/broken"
+	err = checkBackendAtStartup(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), backend.URL+"/broken")
+	assert.Contains(t, err.Error(), "status 503")
+
+	backend.Close()
+	cfg.Proxy.HealthCheck.Path = "/"
+	assert.Error(t, checkBackendAtStartup(cfg))
+}
@@ -0,0 +1,111 @@
+package analyzer
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// authCookieName is the cookie holding the bearer token for browser sessions
+const authCookieName = "docurift_token"
+
+// AuthConfig configures authentication of the analyzer server. Requests are
+// accepted with the bearer token or the basic auth credentials.
+type AuthConfig struct {
+	Token       string   // Static bearer token
+	Username    string   // Basic auth user name
+	Password    string   // Basic auth password
+	ExemptPaths []string // Paths served without authentication, e.g. /api/health
+}
+
+// enabled checks if any credentials are configured
+func (c AuthConfig) enabled() bool {
+	return c.Token != "" || c.Username != ""
+}
+
+// setCredentials adds the configured credentials to an outgoing request
+func (c AuthConfig) setCredentials(req *http.Request) {
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	} else if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+}
+
+// SetAuth requires the given credentials for the API and the UI
+func (s *Server) SetAuth(auth AuthConfig) {
+	s.auth = auth
+}
+
+// requireAuth wraps a handler, rejecting unauthenticated requests with 401
+func (s *Server) requireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// CORS preflight requests never carry credentials
+		if !s.auth.enabled() || r.Method == http.MethodOptions || s.isExemptPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// A token passed in the query string, e.g. when opening the UI from a
+		// link, is kept in a cookie so the UI's own API requests are accepted
+		if token := r.URL.Query().Get("token"); token != "" && s.validToken(token) {
+			http.SetCookie(w, &http.Cookie{
+				Name:     authCookieName,
+				Value:    token,
+				Path:     "/",
+				HttpOnly: true,
+				SameSite: http.SameSiteStrictMode,
+			})
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if s.authenticated(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if s.auth.Username != "" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="DocuRift", charset="UTF-8"`)
+		} else {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="DocuRift"`)
+		}
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	})
+}
+
+// authenticated checks the bearer token, token cookie or basic auth credentials of a request
+func (s *Server) authenticated(r *http.Request) bool {
+	if s.auth.Token != "" {
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && s.validToken(token) {
+			return true
+		}
+		if cookie, err := r.Cookie(authCookieName); err == nil && s.validToken(cookie.Value) {
+			return true
+		}
+	}
+	if s.auth.Username != "" {
+		username, password, ok := r.BasicAuth()
+		if ok &&
+			subtle.ConstantTimeCompare([]byte(username), []byte(s.auth.Username)) == 1 &&
+			subtle.ConstantTimeCompare([]byte(password), []byte(s.auth.Password)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// validToken compares a token with the configured bearer token in constant time
+func (s *Server) validToken(token string) bool {
+	return s.auth.Token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.auth.Token)) == 1
+}
+
+// isExemptPath checks if a path is served without authentication
+func (s *Server) isExemptPath(path string) bool {
+	for _, exempt := range s.auth.ExemptPaths {
+		if path == exempt {
+			return true
+		}
+	}
+	return false
+}
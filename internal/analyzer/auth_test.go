@@ -0,0 +1,119 @@
+package analyzer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireAuth(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	serve := func(s *Server, req *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.requireAuth(ok).ServeHTTP(w, req)
+		return w
+	}
+
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+
+	t.Run("Disabled", func(t *testing.T) {
+		s := NewServer(a)
+		assert.Equal(t, http.StatusOK, serve(s, httptest.NewRequest("GET", "/api/analyzer", nil)).Code)
+	})
+
+	t.Run("Bearer Token", func(t *testing.T) {
+		s := NewServer(a)
+		s.SetAuth(AuthConfig{Token: "s3cret", ExemptPaths: []string{"/api/health"}})
+
+		w := serve(s, httptest.NewRequest("GET", "/api/analyzer", nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, `Bearer realm="DocuRift"`, w.Header().Get("WWW-Authenticate"))
+
+		req := httptest.NewRequest("GET", "/api/openapi.json", nil)
+		req.Header.Set("Authorization", "Bearer wrong")
+		assert.Equal(t, http.StatusUnauthorized, serve(s, req).Code)
+
+		req = httptest.NewRequest("GET", "/api/openapi.json", nil)
+		req.Header.Set("Authorization", "Bearer s3cret")
+		assert.Equal(t, http.StatusOK, serve(s, req).Code)
+
+		// The UI is protected too
+		assert.Equal(t, http.StatusUnauthorized, serve(s, httptest.NewRequest("GET", "/", nil)).Code)
+
+		// Exempt paths and CORS preflight requests are open
+		assert.Equal(t, http.StatusOK, serve(s, httptest.NewRequest("GET", "/api/health", nil)).Code)
+		assert.Equal(t, http.StatusOK, serve(s, httptest.NewRequest("OPTIONS", "/api/analyzer", nil)).Code)
+	})
+
+	t.Run("Token Cookie", func(t *testing.T) {
+		s := NewServer(a)
+		s.SetAuth(AuthConfig{Token: "s3cret"})
+
+		assert.Equal(t, http.StatusUnauthorized, serve(s, httptest.NewRequest("GET", "/swagger?token=wrong", nil)).Code)
+
+		// Opening a page with the token stores it in a cookie for the page's own requests
+		w := serve(s, httptest.NewRequest("GET", "/swagger?token=s3cret", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		cookies := w.Result().Cookies()
+		require.Len(t, cookies, 1)
+		assert.Equal(t, authCookieName, cookies[0].Name)
+		assert.True(t, cookies[0].HttpOnly)
+
+		req := httptest.NewRequest("GET", "/api/openapi.json", nil)
+		req.AddCookie(cookies[0])
+		assert.Equal(t, http.StatusOK, serve(s, req).Code)
+	})
+
+	t.Run("Basic Auth", func(t *testing.T) {
+		s := NewServer(a)
+		s.SetAuth(AuthConfig{Username: "admin", Password: "pa55"})
+
+		w := serve(s, httptest.NewRequest("GET", "/api/analyzer", nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Header().Get("WWW-Authenticate"), `Basic realm="DocuRift"`)
+
+		req := httptest.NewRequest("GET", "/api/analyzer", nil)
+		req.SetBasicAuth("admin", "wrong")
+		assert.Equal(t, http.StatusUnauthorized, serve(s, req).Code)
+
+		req = httptest.NewRequest("GET", "/api/analyzer", nil)
+		req.SetBasicAuth("admin", "pa55")
+		assert.Equal(t, http.StatusOK, serve(s, req).Code)
+
+		// A bearer token is not accepted when only basic auth is configured
+		req = httptest.NewRequest("GET", "/api/analyzer", nil)
+		req.Header.Set("Authorization", "Bearer pa55")
+		assert.Equal(t, http.StatusUnauthorized, serve(s, req).Code)
+	})
+}
+
+func TestRemoteClientAuth(t *testing.T) {
+	central := NewAnalyzer(t.TempDir(), 3600)
+	defer central.Stop()
+	server := NewServer(central)
+	server.SetAuth(AuthConfig{Token: "s3cret"})
+
+	analyzerServer := httptest.NewServer(server.requireAuth(http.HandlerFunc(server.handleIngest)))
+	defer analyzerServer.Close()
+
+	record := IngestRecord{Method: "GET", URL: "http://api.example.com/api/users", Status: 200}
+
+	// Without credentials the records are rejected
+	remote := newTestRemoteClient(analyzerServer.URL, 10, AuthConfig{})
+	remote.Add(record)
+	remote.Close()
+	assert.Equal(t, int64(1), remote.Dropped())
+	assert.Empty(t, central.GetData())
+
+	remote = newTestRemoteClient(analyzerServer.URL, 10, AuthConfig{Token: "s3cret"})
+	remote.Add(record)
+	remote.Close()
+	assert.Equal(t, int64(1), remote.Sent())
+	assert.Contains(t, central.GetData(), "GET /api/users")
+}
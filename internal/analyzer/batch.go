@@ -0,0 +1,157 @@
+package analyzer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// batchPoster queues items and posts them in batches to a URL from its own
+// goroutine, so a slow or unreachable receiver never delays the caller. A
+// batch is posted once it is full or flushInterval after its first item, and
+// failed requests are retried with exponential backoff when the receiver may
+// accept them later, i.e. on network errors, 5xx and 429 responses.
+type batchPoster[T any] struct {
+	url           string
+	client        *http.Client
+	batchSize     int                             // Items posted in a single request, unlimited if 0
+	flushInterval time.Duration                   // Longest time an item waits for its batch to be posted
+	maxAttempts   int                             // Attempts per batch, including the first one
+	backoff       time.Duration                   // Delay before the first retry, doubled on each attempt
+	encode        func(batch []T) ([]byte, error) // Encodes the JSON body of a batch
+	authorize     func(req *http.Request)         // Sets the credentials of requests, if any
+	receiver      string                          // What items are posted to, e.g. "webhook", for errors
+	description   string                          // What the items are, e.g. "change notifications", for logs
+
+	items    chan T
+	stopChan chan struct{}
+	done     chan struct{}
+	sent     atomic.Int64
+	dropped  atomic.Int64
+}
+
+// start creates the queue of the poster, holding up to queueSize items, and
+// starts its sending goroutine
+func (p *batchPoster[T]) start(queueSize int) *batchPoster[T] {
+	p.items = make(chan T, queueSize)
+	p.stopChan = make(chan struct{})
+	p.done = make(chan struct{})
+	go p.run()
+	return p
+}
+
+// Add queues an item. The item is dropped if the queue is full.
+func (p *batchPoster[T]) Add(item T) {
+	select {
+	case p.items <- item:
+	default:
+		if p.dropped.Add(1) == 1 {
+			slog.Warn("Queue is full, dropping "+p.description, "url", p.url)
+		}
+	}
+}
+
+// Close posts the queued items and stops the poster
+func (p *batchPoster[T]) Close() {
+	close(p.stopChan)
+	<-p.done
+}
+
+// run collects items for the flush interval following the first one, or until
+// the batch is full, then posts them in a single request
+func (p *batchPoster[T]) run() {
+	defer close(p.done)
+
+	var batch []T
+	var flush <-chan time.Time
+	for {
+		select {
+		case item := <-p.items:
+			batch = append(batch, item)
+			if p.batchSize > 0 && len(batch) >= p.batchSize {
+				p.send(batch)
+				batch, flush = nil, nil
+			} else if flush == nil {
+				flush = time.After(p.flushInterval)
+			}
+		case <-flush:
+			p.send(batch)
+			batch, flush = nil, nil
+		case <-p.stopChan:
+			for {
+				select {
+				case item := <-p.items:
+					batch = append(batch, item)
+				default:
+					if len(batch) > 0 {
+						p.send(batch)
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+// send posts a batch, retrying failed requests with exponential backoff
+func (p *batchPoster[T]) send(batch []T) {
+	body, err := p.encode(batch)
+	if err != nil {
+		slog.Warn("Failed to encode "+p.description, "error", err)
+		p.dropped.Add(int64(len(batch)))
+		return
+	}
+
+	backoff := p.backoff
+	for attempt := 1; ; attempt++ {
+		retry, err := p.post(body)
+		if err == nil {
+			p.sent.Add(int64(len(batch)))
+			return
+		}
+		if !retry || attempt >= p.maxAttempts {
+			slog.Warn("Failed to send "+p.description, "count", len(batch), "url", p.url, "error", err)
+			p.dropped.Add(int64(len(batch)))
+			return
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-p.stopChan:
+			// Don't delay shutdown by waiting between retries
+		}
+		backoff *= 2
+	}
+}
+
+// post sends an encoded batch, reporting whether a failure may be retried
+func (p *batchPoster[T]) post(body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.authorize != nil {
+		p.authorize(req)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+		return true, fmt.Errorf("%s responded with status %d: %s", p.receiver, resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return false, fmt.Errorf("%s rejected the %s with status %d: %s", p.receiver, p.description, resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return false, nil
+}
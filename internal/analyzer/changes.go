@@ -0,0 +1,255 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Kinds of entries of the change log
+const (
+	ChangeEndpointFirstSeen = "endpoint-first-seen" // A request to a new endpoint was analyzed
+	ChangeNewStatusCode     = "new-status-code"     // An endpoint answered with a new status code
+	ChangeNewField          = "new-field"           // A body contained a new field, or one that had disappeared
+	ChangeFieldTypeChanged  = "field-type-changed"  // A field held a value of a type not observed before
+	ChangeFieldDisappeared  = "field-disappeared"   // A field was absent from the last fieldWindow bodies
+)
+
+const (
+	// maxEndpointChanges is the number of change log entries kept per
+	// endpoint, older entries are dropped first
+	maxEndpointChanges = 100
+	// fieldWindow is the number of consecutive bodies a field must be absent
+	// from to be reported as disappeared
+	fieldWindow = 100
+)
+
+// SchemaChange is an entry of the change log of an endpoint
+type SchemaChange struct {
+	Time     time.Time `json:"time"`
+	Kind     string    `json:"kind"`
+	Location string    `json:"location,omitempty"` // "request" or "response" followed by the status code
+	Field    string    `json:"field,omitempty"`    // Schema path of the field, e.g. items[].discount_code
+	Detail   string    `json:"detail,omitempty"`   // Previous and new types of a type change
+}
+
+// ChangeLogEntry is a change of an endpoint returned by GET /api/changes
+type ChangeLogEntry struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	SchemaChange
+}
+
+// ChangeLog is the response of GET /api/changes
+type ChangeLog struct {
+	Changes []ChangeLogEntry `json:"changes"`
+}
+
+// fieldTracker follows the fields of the bodies added to a schema store to
+// report new, retyped and disappeared fields. It is guarded by the store lock
+// and is not persisted, so after a restart fields get a full window again
+// before being reported as disappeared.
+type fieldTracker struct {
+	active   bool                       // Whether bodies were observed before, so new fields are changes
+	bodies   int                        // Number of finished bodies
+	lastSeen map[string]int             // Field -> index of the last body containing it
+	types    map[string]map[string]bool // Field -> JSON types observed
+	gone     map[string]bool            // Fields reported as disappeared
+	changes  []SchemaChange             // Changes found in the current body
+}
+
+// trackChanges starts following the fields of the bodies added to the store
+func (s *SchemaStore) trackChanges() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tracker == nil {
+		s.tracker = &fieldTracker{
+			// A store loaded from a saved state already knows its fields
+			active:   len(s.Examples) > 0,
+			lastSeen: make(map[string]int),
+			types:    make(map[string]map[string]bool),
+			gone:     make(map[string]bool),
+		}
+	}
+}
+
+// finishBody marks the end of a body added to the store and returns the
+// changes it brought, including the fields it was the last body missing
+func (s *SchemaStore) finishBody() []SchemaChange {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.tracker
+	if t == nil {
+		return nil
+	}
+
+	for _, path := range sortedKeys(s.Examples) {
+		seen, exists := t.lastSeen[path]
+		if !exists {
+			// Field loaded from a saved state
+			t.lastSeen[path] = t.bodies
+			continue
+		}
+		if !t.gone[path] && t.bodies-seen >= fieldWindow {
+			t.gone[path] = true
+			t.changes = append(t.changes, SchemaChange{Kind: ChangeFieldDisappeared, Field: path})
+		}
+	}
+
+	t.bodies++
+	t.active = true
+	changes := t.changes
+	t.changes = nil
+	return changes
+}
+
+// observe records a value of a field of the current body. known tells whether
+// the store had the field before and examples are its retained examples.
+func (t *fieldTracker) observe(path string, value interface{}, known bool, examples []interface{}) {
+	t.lastSeen[path] = t.bodies
+	if !known || t.gone[path] {
+		if t.active {
+			t.changes = append(t.changes, SchemaChange{Kind: ChangeNewField, Field: path})
+		}
+		delete(t.gone, path)
+	}
+
+	valueType := jsonTypeName(value)
+	if valueType == "" {
+		return
+	}
+	types, exists := t.types[path]
+	if !exists {
+		types = make(map[string]bool)
+		for _, example := range examples {
+			if exampleType := jsonTypeName(example); exampleType != "" {
+				types[exampleType] = true
+			}
+		}
+		t.types[path] = types
+	}
+	if !types[valueType] {
+		if t.active && len(types) > 0 {
+			t.changes = append(t.changes, SchemaChange{
+				Kind:   ChangeFieldTypeChanged,
+				Field:  path,
+				Detail: strings.Join(sortedKeys(types), "|") + " -> " + valueType,
+			})
+		}
+		types[valueType] = true
+	}
+}
+
+// jsonTypeName returns the JSON type of a decoded value, or an empty string for null
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return ""
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64, int, int64:
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// addChanges appends changes found at location to the change log of the
+// endpoint, dropping the oldest entries beyond maxEndpointChanges, and
+// returns the added entries. The caller holds e.mu.
+func (e *EndpointData) addChanges(now time.Time, location string, changes ...SchemaChange) []SchemaChange {
+	for i := range changes {
+		changes[i].Time = now
+		changes[i].Location = location
+	}
+	e.Changes = append(e.Changes, changes...)
+	if excess := len(e.Changes) - maxEndpointChanges; excess > 0 {
+		e.Changes = append([]SchemaChange(nil), e.Changes[excess:]...)
+	}
+	return changes
+}
+
+// recordBodyChanges finishes a body added to store, adds its changes to the
+// change log of the endpoint and returns them
+func (e *EndpointData) recordBodyChanges(store *SchemaStore, now time.Time, location string) []SchemaChange {
+	changes := store.finishBody()
+	if len(changes) == 0 {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.addChanges(now, location, changes...)
+}
+
+// changesSince returns the change log entries of the endpoint recorded at or after since
+func (e *EndpointData) changesSince(since time.Time) []ChangeLogEntry {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var entries []ChangeLogEntry
+	for _, change := range e.Changes {
+		if !change.Time.Before(since) {
+			entries = append(entries, ChangeLogEntry{Method: e.Method, Path: e.URL, SchemaChange: change})
+		}
+	}
+	return entries
+}
+
+// responseLocation returns the change log location of the response with a status code
+func responseLocation(status int) string {
+	return fmt.Sprintf("response %d", status)
+}
+
+// Changes returns the change log entries of all endpoints recorded at or
+// after since, oldest first
+func (a *Analyzer) Changes(since time.Time) []ChangeLogEntry {
+	entries := a.endpoints.changesSince(since)
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].Time.Equal(entries[j].Time) {
+			return entries[i].Time.Before(entries[j].Time)
+		}
+		if entries[i].Path != entries[j].Path {
+			return entries[i].Path < entries[j].Path
+		}
+		return entries[i].Method < entries[j].Method
+	})
+	return entries
+}
+
+// handleChanges returns the change log, limited to the entries recorded since
+// an RFC 3339 time given as ?since=
+func (s *Server) handleChanges(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Add CORS headers
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+
+	var since time.Time
+	if value := r.URL.Query().Get("since"); value != "" {
+		var err error
+		if since, err = time.Parse(time.RFC3339, value); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid since %q, expected an RFC 3339 time", value), http.StatusBadRequest)
+			return
+		}
+	}
+
+	changes := s.analyzer.Changes(since)
+	if changes == nil {
+		changes = []ChangeLogEntry{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ChangeLog{Changes: changes})
+}
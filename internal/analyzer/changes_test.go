@@ -0,0 +1,130 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// changeSummaries returns the changes as "kind location field detail" strings
+func changeSummaries(entries []ChangeLogEntry) []string {
+	summaries := make([]string, len(entries))
+	for i, entry := range entries {
+		summaries[i] = fmt.Sprintf("%s %s %s %s %s %s", entry.Method, entry.Path, entry.Kind, entry.Location, entry.Field, entry.Detail)
+	}
+	return summaries
+}
+
+func TestChangeLog(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	a.SetCaptureErrors(true)
+	process := func(status int, reqBody, respBody string) {
+		req := httptest.NewRequest("POST", "https://example.com/orders", nil)
+		req.Header.Set("Content-Type", "application/json")
+		var body []byte
+		if reqBody != "" {
+			body = []byte(reqBody)
+		}
+		a.ProcessRequest("POST", "https://example.com/orders", req, &http.Response{StatusCode: status}, body, []byte(respBody))
+	}
+
+	// The fields of the first bodies are not changes
+	process(201, `{"sku":"A-1"}`, `{"id":1,"total":9.5}`)
+	assert.Equal(t, []string{"POST /orders endpoint-first-seen   "}, changeSummaries(a.Changes(time.Time{})))
+
+	process(201, `{"sku":"A-1","qty":2}`, `{"id":2,"total":"9.50"}`)
+	process(201, `{"sku":"A-1","qty":2}`, `{"id":3,"total":"9.50","items":[{"discount_code":"SPRING"}]}`)
+	process(422, "", `{"error":"invalid sku"}`)
+	process(201, `{"sku":"A-1","qty":2}`, `{"id":4,"total":9.5}`)
+	assert.Equal(t, []string{
+		"POST /orders endpoint-first-seen   ",
+		"POST /orders new-field request qty ",
+		"POST /orders field-type-changed response 201 total number -> string",
+		"POST /orders new-field response 201 items[].discount_code ",
+		"POST /orders new-status-code response 422  ",
+	}, changeSummaries(a.Changes(time.Time{})))
+
+	// Fields absent from a whole window of bodies disappear and may come back
+	for i := 0; i < fieldWindow; i++ {
+		process(201, `{"sku":"A-1","qty":2}`, `{"id":5,"total":9.5}`)
+	}
+	since := time.Now()
+	process(201, `{"sku":"A-1","qty":2}`, `{"id":6,"total":9.5,"items":[{"discount_code":"SPRING"}]}`)
+	process(201, `{"sku":"A-1","qty":2}`, `{"id":7,"total":9.5}`)
+	changes := a.Changes(time.Time{})
+	assert.Equal(t, []string{
+		"POST /orders field-disappeared response 201 items[].discount_code ",
+		"POST /orders new-field response 201 items[].discount_code ",
+	}, changeSummaries(changes[len(changes)-2:]))
+	assert.Equal(t, []string{"POST /orders new-field response 201 items[].discount_code "}, changeSummaries(a.Changes(since)))
+}
+
+func TestChangeLogFieldNamedLikeTruncationMarker(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	process := func(respBody string) {
+		req := httptest.NewRequest("GET", "https://example.com/jobs/1", nil)
+		a.ProcessRequest("GET", "https://example.com/jobs/1", req, &http.Response{StatusCode: 200}, nil, []byte(respBody))
+	}
+
+	process(`{"id":1}`)
+	process(`{"id":1,"_truncated":false}`)
+	process(`{"id":1,"_truncated":"no"}`)
+	assert.Equal(t, []string{
+		"GET /jobs/{id} endpoint-first-seen   ",
+		"GET /jobs/{id} new-field response 200 _truncated ",
+		"GET /jobs/{id} field-type-changed response 200 _truncated boolean -> string",
+	}, changeSummaries(a.Changes(time.Time{})))
+}
+
+func TestChangeLogBounded(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	a.SetCaptureErrors(true)
+	for status := 200; status < 200+2*maxEndpointChanges; status++ {
+		req := httptest.NewRequest("GET", "https://example.com/items", nil)
+		a.ProcessRequest("GET", "https://example.com/items", req, &http.Response{StatusCode: status}, nil, nil)
+	}
+
+	changes := a.Changes(time.Time{})
+	require.Len(t, changes, maxEndpointChanges)
+	assert.Equal(t, responseLocation(200+maxEndpointChanges), changes[0].Location, "oldest entries are dropped first")
+	assert.Equal(t, responseLocation(200+2*maxEndpointChanges-1), changes[len(changes)-1].Location)
+}
+
+func TestHandleChanges(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	req := httptest.NewRequest("GET", "https://example.com/users", nil)
+	a.ProcessRequest("GET", "https://example.com/users", req, &http.Response{StatusCode: 200}, nil, []byte(`[{"id":1}]`))
+	handler := NewServer(a).Handler()
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	w := get("/api/changes")
+	require.Equal(t, http.StatusOK, w.Code)
+	var log ChangeLog
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &log))
+	require.Len(t, log.Changes, 1)
+	assert.Equal(t, ChangeLogEntry{Method: "GET", Path: "/users", SchemaChange: SchemaChange{
+		Time: log.Changes[0].Time,
+		Kind: ChangeEndpointFirstSeen,
+	}}, log.Changes[0])
+
+	w = get("/api/changes?since=" + time.Now().Add(time.Minute).Format(time.RFC3339))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"changes":[]}`, w.Body.String())
+
+	assert.Equal(t, http.StatusBadRequest, get("/api/changes?since=yesterday").Code)
+}
@@ -0,0 +1,230 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+)
+
+// schemaRefPrefix starts the references to component schemas
+const schemaRefPrefix = "#/components/schemas/"
+
+// extractComponents moves the object schemas found more than once in request
+// and response bodies to the component schemas and replaces them with
+// references. Schemas are compared without their examples, enums and
+// descriptions, as those depend on the values observed by each operation: a
+// component keeps the examples and descriptions of its first occurrence and
+// the enum values of all occurrences, up to enumThreshold values. Components
+// are named after where they first appear, e.g. UsersItem for the items of a
+// list returned by /users or Address for an address field, with a counter
+// when names collide.
+func (o *OpenAPI) extractComponents(enumThreshold int) {
+	counts := make(map[string]int)
+	o.visitBodySchemas(func(schema *Schema, _ string) {
+		countObjectSchemas(*schema, counts)
+	})
+	names := make(map[string]string)
+	o.visitBodySchemas(func(schema *Schema, name string) {
+		*schema = o.referenceSchemas(*schema, name, counts, names, enumThreshold)
+	})
+}
+
+// visitBodySchemas calls visit with the schema of every request and response
+// body and the name of its resource, in a fixed order so component names are
+// stable
+func (o *OpenAPI) visitBodySchemas(visit func(schema *Schema, name string)) {
+	visitContent := func(content map[string]MediaType, name string) {
+		for _, mediaType := range sortedKeys(content) {
+			value := content[mediaType]
+			visit(&value.Schema, name)
+			content[mediaType] = value
+		}
+	}
+	for _, path := range sortedKeys(o.Paths) {
+		name := pascalCase(resourceTag(path))
+		if name == "" {
+			name = "Root"
+		}
+		pathItem := o.Paths[path]
+		for _, operation := range []*Operation{pathItem.Get, pathItem.Post, pathItem.Put, pathItem.Delete} {
+			if operation == nil {
+				continue
+			}
+			if operation.RequestBody != nil {
+				visitContent(operation.RequestBody.Content, name)
+			}
+			for _, status := range sortedKeys(operation.Responses) {
+				visitContent(operation.Responses[status].Content, name)
+			}
+		}
+	}
+}
+
+// isComponentCandidate reports whether a schema is an object with properties,
+// which can be moved to the components
+func isComponentCandidate(schema Schema) bool {
+	return schema.Type == "object" && len(schema.Properties) > 0
+}
+
+// countObjectSchemas counts the occurrences of a schema and of the schemas
+// nested in it by structure
+func countObjectSchemas(schema Schema, counts map[string]int) {
+	if isComponentCandidate(schema) {
+		counts[schemaStructure(schema)]++
+	}
+	for _, property := range schema.Properties {
+		countObjectSchemas(property, counts)
+	}
+	if schema.Items != nil {
+		countObjectSchemas(*schema.Items, counts)
+	}
+	for _, alternative := range schema.OneOf {
+		countObjectSchemas(alternative, counts)
+	}
+}
+
+// referenceSchemas returns a schema with the schemas nested in it, and the
+// schema itself, replaced by references if they were counted more than once
+func (o *OpenAPI) referenceSchemas(schema Schema, name string, counts map[string]int, names map[string]string, enumThreshold int) Schema {
+	structure := ""
+	if isComponentCandidate(schema) {
+		structure = schemaStructure(schema)
+	}
+
+	if schema.Properties != nil {
+		properties := make(map[string]Schema, len(schema.Properties))
+		for _, property := range sortedKeys(schema.Properties) {
+			properties[property] = o.referenceSchemas(schema.Properties[property], pascalCase(property), counts, names, enumThreshold)
+		}
+		schema.Properties = properties
+	}
+	if schema.Items != nil {
+		items := o.referenceSchemas(*schema.Items, name+"Item", counts, names, enumThreshold)
+		schema.Items = &items
+	}
+	if schema.OneOf != nil {
+		alternatives := make([]Schema, len(schema.OneOf))
+		for i, alternative := range schema.OneOf {
+			alternatives[i] = o.referenceSchemas(alternative, name, counts, names, enumThreshold)
+		}
+		schema.OneOf = alternatives
+	}
+
+	if structure == "" || counts[structure] < 2 {
+		return schema
+	}
+	component, exists := names[structure]
+	if exists {
+		o.Components.Schemas[component] = mergeEnums(o.Components.Schemas[component], schema, enumThreshold)
+	} else {
+		if name == "" {
+			name = "Schema"
+		}
+		component = name
+		for i := 2; ; i++ {
+			if _, used := o.Components.Schemas[component]; !used {
+				break
+			}
+			component = name + strconv.Itoa(i)
+		}
+		names[structure] = component
+		o.Components.Schemas[component] = schema
+	}
+	return Schema{Ref: schemaRefPrefix + component}
+}
+
+// mergeEnums returns a schema with the enum values of another schema of the
+// same structure added to its own, leaving out enums with more than
+// enumThreshold values or missing from either schema
+func mergeEnums(schema, other Schema, enumThreshold int) Schema {
+	if schema.Enum != nil {
+		if other.Enum == nil {
+			schema.Enum = nil
+		} else {
+			schema.Enum = unionEnum(schema.Enum, other.Enum, enumThreshold)
+		}
+	}
+	if schema.Properties != nil {
+		properties := make(map[string]Schema, len(schema.Properties))
+		for name, property := range schema.Properties {
+			properties[name] = mergeEnums(property, other.Properties[name], enumThreshold)
+		}
+		schema.Properties = properties
+	}
+	if schema.Items != nil && other.Items != nil {
+		items := mergeEnums(*schema.Items, *other.Items, enumThreshold)
+		schema.Items = &items
+	}
+	if schema.OneOf != nil && len(other.OneOf) == len(schema.OneOf) {
+		alternatives := make([]Schema, len(schema.OneOf))
+		for i, alternative := range schema.OneOf {
+			alternatives[i] = mergeEnums(alternative, other.OneOf[i], enumThreshold)
+		}
+		schema.OneOf = alternatives
+	}
+	return schema
+}
+
+// unionEnum returns the sorted distinct values of two enums of strings or
+// numbers, or nil if there are more than enumThreshold of them
+func unionEnum(enum, other []interface{}, enumThreshold int) []interface{} {
+	seen := make(map[interface{}]bool, len(enum)+len(other))
+	var values []interface{}
+	for _, value := range append(append([]interface{}(nil), enum...), other...) {
+		if !seen[value] {
+			seen[value] = true
+			values = append(values, value)
+		}
+	}
+	if len(values) > enumThreshold {
+		return nil
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if a, ok := values[i].(string); ok {
+			b, _ := values[j].(string)
+			return a < b
+		}
+		a, _ := toFloat64(values[i])
+		b, _ := toFloat64(values[j])
+		return a < b
+	})
+	return values
+}
+
+// schemaStructure returns a key identifying a schema without its examples,
+// enums and descriptions
+func schemaStructure(schema Schema) string {
+	data, err := json.Marshal(withoutExamples(schema))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// withoutExamples returns a copy of a schema and its nested schemas without
+// examples, enums and descriptions
+func withoutExamples(schema Schema) Schema {
+	schema.Example = nil
+	schema.Examples = nil
+	schema.Enum = nil
+	schema.Description = ""
+	if schema.Properties != nil {
+		properties := make(map[string]Schema, len(schema.Properties))
+		for name, property := range schema.Properties {
+			properties[name] = withoutExamples(property)
+		}
+		schema.Properties = properties
+	}
+	if schema.Items != nil {
+		items := withoutExamples(*schema.Items)
+		schema.Items = &items
+	}
+	if schema.OneOf != nil {
+		alternatives := make([]Schema, len(schema.OneOf))
+		for i, alternative := range schema.OneOf {
+			alternatives[i] = withoutExamples(alternative)
+		}
+		schema.OneOf = alternatives
+	}
+	return schema
+}
@@ -0,0 +1,86 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractComponents(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	process := func(method, url, reqBody string, status int, respBody string) {
+		req := httptest.NewRequest(method, url, strings.NewReader(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		a.ProcessRequest(method, url, req, &http.Response{StatusCode: status}, []byte(reqBody), []byte(respBody))
+	}
+	process("GET", "http://example.com/users", "", 200,
+		`[{"id":1,"name":"alice","address":{"city":"Paris"}}]`)
+	process("GET", "http://example.com/users/1", "", 200,
+		`{"id":1,"name":"alice","address":{"city":"Paris"}}`)
+	process("POST", "http://example.com/users", `{"name":"bob"}`, 201,
+		`{"id":2,"name":"bob","address":{"city":"Rome"}}`)
+	process("GET", "http://example.com/orders/1", "", 200,
+		`{"id":1,"shipping":{"city":"Oslo"}}`)
+
+	// Schemas are inline by default
+	openAPI := a.GenerateOpenAPI()
+	assert.Empty(t, openAPI.Components.Schemas)
+	inline := openAPI.Paths["/users/{id}"].Get.Responses["200"].Content["application/json"].Schema
+	assert.Equal(t, "object", inline.Type)
+
+	a.SetUseRefs(true)
+	openAPI = a.GenerateOpenAPI()
+	require.Equal(t, []string{"Shipping", "UsersItem"}, sortedKeys(openAPI.Components.Schemas))
+
+	// The user returned by three operations is a single component, named after
+	// its first occurrence in the items of GET /users
+	schemaOf := func(operation *Operation, status string) Schema {
+		return operation.Responses[status].Content["application/json"].Schema
+	}
+	list := schemaOf(openAPI.Paths["/users"].Get, "200")
+	assert.Equal(t, "array", list.Type)
+	assert.Equal(t, "#/components/schemas/UsersItem", list.Items.Ref)
+	assert.Equal(t, Schema{Ref: "#/components/schemas/UsersItem"}, schemaOf(openAPI.Paths["/users/{id}"].Get, "200"))
+	assert.Equal(t, Schema{Ref: "#/components/schemas/UsersItem"}, schemaOf(openAPI.Paths["/users"].Post, "201"))
+
+	// Nested objects are extracted too, whatever their field names
+	user := openAPI.Components.Schemas["UsersItem"]
+	assert.Equal(t, []string{"address", "id", "name"}, user.Required)
+	assert.Equal(t, "#/components/schemas/Shipping", user.Properties["address"].Ref)
+	order := schemaOf(openAPI.Paths["/orders/{id}"].Get, "200")
+	assert.Equal(t, "#/components/schemas/Shipping", order.Properties["shipping"].Ref)
+	assert.Equal(t, "object", openAPI.Components.Schemas["Shipping"].Type)
+
+	// Components list the enum values observed by all operations
+	assert.Equal(t, []interface{}{"alice", "bob"}, user.Properties["name"].Enum)
+	assert.Equal(t, []interface{}{"Oslo", "Paris", "Rome"}, openAPI.Components.Schemas["Shipping"].Properties["city"].Enum)
+
+	// Objects found once stay inline
+	request := openAPI.Paths["/users"].Post.RequestBody.Content["application/json"].Schema
+	assert.Empty(t, request.Ref)
+	assert.Contains(t, request.Properties, "name")
+
+	// The derived formats keep their own definitions
+	assert.NotContains(t, mustJSON(t, a.GenerateSwagger2()), "#/components/")
+	assert.Contains(t, a.GenerateTypeScript(false, false), "export interface GetUsersIdResponse200 {")
+
+	first := mustJSON(t, openAPI)
+	assert.Contains(t, first, `"$ref":"#/components/schemas/UsersItem"`)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, first, mustJSON(t, a.GenerateOpenAPI()))
+	}
+}
+
+// mustJSON returns the JSON encoding of a value
+func mustJSON(t *testing.T, value interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(value)
+	require.NoError(t, err)
+	return string(data)
+}
@@ -0,0 +1,101 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// CurlSnippet returns a curl command sending the example request of an
+// endpoint to the backend, or else to the first server of the specifications.
+// Every recorded value of query parameters and headers is sent, so
+// multi-valued ones are repeated, and redacted values are replaced by a
+// REDACTED placeholder. Arguments are quoted for POSIX shells.
+func (a *Analyzer) CurlSnippet(endpoint *EndpointData) string {
+	baseURL := a.GetBackendURL()
+	if baseURL == "" {
+		baseURL = a.baseURL()
+	}
+	return a.curlSnippet(endpoint, baseURL)
+}
+
+// curlSnippet returns the curl command of an endpoint against baseURL
+func (a *Analyzer) curlSnippet(endpoint *EndpointData, baseURL string) string {
+	// The path parameters come from the example path, the query from all values
+	requestURL := strings.TrimSuffix(baseURL, "/") + strings.SplitN(a.examplePath(endpoint), "?", 2)[0]
+	query := a.placeholderValues(endpoint.URLParameters)
+	if len(query) > 0 {
+		params := make([]string, 0, len(query))
+		for _, key := range sortedKeys(query) {
+			for _, value := range query[key] {
+				params = append(params, url.QueryEscape(key)+"="+url.QueryEscape(value))
+			}
+		}
+		requestURL += "?" + strings.Join(params, "&")
+	}
+
+	command := "curl "
+	if endpoint.Method != http.MethodGet {
+		command += "-X " + endpoint.Method + " "
+	}
+	args := []string{command + shellQuote(requestURL)}
+
+	headers := make(map[string][]string)
+	for key, values := range a.placeholderValues(endpoint.RequestHeaders) {
+		if !replaySkippedHeaders[http.CanonicalHeaderKey(key)] {
+			headers[key] = values
+		}
+	}
+	var body string
+	if endpoint.RequestPayload != nil && len(endpoint.RequestPayload.Examples) > 0 {
+		if data, err := json.Marshal(createExampleFromStore(a.placeholderStore(endpoint.RequestPayload))); err == nil {
+			body = string(data)
+			headers["Content-Type"] = []string{mediaTypeOrDefault(endpoint.RequestContentType)}
+		}
+	}
+	for _, key := range sortedKeys(headers) {
+		for _, value := range headers[key] {
+			args = append(args, "-H "+shellQuote(key+": "+value))
+		}
+	}
+	if body != "" {
+		args = append(args, "-d "+shellQuote(body))
+	}
+	return strings.Join(args, " \\\n  ")
+}
+
+// placeholderValues returns the distinct examples of each field of a flat
+// store, such as headers or query parameters, with redacted values replaced by
+// a single placeholder
+func (a *Analyzer) placeholderValues(store *SchemaStore) map[string][]string {
+	values := make(map[string][]string)
+	if store == nil {
+		return values
+	}
+	for key, examples := range store.Examples {
+		if len(examples) == 0 {
+			continue
+		}
+		if a.shouldRedact(key) {
+			values[key] = []string{redactedPlaceholder}
+			continue
+		}
+		seen := make(map[string]bool, len(examples))
+		for _, example := range examples {
+			value := exampleString(example)
+			if !seen[value] {
+				seen[value] = true
+				values[key] = append(values[key], value)
+			}
+		}
+	}
+	return values
+}
+
+// shellQuote quotes a string as a single POSIX shell word. Nothing is expanded
+// inside single quotes, including $ and newlines, so only single quotes need
+// escaping, by closing the quotes around an escaped quote.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
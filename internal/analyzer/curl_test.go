@@ -0,0 +1,99 @@
+package analyzer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"empty", "", `''`},
+		{"plain", "hello", `'hello'`},
+		{"single quote", "it's", `'it'\''s'`},
+		{"dollar", "$HOME costs $5", `'$HOME costs $5'`},
+		{"newline", "a\nb", "'a\nb'"},
+		{"backslash and backtick", "a\\b`c`", "'a\\b`c`'"},
+		{"quotes only", "''", `''\'''\'''`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, shellQuote(tt.input))
+		})
+	}
+
+	// The shell must read back each string as is
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not found")
+	}
+	for _, tt := range tests {
+		output, err := exec.Command(sh, "-c", "printf %s "+shellQuote(tt.input)).Output()
+		require.NoError(t, err, tt.name)
+		assert.Equal(t, tt.input, string(output), tt.name)
+	}
+}
+
+func TestCurlSnippet(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	a.SetRedactedFields([]string{"Authorization"})
+	a.SetHeaderFilters(nil, []string{"Connection"})
+	a.SetProxyConfig(8080, "http://backend:3000/")
+
+	req := httptest.NewRequest("POST", "https://example.com/users/42/notes?tag=a&tag=b", nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Connection", "keep-alive")
+	req.Header.Add("X-Region", "eu")
+	req.Header.Add("X-Region", "us")
+	a.ProcessRequest("POST", "https://example.com/users/42/notes?tag=a&tag=b", req, &http.Response{StatusCode: 201}, []byte(`{"text":"it's $5"}`), []byte(`{"id":1}`))
+
+	endpoint := a.GetEndpoint("POST", "/users/{id}/notes")
+	require.NotNil(t, endpoint)
+	assert.Equal(t, `curl -X POST 'http://backend:3000/users/42/notes?tag=a&tag=b' \
+  -H 'Authorization: REDACTED' \
+  -H 'Content-Type: application/json' \
+  -H 'X-Region: eu' \
+  -H 'X-Region: us' \
+  -d '{"text":"it'\''s $5"}'`, a.CurlSnippet(endpoint))
+
+	req = httptest.NewRequest("GET", "https://example.com/orders", nil)
+	a.ProcessRequest("GET", "https://example.com/orders", req, &http.Response{StatusCode: 200}, nil, []byte(`[]`))
+	assert.Equal(t, `curl 'http://backend:3000/orders'`, a.CurlSnippet(a.GetEndpoint("GET", "/orders")))
+}
+
+func TestHandleCurlSnippet(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	req := httptest.NewRequest("GET", "https://example.com/orders", nil)
+	a.ProcessRequest("GET", "https://example.com/orders", req, &http.Response{StatusCode: 200}, nil, []byte(`[]`))
+	handler := NewServer(a).Handler()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/snippets/curl?endpoint=get%20/orders", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "curl 'http://localhost:8080/orders'\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/snippets/curl?endpoint=POST%20/orders", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/snippets/curl", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/analyzer", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"Snippets":{"curl":"curl 'http://localhost:8080/orders'"}`)
+}
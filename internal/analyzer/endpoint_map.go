@@ -0,0 +1,318 @@
+package analyzer
+
+import (
+	"hash/maphash"
+	"sort"
+	"sync"
+	"time"
+)
+
+// endpointShardCount is the number of shards the endpoints map is split into,
+// so that requests to different endpoints don't serialize on a single lock
+const endpointShardCount = 32
+
+// endpointShard holds the endpoints whose keys hash to the shard
+type endpointShard struct {
+	mu        sync.RWMutex
+	endpoints map[string]*EndpointData
+}
+
+// endpointMap is a sharded map of endpoints keyed by method+url
+type endpointMap struct {
+	seed   maphash.Seed
+	shards [endpointShardCount]endpointShard
+}
+
+// newEndpointMap creates an endpoint map holding the given endpoints
+func newEndpointMap(endpoints map[string]*EndpointData) *endpointMap {
+	m := &endpointMap{seed: maphash.MakeSeed()}
+	for i := range m.shards {
+		m.shards[i].endpoints = make(map[string]*EndpointData)
+	}
+	for key, endpoint := range endpoints {
+		m.shard(key).endpoints[key] = endpoint
+	}
+	return m
+}
+
+// shard returns the shard responsible for a key
+func (m *endpointMap) shard(key string) *endpointShard {
+	return &m.shards[maphash.String(m.seed, key)%endpointShardCount]
+}
+
+// getOrCreate returns the endpoint for a key, adding the endpoint returned by
+// create if the key is not present yet
+func (m *endpointMap) getOrCreate(key string, create func() *EndpointData) *EndpointData {
+	shard := m.shard(key)
+
+	shard.mu.RLock()
+	endpoint, exists := shard.endpoints[key]
+	shard.mu.RUnlock()
+	if exists {
+		return endpoint
+	}
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	if endpoint, exists := shard.endpoints[key]; exists {
+		return endpoint
+	}
+	endpoint = create()
+	shard.endpoints[key] = endpoint
+	return endpoint
+}
+
+// contains checks if an endpoint is present for a key
+func (m *endpointMap) contains(key string) bool {
+	shard := m.shard(key)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+	_, exists := shard.endpoints[key]
+	return exists
+}
+
+// get returns a copy of the endpoint for a key, or nil if it is not present
+func (m *endpointMap) get(key string) *EndpointData {
+	shard := m.shard(key)
+	shard.mu.RLock()
+	endpoint, exists := shard.endpoints[key]
+	shard.mu.RUnlock()
+	if !exists {
+		return nil
+	}
+	return endpoint.snapshot()
+}
+
+// summaries returns the summaries of all endpoints
+func (m *endpointMap) summaries() []EndpointSummary {
+	m.rlockAll()
+	defer m.runlockAll()
+
+	var summaries []EndpointSummary
+	for i := range m.shards {
+		for _, endpoint := range m.shards[i].endpoints {
+			summaries = append(summaries, endpoint.summary())
+		}
+	}
+	return summaries
+}
+
+// changesSince returns the change log entries of all endpoints recorded at or after since
+func (m *endpointMap) changesSince(since time.Time) []ChangeLogEntry {
+	m.rlockAll()
+	defer m.runlockAll()
+
+	var entries []ChangeLogEntry
+	for i := range m.shards {
+		for _, endpoint := range m.shards[i].endpoints {
+			entries = append(entries, endpoint.changesSince(since)...)
+		}
+	}
+	return entries
+}
+
+// replace replaces all endpoints in the map
+func (m *endpointMap) replace(endpoints map[string]*EndpointData) {
+	m.lockAll()
+	defer m.unlockAll()
+
+	for i := range m.shards {
+		m.shards[i].endpoints = make(map[string]*EndpointData)
+	}
+	for key, endpoint := range endpoints {
+		m.shard(key).endpoints[key] = endpoint
+	}
+}
+
+// snapshot returns a consistent deep copy of all endpoints, which can be
+// read and encoded while requests are still being processed
+func (m *endpointMap) snapshot() map[string]*EndpointData {
+	m.rlockAll()
+	defer m.runlockAll()
+
+	endpoints := make(map[string]*EndpointData)
+	for i := range m.shards {
+		for key, endpoint := range m.shards[i].endpoints {
+			endpoints[key] = endpoint.snapshot()
+		}
+	}
+	return endpoints
+}
+
+// snapshotChanges returns a consistent copy of all endpoints and the keys of
+// the endpoints changed since the previous call
+func (m *endpointMap) snapshotChanges() (map[string]*EndpointData, []string) {
+	m.rlockAll()
+	defer m.runlockAll()
+
+	endpoints := make(map[string]*EndpointData)
+	var changed []string
+	for i := range m.shards {
+		for key, endpoint := range m.shards[i].endpoints {
+			if endpoint.takeChanged() {
+				changed = append(changed, key)
+			}
+			endpoints[key] = endpoint.snapshot()
+		}
+	}
+	return endpoints, changed
+}
+
+// markChanged flags the endpoints with the given keys as changed
+func (m *endpointMap) markChanged(keys []string) {
+	for _, key := range keys {
+		shard := m.shard(key)
+		shard.mu.RLock()
+		endpoint, exists := shard.endpoints[key]
+		shard.mu.RUnlock()
+		if exists {
+			endpoint.markChanged()
+		}
+	}
+}
+
+// len returns the number of endpoints
+func (m *endpointMap) len() int {
+	m.rlockAll()
+	defer m.runlockAll()
+
+	n := 0
+	for i := range m.shards {
+		n += len(m.shards[i].endpoints)
+	}
+	return n
+}
+
+// lockAll write-locks all shards in order
+func (m *endpointMap) lockAll() {
+	for i := range m.shards {
+		m.shards[i].mu.Lock()
+	}
+}
+
+// unlockAll releases the write locks taken by lockAll
+func (m *endpointMap) unlockAll() {
+	for i := range m.shards {
+		m.shards[i].mu.Unlock()
+	}
+}
+
+// rlockAll read-locks all shards in order
+func (m *endpointMap) rlockAll() {
+	for i := range m.shards {
+		m.shards[i].mu.RLock()
+	}
+}
+
+// runlockAll releases the read locks taken by rlockAll
+func (m *endpointMap) runlockAll() {
+	for i := range m.shards {
+		m.shards[i].mu.RUnlock()
+	}
+}
+
+// addProtocol adds an HTTP version to the sorted protocols of the endpoint.
+// The caller holds e.mu.
+func (e *EndpointData) addProtocol(protocol string) {
+	if protocol == "" {
+		return
+	}
+	i := sort.SearchStrings(e.Protocols, protocol)
+	if i < len(e.Protocols) && e.Protocols[i] == protocol {
+		return
+	}
+	e.Protocols = append(e.Protocols, "")
+	copy(e.Protocols[i+1:], e.Protocols[i:])
+	e.Protocols[i] = protocol
+}
+
+// markChanged flags the endpoint as changed since the last save
+func (e *EndpointData) markChanged() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.changed = true
+}
+
+// takeChanged clears the changed flag of the endpoint, returning its previous value
+func (e *EndpointData) takeChanged() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	changed := e.changed
+	e.changed = false
+	return changed
+}
+
+// summary returns the summary of the endpoint
+func (e *EndpointData) summary() EndpointSummary {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	statuses := make([]int, 0, len(e.ResponseStatuses))
+	for status := range e.ResponseStatuses {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+	return EndpointSummary{
+		Method:       e.Method,
+		Path:         e.URL,
+		RequestCount: e.RequestCount,
+		StatusCodes:  statuses,
+		LastSeen:     e.LastSeen,
+		Link:         endpointLink(e.Method, e.URL),
+	}
+}
+
+// bodyStores returns the schema stores of the request and response bodies of
+// the endpoint
+func (e *EndpointData) bodyStores() []*SchemaStore {
+	stores := []*SchemaStore{e.RequestPayload}
+	for _, store := range e.RequestBodies {
+		stores = append(stores, store)
+	}
+	for _, response := range e.ResponseStatuses {
+		stores = append(stores, response.Payload)
+	}
+	return stores
+}
+
+// snapshot returns a deep copy of the endpoint and its schema stores
+func (e *EndpointData) snapshot() *EndpointData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var bodies map[string]*SchemaStore
+	if e.RequestBodies != nil {
+		bodies = make(map[string]*SchemaStore, len(e.RequestBodies))
+		for mediaType, store := range e.RequestBodies {
+			bodies[mediaType] = store.clone()
+		}
+	}
+	statuses := make(map[int]*ResponseData, len(e.ResponseStatuses))
+	for status, response := range e.ResponseStatuses {
+		statuses[status] = &ResponseData{
+			Headers:     response.Headers.clone(),
+			Payload:     response.Payload.clone(),
+			SetCookies:  response.SetCookies.clone(),
+			ContentType: response.ContentType,
+			Count:       response.Count,
+		}
+	}
+	return &EndpointData{
+		Method:             e.Method,
+		URL:                e.URL,
+		RequestCount:       e.RequestCount,
+		LastSeen:           e.LastSeen,
+		Protocols:          append([]string(nil), e.Protocols...),
+		TLS:                e.TLS,
+		Changes:            append([]SchemaChange(nil), e.Changes...),
+		RequestHeaders:     e.RequestHeaders.clone(),
+		RequestPayload:     e.RequestPayload.clone(),
+		RequestContentType: e.RequestContentType,
+		RequestBodies:      bodies,
+		URLParameters:      e.URLParameters.clone(),
+		PathParams:         e.PathParams.clone(),
+		Cookies:            e.Cookies.clone(),
+		Tracing:            e.Tracing.clone(),
+		ResponseStatuses:   statuses,
+	}
+}
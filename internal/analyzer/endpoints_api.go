@@ -0,0 +1,263 @@
+package analyzer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Endpoint list pagination settings
+const (
+	defaultEndpointPageSize = 50
+	maxEndpointPageSize     = 500
+	endpointSnapshotTTL     = 10 * time.Minute // How long a list snapshot can be paged through
+	maxEndpointSnapshots    = 32               // Number of list snapshots kept at once
+)
+
+// EndpointSummary describes an endpoint without its schema stores
+type EndpointSummary struct {
+	Method       string    `json:"method"`
+	Path         string    `json:"path"`
+	RequestCount int64     `json:"requestCount"`
+	StatusCodes  []int     `json:"statusCodes"`
+	LastSeen     time.Time `json:"lastSeen,omitzero"`
+	Link         string    `json:"link"` // URL of the endpoint details
+}
+
+// EndpointList is a page of endpoint summaries taken from a snapshot
+type EndpointList struct {
+	Snapshot  string            `json:"snapshot"` // ID to pass as ?snapshot= to page through the same results
+	Total     int               `json:"total"`    // Number of endpoints matching the filters
+	Page      int               `json:"page"`
+	PageSize  int               `json:"pageSize"`
+	Endpoints []EndpointSummary `json:"endpoints"`
+}
+
+// EndpointSummaries returns summaries of all endpoints, without copying their
+// schema stores
+func (a *Analyzer) EndpointSummaries() []EndpointSummary {
+	return a.endpoints.summaries()
+}
+
+// GetEndpoint returns a copy of the endpoint with the given method and
+// normalized path, or nil if it doesn't exist
+func (a *Analyzer) GetEndpoint(method, path string) *EndpointData {
+	return a.endpoints.get(method + " " + path)
+}
+
+// endpointLink returns the URL of the details of an endpoint, whose path is
+// base64url encoded so it fits in a single path segment
+func endpointLink(method, path string) string {
+	return "/api/endpoints/" + method + "/" + base64.RawURLEncoding.EncodeToString([]byte(path))
+}
+
+// endpointSnapshots keeps recent endpoint lists so clients can page through
+// results that don't shift while traffic adds endpoints
+type endpointSnapshots struct {
+	mu      sync.Mutex
+	next    int64
+	entries map[string]*endpointSnapshot
+}
+
+// endpointSnapshot is the list of all endpoints at the time it was taken
+type endpointSnapshot struct {
+	created   time.Time
+	summaries []EndpointSummary
+}
+
+// add stores a snapshot and returns its ID, evicting expired snapshots and the
+// oldest one if too many are kept
+func (s *endpointSnapshots) add(summaries []EndpointSummary) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.entries == nil {
+		s.entries = make(map[string]*endpointSnapshot)
+	}
+
+	now := time.Now()
+	oldest := ""
+	for id, snapshot := range s.entries {
+		if now.Sub(snapshot.created) > endpointSnapshotTTL {
+			delete(s.entries, id)
+		} else if oldest == "" || snapshot.created.Before(s.entries[oldest].created) {
+			oldest = id
+		}
+	}
+	if len(s.entries) >= maxEndpointSnapshots {
+		delete(s.entries, oldest)
+	}
+
+	s.next++
+	id := strconv.FormatInt(s.next, 10)
+	s.entries[id] = &endpointSnapshot{created: now, summaries: summaries}
+	return id
+}
+
+// get returns the summaries of a snapshot, or false if it doesn't exist or expired
+func (s *endpointSnapshots) get(id string) ([]EndpointSummary, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snapshot, exists := s.entries[id]
+	if !exists || time.Since(snapshot.created) > endpointSnapshotTTL {
+		return nil, false
+	}
+	return snapshot.summaries, true
+}
+
+// sortEndpointSummaries sorts summaries by path, by request count (busiest
+// first) or by last seen time (most recent first), breaking ties by path and
+// method so pages are stable
+func sortEndpointSummaries(summaries []EndpointSummary, by string) error {
+	byPath := func(a, b EndpointSummary) bool {
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Method < b.Method
+	}
+	var less func(a, b EndpointSummary) bool
+	switch by {
+	case "", "path":
+		less = byPath
+	case "count":
+		less = func(a, b EndpointSummary) bool {
+			if a.RequestCount != b.RequestCount {
+				return a.RequestCount > b.RequestCount
+			}
+			return byPath(a, b)
+		}
+	case "last-seen":
+		less = func(a, b EndpointSummary) bool {
+			if !a.LastSeen.Equal(b.LastSeen) {
+				return a.LastSeen.After(b.LastSeen)
+			}
+			return byPath(a, b)
+		}
+	default:
+		return fmt.Errorf("sort must be one of path, count or last-seen")
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return less(summaries[i], summaries[j])
+	})
+	return nil
+}
+
+// positiveQuery parses a positive integer query parameter, returning def if it is not set
+func positiveQuery(r *http.Request, name string, def int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%s must be a positive integer", name)
+	}
+	return n, nil
+}
+
+// handleEndpoints lists endpoint summaries, filtered by ?prefix= and ?method=,
+// sorted by ?sort= and paginated by ?page= and ?page_size=. The first request
+// takes a snapshot of the endpoints whose ID is returned; passing it as
+// ?snapshot= pages through the same results while new traffic is analyzed.
+func (s *Server) handleEndpoints(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Add CORS headers
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+
+	query := r.URL.Query()
+	page, err := positiveQuery(r, "page", 1)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	pageSize, err := positiveQuery(r, "page_size", defaultEndpointPageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	pageSize = min(pageSize, maxEndpointPageSize)
+
+	snapshotID := query.Get("snapshot")
+	var summaries []EndpointSummary
+	if snapshotID == "" {
+		summaries = s.analyzer.EndpointSummaries()
+		snapshotID = s.snapshots.add(summaries)
+	} else if summaries, err = s.snapshotSummaries(snapshotID); err != nil {
+		http.Error(w, err.Error(), http.StatusGone)
+		return
+	}
+
+	prefix, method := query.Get("prefix"), query.Get("method")
+	matching := make([]EndpointSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		if strings.HasPrefix(summary.Path, prefix) && (method == "" || strings.EqualFold(summary.Method, method)) {
+			matching = append(matching, summary)
+		}
+	}
+	if err := sortEndpointSummaries(matching, query.Get("sort")); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	list := EndpointList{
+		Snapshot:  snapshotID,
+		Total:     len(matching),
+		Page:      page,
+		PageSize:  pageSize,
+		Endpoints: []EndpointSummary{},
+	}
+	if start := (page - 1) * pageSize; start < len(matching) {
+		list.Endpoints = matching[start:min(start+pageSize, len(matching))]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// snapshotSummaries returns the summaries of a snapshot taken by a previous list request
+func (s *Server) snapshotSummaries(id string) ([]EndpointSummary, error) {
+	summaries, exists := s.snapshots.get(id)
+	if !exists {
+		return nil, fmt.Errorf("snapshot %q expired, list the endpoints again without it", id)
+	}
+	return summaries, nil
+}
+
+// handleEndpoint returns all data of one endpoint, identified by its method
+// and base64url encoded normalized path as in the links of the endpoint list
+func (s *Server) handleEndpoint(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Add CORS headers
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+
+	path, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(r.PathValue("path"), "="))
+	if err != nil {
+		http.Error(w, "Path must be base64url encoded", http.StatusBadRequest)
+		return
+	}
+	endpoint := s.analyzer.GetEndpoint(strings.ToUpper(r.PathValue("method")), string(path))
+	if endpoint == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(endpoint)
+}
@@ -0,0 +1,102 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleEndpoints(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	process := func(method, url string, status int) {
+		req := httptest.NewRequest(method, url, nil)
+		a.ProcessRequest(method, url, req, &http.Response{StatusCode: status}, nil, []byte(`{"id":1}`))
+		time.Sleep(time.Millisecond) // Distinct last seen times
+	}
+	a.SetCaptureErrors(true)
+	for i := 0; i < 3; i++ {
+		process("GET", fmt.Sprintf("http://example.com/api/orders/%d", i+1), 200)
+	}
+	process("GET", "http://example.com/api/orders/4", 404)
+	process("POST", "http://example.com/api/orders", 201)
+	process("POST", "http://example.com/api/orders", 201)
+	process("GET", "http://example.com/api/users", 200)
+
+	handler := NewServer(a).Handler()
+	list := func(query string) EndpointList {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/endpoints"+query, nil))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var list EndpointList
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		return list
+	}
+	names := func(list EndpointList) []string {
+		var names []string
+		for _, endpoint := range list.Endpoints {
+			names = append(names, endpoint.Method+" "+endpoint.Path)
+		}
+		return names
+	}
+
+	// Sorted by path by default
+	all := list("")
+	assert.Equal(t, 3, all.Total)
+	assert.Equal(t, []string{"POST /api/orders", "GET /api/orders/{id}", "GET /api/users"}, names(all))
+	orders := all.Endpoints[1]
+	assert.Equal(t, int64(4), orders.RequestCount)
+	assert.Equal(t, []int{200, 404}, orders.StatusCodes)
+	assert.False(t, orders.LastSeen.IsZero())
+
+	// Filters and sorting
+	assert.Equal(t, []string{"POST /api/orders", "GET /api/orders/{id}"}, names(list("?prefix=/api/orders")))
+	assert.Equal(t, []string{"POST /api/orders"}, names(list("?prefix=/api/orders&method=post")))
+	assert.Equal(t, []string{"GET /api/orders/{id}", "POST /api/orders", "GET /api/users"}, names(list("?sort=count")))
+	assert.Equal(t, []string{"GET /api/users", "POST /api/orders", "GET /api/orders/{id}"}, names(list("?sort=last-seen")))
+
+	// Pages of a snapshot don't change while new endpoints are recorded
+	first := list("?page_size=2")
+	assert.Equal(t, []string{"POST /api/orders", "GET /api/orders/{id}"}, names(first))
+	process("GET", "http://example.com/api/carts", 200)
+	second := list("?page_size=2&page=2&snapshot=" + first.Snapshot)
+	assert.Equal(t, 3, second.Total)
+	assert.Equal(t, []string{"GET /api/users"}, names(second))
+	assert.Empty(t, list("?page_size=2&page=3&snapshot="+first.Snapshot).Endpoints)
+	assert.Equal(t, 4, list("").Total)
+
+	// Invalid parameters
+	for query, status := range map[string]int{
+		"?page=0":         http.StatusBadRequest,
+		"?page_size=x":    http.StatusBadRequest,
+		"?sort=size":      http.StatusBadRequest,
+		"?snapshot=12345": http.StatusGone,
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/endpoints"+query, nil))
+		assert.Equal(t, status, w.Code, query)
+	}
+
+	// Details of an endpoint
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", orders.Link, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var endpoint EndpointData
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &endpoint))
+	assert.Equal(t, "/api/orders/{id}", endpoint.URL)
+	assert.Contains(t, endpoint.PathParams.Examples, "id")
+	assert.Contains(t, endpoint.ResponseStatuses, 404)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", endpointLink("DELETE", "/api/orders/{id}"), nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/endpoints/GET/not*base64", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,364 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// goTestsHelpers is the part of generated contract tests shared by all tests.
+// Schemas are decoded without struct tags, relying on encoding/json matching
+// field names case-insensitively.
+const goTestsHelpers = `
+// baseURL is where the requests are sent, overridden by the DOCURIFT_BASE_URL
+// environment variable
+var baseURL = %s
+
+func init() {
+	if value := os.Getenv("DOCURIFT_BASE_URL"); value != "" {
+		baseURL = value
+	}
+}
+
+// contractRequest is an example request of an endpoint
+type contractRequest struct {
+	Method      string
+	Path        string
+	Header      map[string]string
+	ContentType string
+	Body        string
+}
+
+// schema is the part of a response body schema checked by the tests
+type schema struct {
+	Type       string
+	Nullable   bool
+	Properties map[string]*schema
+	Required   []string
+	Items      *schema
+	OneOf      []*schema
+}
+
+var client = &http.Client{Timeout: 30 * time.Second}
+
+// checkEndpoint sends a request and checks that the response has one of the
+// recorded statuses and that its body conforms to the schema of that status,
+// if any. Values are not compared, only types and required fields.
+func checkEndpoint(t *testing.T, request contractRequest, responses map[int]string) {
+	t.Helper()
+
+	var body io.Reader
+	if request.Body != "" {
+		body = strings.NewReader(request.Body)
+	}
+	req, err := http.NewRequest(request.Method, strings.TrimSuffix(baseURL, "/")+request.Path, body)
+	if err != nil {
+		t.Fatalf("Failed to create request: %%v", err)
+	}
+	for key, value := range request.Header {
+		req.Header.Set(key, value)
+	}
+	if request.ContentType != "" {
+		req.Header.Set("Content-Type", request.ContentType)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("%%s %%s failed: %%v", request.Method, request.Path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read response body: %%v", err)
+	}
+
+	schemaJSON, recorded := responses[resp.StatusCode]
+	if !recorded {
+		statuses := make([]int, 0, len(responses))
+		for status := range responses {
+			statuses = append(statuses, status)
+		}
+		sort.Ints(statuses)
+		t.Fatalf("%%s %%s returned status %%d, recorded %%v", request.Method, request.Path, resp.StatusCode, statuses)
+	}
+	if schemaJSON == "" {
+		return
+	}
+
+	var expected schema
+	if err := json.Unmarshal([]byte(schemaJSON), &expected); err != nil {
+		t.Fatalf("Invalid schema: %%v", err)
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		t.Fatalf("%%s %%s returned a body that is not JSON: %%v", request.Method, request.Path, err)
+	}
+	for _, problem := range conform("$", value, &expected) {
+		t.Error(problem)
+	}
+}
+
+// conform returns how a decoded JSON value doesn't conform to a schema
+func conform(path string, value interface{}, s *schema) []string {
+	if value == nil {
+		if s.Nullable || s.Type == "" {
+			return nil
+		}
+		return []string{fmt.Sprintf("%%s: got null, expected %%s", path, s.Type)}
+	}
+	if len(s.OneOf) > 0 {
+		for _, alternative := range s.OneOf {
+			if len(conform(path, value, alternative)) == 0 {
+				return nil
+			}
+		}
+		return []string{fmt.Sprintf("%%s: matches none of the %%d recorded shapes", path, len(s.OneOf))}
+	}
+
+	var ok bool
+	switch s.Type {
+	case "":
+		ok = true
+	case "string":
+		_, ok = value.(string)
+	case "boolean":
+		_, ok = value.(bool)
+	case "number":
+		_, ok = value.(float64)
+	case "integer":
+		var number float64
+		number, ok = value.(float64)
+		ok = ok && number == math.Trunc(number)
+	case "array":
+		var items []interface{}
+		if items, ok = value.([]interface{}); ok && s.Items != nil {
+			var problems []string
+			for i, item := range items {
+				problems = append(problems, conform(fmt.Sprintf("%%s[%%d]", path, i), item, s.Items)...)
+			}
+			return problems
+		}
+	case "object":
+		var object map[string]interface{}
+		if object, ok = value.(map[string]interface{}); ok {
+			var problems []string
+			for _, name := range s.Required {
+				if _, exists := object[name]; !exists {
+					problems = append(problems, fmt.Sprintf("%%s.%%s: required field is missing", path, name))
+				}
+			}
+			names := make([]string, 0, len(s.Properties))
+			for name := range s.Properties {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				if property, exists := object[name]; exists {
+					problems = append(problems, conform(path+"."+name, property, s.Properties[name])...)
+				}
+			}
+			return problems
+		}
+	default:
+		ok = true
+	}
+	if !ok {
+		return []string{fmt.Sprintf("%%s: got %%T, expected %%s", path, value, s.Type)}
+	}
+	return nil
+}
+`
+
+// redactedPlaceholder is sent by generated tests and load test scripts in
+// place of redacted values
+const redactedPlaceholder = "REDACTED"
+
+// GenerateGoTests generates a Go test file checking a running API against
+// the recorded traffic, with a test per endpoint sending its example request
+// to baseURL, or else to the first server of the specifications. A test
+// passes if the response has a recorded status and its body has the recorded
+// field types and required fields. Redacted values are sent as a REDACTED
+// placeholder. The file only imports the standard library.
+func (a *Analyzer) GenerateGoTests(packageName, baseURL string) string {
+	if packageName == "" {
+		packageName = "contract"
+	}
+	if baseURL == "" {
+		baseURL = a.baseURL()
+	}
+	endpoints := a.GetData()
+	enumThreshold := a.getEnumThreshold()
+
+	var output strings.Builder
+	output.WriteString("// Code generated by DocuRift from observed traffic. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&output, "package %s\n\n", packageName)
+	output.WriteString("import (\n\t\"encoding/json\"\n\t\"fmt\"\n\t\"io\"\n\t\"math\"\n\t\"net/http\"\n\t\"os\"\n\t\"sort\"\n\t\"strings\"\n\t\"testing\"\n\t\"time\"\n)\n")
+	fmt.Fprintf(&output, goTestsHelpers, strconv.Quote(baseURL))
+
+	// Tests are in order of path and method so the file is the same for identical data
+	keys := sortedKeys(endpoints)
+	sort.SliceStable(keys, func(i, j int) bool {
+		first, second := endpoints[keys[i]], endpoints[keys[j]]
+		if first.URL != second.URL {
+			return first.URL < second.URL
+		}
+		return first.Method < second.Method
+	})
+	names := make(map[string]bool)
+	for _, key := range keys {
+		endpoint := endpoints[key]
+		if len(endpoint.ResponseStatuses) == 0 {
+			continue
+		}
+		name := "Test" + definitionName(endpoint.Method, endpoint.URL)
+		unique := name
+		for i := 2; names[unique]; i++ {
+			unique = name + strconv.Itoa(i)
+		}
+		names[unique] = true
+		a.writeGoTest(&output, unique, endpoint, enumThreshold)
+	}
+
+	source := []byte(output.String())
+	if formatted, err := format.Source(source); err == nil {
+		source = formatted
+	}
+	return string(source)
+}
+
+// writeGoTest writes the test of an endpoint
+func (a *Analyzer) writeGoTest(output *strings.Builder, name string, endpoint *EndpointData, enumThreshold int) {
+	statuses := make([]int, 0, len(endpoint.ResponseStatuses))
+	for status := range endpoint.ResponseStatuses {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+
+	fmt.Fprintf(output, "\n// %s checks %s %s\n", name, endpoint.Method, endpoint.URL)
+	fmt.Fprintf(output, "func %s(t *testing.T) {\n", name)
+	output.WriteString("\tcheckEndpoint(t, contractRequest{\n")
+	fmt.Fprintf(output, "\t\tMethod: %s,\n", strconv.Quote(endpoint.Method))
+	fmt.Fprintf(output, "\t\tPath: %s,\n", strconv.Quote(a.examplePath(endpoint)))
+	if headers := a.placeholderExamples(endpoint.RequestHeaders); len(headers) > 0 {
+		output.WriteString("\t\tHeader: map[string]string{\n")
+		for _, header := range sortedKeys(headers) {
+			fmt.Fprintf(output, "\t\t\t%s: %s,\n", strconv.Quote(header), strconv.Quote(headers[header]))
+		}
+		output.WriteString("\t\t},\n")
+	}
+	if endpoint.RequestPayload != nil && len(endpoint.RequestPayload.Examples) > 0 {
+		if body, err := json.Marshal(createExampleFromStore(a.placeholderStore(endpoint.RequestPayload))); err == nil {
+			fmt.Fprintf(output, "\t\tContentType: %s,\n", strconv.Quote(mediaTypeOrDefault(endpoint.RequestContentType)))
+			fmt.Fprintf(output, "\t\tBody: %s,\n", goStringLiteral(string(body)))
+		}
+	}
+	output.WriteString("\t}, map[int]string{\n")
+	for _, status := range statuses {
+		schema := ""
+		if payload := endpoint.ResponseStatuses[status].Payload; payload != nil && len(payload.Examples) > 0 {
+			if data, err := json.Marshal(contractSchema(generateSchemaFromStore(payload, enumThreshold))); err == nil {
+				schema = string(data)
+			}
+		}
+		fmt.Fprintf(output, "\t\t%d: %s,\n", status, goStringLiteral(schema))
+	}
+	output.WriteString("\t})\n}\n")
+}
+
+// examplePath returns the path and query of the example request of an
+// endpoint, with path parameters set to their first example
+func (a *Analyzer) examplePath(endpoint *EndpointData) string {
+	params := a.placeholderExamples(endpoint.PathParams)
+	keys := pathParamKeys(endpoint.URL)
+	segments := strings.Split(endpoint.URL, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			continue
+		}
+		value := "1"
+		if example, exists := params[keys[0]]; exists {
+			value = example
+		}
+		segments[i] = url.PathEscape(value)
+		keys = keys[1:]
+	}
+	path := strings.Join(segments, "/")
+
+	query := a.placeholderExamples(endpoint.URLParameters)
+	if len(query) > 0 {
+		params := make([]string, 0, len(query))
+		for _, key := range sortedKeys(query) {
+			params = append(params, url.QueryEscape(key)+"="+url.QueryEscape(query[key]))
+		}
+		path += "?" + strings.Join(params, "&")
+	}
+	return path
+}
+
+// placeholderExamples returns the first example of each field of a flat
+// store, such as headers or query parameters, with redacted values replaced
+// by a placeholder
+func (a *Analyzer) placeholderExamples(store *SchemaStore) map[string]string {
+	examples := make(map[string]string)
+	if store == nil {
+		return examples
+	}
+	for key, values := range store.Examples {
+		if len(values) == 0 {
+			continue
+		}
+		if a.shouldRedact(key) {
+			examples[key] = redactedPlaceholder
+		} else {
+			examples[key] = exampleString(values[0])
+		}
+	}
+	return examples
+}
+
+// placeholderStore returns a copy of a body store whose redacted fields only
+// hold a placeholder
+func (a *Analyzer) placeholderStore(store *SchemaStore) *SchemaStore {
+	placeholders := store.clone()
+	for path := range placeholders.Examples {
+		if a.shouldRedact(path) {
+			placeholders.Examples[path] = []interface{}{redactedPlaceholder}
+		}
+	}
+	return placeholders
+}
+
+// contractSchema returns the part of a schema checked by generated tests
+func contractSchema(schema Schema) Schema {
+	checked := Schema{
+		Type:     schema.Type,
+		Required: schema.Required,
+		Nullable: schema.Nullable,
+	}
+	if len(schema.Properties) > 0 {
+		checked.Properties = make(map[string]Schema, len(schema.Properties))
+		for name, property := range schema.Properties {
+			checked.Properties[name] = contractSchema(property)
+		}
+	}
+	if schema.Items != nil {
+		items := contractSchema(*schema.Items)
+		checked.Items = &items
+	}
+	for _, alternative := range schema.OneOf {
+		checked.OneOf = append(checked.OneOf, contractSchema(alternative))
+	}
+	return checked
+}
+
+// goStringLiteral returns a Go string literal of s, raw if possible for readability
+func goStringLiteral(s string) string {
+	if s == "" || strings.ContainsAny(s, "`\r") {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
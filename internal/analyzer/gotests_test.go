@@ -0,0 +1,82 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateGoTests(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	a.SetRedactedFields([]string{"password", "Authorization"})
+	a.SetCaptureErrors(true)
+
+	req := httptest.NewRequest("GET", "https://example.com/users/42?expand=orders", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-Tenant", "acme")
+	a.ProcessRequest("GET", "https://example.com/users/42?expand=orders", req, &http.Response{StatusCode: 200}, nil, []byte(`{"id":42,"name":"alice"}`))
+	req = httptest.NewRequest("GET", "https://example.com/users/43", nil)
+	a.ProcessRequest("GET", "https://example.com/users/43", req, &http.Response{StatusCode: 404}, nil, []byte(`{"error":"not found"}`))
+	req = httptest.NewRequest("POST", "https://example.com/users", nil)
+	req.Header.Set("Content-Type", "application/json")
+	a.ProcessRequest("POST", "https://example.com/users", req, &http.Response{StatusCode: 201}, []byte(`{"name":"bob","password":"hunter2"}`), []byte(`{"id":1}`))
+	req = httptest.NewRequest("DELETE", "https://example.com/users/42", nil)
+	a.ProcessRequest("DELETE", "https://example.com/users/42", req, &http.Response{StatusCode: 204}, nil, nil)
+
+	source := a.GenerateGoTests("", "https://staging.example.com")
+
+	// The file compiles on its own, with only standard library imports
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "contract_test.go", source, parser.ParseComments)
+	require.NoError(t, err, source)
+	assert.Equal(t, "contract", file.Name.Name)
+	_, err = (&types.Config{Importer: importer.ForCompiler(fset, "source", nil)}).Check("contract", fset, []*ast.File{file}, nil)
+	require.NoError(t, err, source)
+
+	var tests []string
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Name.Name != "checkEndpoint" && fn.Name.Name != "conform" && fn.Name.Name != "init" {
+			tests = append(tests, fn.Name.Name)
+		}
+	}
+	assert.Equal(t, []string{"TestPostUsers", "TestDeleteUsersId", "TestGetUsersId"}, tests)
+
+	assert.Contains(t, source, `var baseURL = "https://staging.example.com"`)
+	assert.Contains(t, source, `Path:   "/users/42?expand=orders",`)
+	assert.Contains(t, source, `"Authorization": "REDACTED",`)
+	assert.Contains(t, source, `"X-Tenant":      "acme",`)
+	assert.Contains(t, source, "Body:        `{\"name\":\"bob\",\"password\":\"REDACTED\"}`,")
+	assert.Contains(t, source, "404: `{\"type\":\"object\",\"properties\":{\"error\":{\"type\":\"string\"}},\"required\":[\"error\"]}`,")
+	assert.Contains(t, source, `204: "",`)
+	assert.NotContains(t, source, "hunter2")
+	assert.NotContains(t, source, "secret")
+
+	// Without a base URL, requests go to the first server
+	assert.Contains(t, a.GenerateGoTests("api", ""), "var baseURL = "+strconv.Quote(defaultBaseURL))
+}
+
+func TestHandleGoTests(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	handler := NewServer(a).Handler()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/tests/go?package=api_test&download=1", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "attachment; filename=contract_test.go", w.Header().Get("Content-Disposition"))
+	assert.Contains(t, w.Body.String(), "package api_test\n")
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/tests/go?package=my-tests", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
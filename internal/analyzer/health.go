@@ -0,0 +1,115 @@
+package analyzer
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Backend health probe settings
+const (
+	healthProbeTimeout  = 2 * time.Second // Timeout of a single probe request
+	healthProbeCacheTTL = 5 * time.Second // How long a probe result is reused
+)
+
+// BackendHealth describes the reachability of the proxied backend
+type BackendHealth struct {
+	URL           string     `json:"url"`
+	Reachable     bool       `json:"reachable"`
+	Error         string     `json:"error,omitempty"`
+	CheckedAt     time.Time  `json:"checkedAt"`
+	LastProxiedAt *time.Time `json:"lastProxiedAt,omitempty"`
+}
+
+// backendProbe checks whether the backend responds, caching the result briefly
+// to avoid sending a request to the backend for every health check
+type backendProbe struct {
+	client *http.Client
+	path   string
+
+	mu     sync.Mutex
+	result BackendHealth
+}
+
+// newBackendProbe creates a probe requesting the given path on the backend
+func newBackendProbe(path string) *backendProbe {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return &backendProbe{
+		client: &http.Client{Timeout: healthProbeTimeout},
+		path:   path,
+	}
+}
+
+// check returns the backend health, probing the backend if the cached result
+// is missing or expired
+func (p *backendProbe) check(backendURL string) BackendHealth {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	url := strings.TrimSuffix(backendURL, "/") + p.path
+	if p.result.URL == url && time.Since(p.result.CheckedAt) < healthProbeCacheTTL {
+		return p.result
+	}
+
+	p.result = BackendHealth{URL: url, CheckedAt: time.Now()}
+	resp, err := p.client.Head(url)
+	if err != nil {
+		p.result.Error = err.Error()
+		return p.result
+	}
+	resp.Body.Close()
+
+	// Any response except a server error shows the backend is up
+	if resp.StatusCode >= http.StatusInternalServerError {
+		p.result.Error = fmt.Sprintf("backend responded with status %d", resp.StatusCode)
+		return p.result
+	}
+	p.result.Reachable = true
+	return p.result
+}
+
+// ProbeBackend requests the given path on the backend once, e.g. to check it at
+// startup, with the same timeout and criteria as the health endpoint
+func ProbeBackend(backendURL, path string) BackendHealth {
+	return newBackendProbe(path).check(backendURL)
+}
+
+// SetBackendHealthCheck enables probing the backend at the given path when the
+// health endpoint is requested
+func (a *Analyzer) SetBackendHealthCheck(path string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.healthProbe = newBackendProbe(path)
+}
+
+// RecordProxied records that the proxy forwarded a request and got a response
+// with the given status, for every request whether or not it is analyzed.
+// Gateway errors are produced by the proxy when the backend can't be reached,
+// so they are not recorded.
+func (a *Analyzer) RecordProxied(status int) {
+	if status != http.StatusBadGateway && status != http.StatusGatewayTimeout {
+		a.lastProxied.Store(time.Now().UnixNano())
+	}
+}
+
+// CheckBackendHealth probes the backend if a health check is configured. It
+// returns false if no health check is configured.
+func (a *Analyzer) CheckBackendHealth() (BackendHealth, bool) {
+	a.mu.RLock()
+	probe, backendURL := a.healthProbe, a.backendURL
+	a.mu.RUnlock()
+	if probe == nil {
+		return BackendHealth{}, false
+	}
+
+	health := probe.check(backendURL)
+	if nanos := a.lastProxied.Load(); nanos != 0 {
+		lastProxied := time.Unix(0, nanos)
+		health.LastProxiedAt = &lastProxied
+	}
+	return health, true
+}
@@ -0,0 +1,124 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// BodyEncodingBase64 marks ingest records whose bodies are base64 encoded
+const BodyEncodingBase64 = "base64"
+
+// IngestRecord is a request/response exchange captured by a remote proxy and
+// sent to the analyzer's ingest endpoint
+type IngestRecord struct {
+	Method          string      `json:"method"`
+	URL             string      `json:"url"`
+	RequestHeaders  http.Header `json:"requestHeaders,omitempty"`
+	RequestBody     string      `json:"requestBody,omitempty"`
+	Status          int         `json:"status"`
+	ResponseHeaders http.Header `json:"responseHeaders,omitempty"`
+	ResponseBody    string      `json:"responseBody,omitempty"`
+	BodyEncoding    string      `json:"bodyEncoding,omitempty"` // Empty for raw text bodies or base64
+	Timestamp       *time.Time  `json:"timestamp,omitempty"`    // When the exchange was captured
+}
+
+// NewIngestRecord creates a record of a proxied exchange. The bodies are base64
+// encoded, as compressed or binary bodies are not valid UTF-8 and would be
+// altered by the JSON encoding of a string.
+func NewIngestRecord(req *http.Request, reqBody []byte, resp *http.Response, respBody []byte) IngestRecord {
+	now := time.Now()
+	return IngestRecord{
+		Method:          req.Method,
+		URL:             req.URL.String(),
+		RequestHeaders:  req.Header.Clone(),
+		RequestBody:     base64.StdEncoding.EncodeToString(reqBody),
+		Status:          resp.StatusCode,
+		ResponseHeaders: resp.Header.Clone(),
+		ResponseBody:    base64.StdEncoding.EncodeToString(respBody),
+		BodyEncoding:    BodyEncodingBase64,
+		Timestamp:       &now,
+	}
+}
+
+// Validate checks that the record describes a complete exchange
+func (r *IngestRecord) Validate() error {
+	if r.Method == "" {
+		return fmt.Errorf("method is required")
+	}
+	if r.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+	if _, err := url.ParseRequestURI(r.URL); err != nil {
+		return fmt.Errorf("invalid url %q: %w", r.URL, err)
+	}
+	if r.Status < 100 || r.Status > 599 {
+		return fmt.Errorf("status must be between 100 and 599")
+	}
+	if _, _, err := r.bodies(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// bodies returns the decoded request and response bodies
+func (r *IngestRecord) bodies() ([]byte, []byte, error) {
+	switch r.BodyEncoding {
+	case "":
+		return []byte(r.RequestBody), []byte(r.ResponseBody), nil
+	case BodyEncodingBase64:
+		reqBody, err := base64.StdEncoding.DecodeString(r.RequestBody)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid base64 request body: %w", err)
+		}
+		respBody, err := base64.StdEncoding.DecodeString(r.ResponseBody)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid base64 response body: %w", err)
+		}
+		return reqBody, respBody, nil
+	}
+	return nil, nil, fmt.Errorf("unsupported body encoding %q", r.BodyEncoding)
+}
+
+// canonicalHeader returns a copy of headers with canonical names, e.g.
+// Authorization for authorization, as sent by HTTP/2 and non-Go agents
+func canonicalHeader(header http.Header) http.Header {
+	canonical := make(http.Header, len(header))
+	for key, values := range header {
+		name := http.CanonicalHeaderKey(key)
+		canonical[name] = append(canonical[name], values...)
+	}
+	return canonical
+}
+
+// Ingest validates a record captured by a remote proxy and analyzes it as of
+// its timestamp, or else of now
+func (a *Analyzer) Ingest(record IngestRecord) error {
+	if err := record.Validate(); err != nil {
+		return err
+	}
+	reqBody, respBody, err := record.bodies()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(record.Method, record.URL, bytes.NewReader(reqBody))
+	if err != nil {
+		return fmt.Errorf("invalid request: %w", err)
+	}
+	req.Header = canonicalHeader(record.RequestHeaders)
+	resp := &http.Response{
+		StatusCode: record.Status,
+		Header:     canonicalHeader(record.ResponseHeaders),
+	}
+
+	at := time.Now()
+	if record.Timestamp != nil {
+		at = *record.Timestamp
+	}
+	a.processRequest(record.Method, record.URL, req, resp, reqBody, respBody, at)
+	return nil
+}
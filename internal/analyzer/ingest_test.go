@@ -0,0 +1,185 @@
+package analyzer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIngestRecordValidate(t *testing.T) {
+	valid := IngestRecord{Method: "GET", URL: "http://api.example.com/api/users", Status: 200}
+	assert.NoError(t, valid.Validate())
+
+	tests := []struct {
+		name    string
+		modify  func(r *IngestRecord)
+		wantErr string
+	}{
+		{"missing method", func(r *IngestRecord) { r.Method = "" }, "method is required"},
+		{"missing url", func(r *IngestRecord) { r.URL = "" }, "url is required"},
+		{"relative url", func(r *IngestRecord) { r.URL = "api/users" }, "invalid url"},
+		{"missing status", func(r *IngestRecord) { r.Status = 0 }, "status must be between 100 and 599"},
+		{"invalid status", func(r *IngestRecord) { r.Status = 700 }, "status must be between 100 and 599"},
+		{"unknown body encoding", func(r *IngestRecord) { r.BodyEncoding = "hex" }, `unsupported body encoding "hex"`},
+		{"invalid base64 body", func(r *IngestRecord) { r.BodyEncoding, r.ResponseBody = "base64", "{}" }, "invalid base64 response body"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := valid
+			tt.modify(&record)
+			err := record.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestIngest(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+
+	err := a.Ingest(IngestRecord{
+		Method:          "POST",
+		URL:             "http://api.example.com/api/users?invite=true",
+		RequestHeaders:  http.Header{"X-Tenant": []string{"acme"}, "Content-Type": []string{"application/json"}},
+		RequestBody:     `{"name":"alice"}`,
+		Status:          201,
+		ResponseHeaders: http.Header{"Content-Type": []string{"application/json"}},
+		ResponseBody:    `{"id":1,"name":"alice"}`,
+	})
+	require.NoError(t, err)
+
+	data := a.GetData()
+	require.Contains(t, data, "POST /api/users")
+	endpoint := data["POST /api/users"]
+	assert.Equal(t, []interface{}{"alice"}, endpoint.RequestPayload.Examples["name"])
+	assert.Equal(t, []interface{}{"acme"}, endpoint.RequestHeaders.Examples["X-Tenant"])
+	assert.Equal(t, []interface{}{true}, endpoint.URLParameters.Examples["invite"])
+	require.Contains(t, endpoint.ResponseStatuses, 201)
+	assert.Equal(t, []interface{}{float64(1)}, endpoint.ResponseStatuses[201].Payload.Examples["id"])
+
+	// Invalid records are rejected
+	assert.Error(t, a.Ingest(IngestRecord{Method: "GET", URL: "http://api.example.com/x"}))
+	assert.Error(t, a.Ingest(IngestRecord{Method: "BAD METHOD", URL: "http://api.example.com/x", Status: 200}))
+	assert.Len(t, a.GetData(), 1)
+}
+
+func TestIngestLowercaseHeaders(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	a.SetRedactedFields([]string{"Authorization"})
+
+	// HTTP/2 and non-Go agents send lowercase header names
+	require.NoError(t, a.Ingest(IngestRecord{
+		Method:          "GET",
+		URL:             "http://api.example.com/api/profile",
+		RequestHeaders:  http.Header{"authorization": {"Bearer secret"}, "cookie": {"sid=abc"}, "user-agent": {"curl/8.0"}, "x-tenant": {"acme"}},
+		Status:          200,
+		ResponseHeaders: http.Header{"set-cookie": {"sid=def; Path=/"}},
+	}))
+
+	endpoint := a.GetData()["GET /api/profile"]
+	require.NotNil(t, endpoint)
+	headers := endpoint.RequestHeaders.Examples
+	assert.Equal(t, []interface{}{"acme"}, headers["X-Tenant"])
+	assert.NotContains(t, headers, "authorization")
+	assert.NotContains(t, headers, "cookie")
+	assert.NotContains(t, headers, "Cookie")
+	assert.NotContains(t, headers, "user-agent")
+	assert.NotContains(t, headers, "User-Agent")
+	for _, examples := range headers {
+		for _, example := range examples {
+			assert.NotContains(t, example, "secret")
+		}
+	}
+	assert.NotEqual(t, []interface{}{"abc"}, endpoint.Cookies.Examples["sid"])
+}
+
+func TestIngestEncodedBodiesAndTimestamp(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+
+	// A gzip compressed response survives the JSON encoding of the record
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	_, err := zw.Write([]byte(`{"id":7}`))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	req := httptest.NewRequest("GET", "http://api.example.com/api/orders/7", nil)
+	resp := &http.Response{StatusCode: 200, Header: http.Header{"Content-Encoding": {"gzip"}, "Content-Type": {"application/json"}}}
+	record := NewIngestRecord(req, nil, resp, compressed.Bytes())
+	captured := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	record.Timestamp = &captured
+
+	data, err := json.Marshal(record)
+	require.NoError(t, err)
+	var decoded IngestRecord
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.NoError(t, a.Ingest(decoded))
+
+	endpoint := a.GetData()["GET /api/orders/{id}"]
+	require.NotNil(t, endpoint)
+	assert.Equal(t, []interface{}{float64(7)}, endpoint.ResponseStatuses[200].Payload.Examples["id"])
+
+	// The timestamp of the record is the time it was seen
+	assert.True(t, captured.Equal(endpoint.LastSeen), endpoint.LastSeen)
+	changes := a.Changes(time.Time{})
+	require.NotEmpty(t, changes)
+	assert.True(t, captured.Equal(changes[0].Time), changes[0].Time)
+
+	// An older record doesn't move the last seen time back
+	earlier := captured.Add(-time.Hour)
+	decoded.Timestamp = &earlier
+	require.NoError(t, a.Ingest(decoded))
+	assert.True(t, captured.Equal(a.GetData()["GET /api/orders/{id}"].LastSeen))
+}
+
+func TestHandleIngest(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	server := NewServer(a)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.handleIngest(w, httptest.NewRequest("POST", "/api/ingest", strings.NewReader(body)))
+		return w
+	}
+
+	// A single record
+	w := post(`{"method":"GET","url":"http://api.example.com/api/users/1","status":200,"responseBody":"{\"id\":1}"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"ingested","count":1}`, w.Body.String())
+
+	// A batch of records
+	w = post(`[
+		{"method":"GET","url":"http://api.example.com/api/orders","status":200,"responseBody":"[]","timestamp":"2025-01-02T03:04:05Z"},
+		{"method":"DELETE","url":"http://api.example.com/api/orders/7","status":204}
+	]`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"ingested","count":2}`, w.Body.String())
+	assert.Len(t, a.GetData(), 3)
+
+	// A batch with an invalid record is rejected as a whole
+	w = post(`[
+		{"method":"GET","url":"http://api.example.com/api/products","status":200},
+		{"method":"GET","status":200}
+	]`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Invalid record 1: url is required")
+	assert.Len(t, a.GetData(), 3)
+
+	w = post(`{"method":`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	server.handleIngest(w, httptest.NewRequest("GET", "/api/ingest", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
@@ -0,0 +1,163 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// InsomniaExport represents an Insomnia export in the v4 format
+type InsomniaExport struct {
+	Type         string             `json:"_type"`
+	ExportFormat int                `json:"__export_format"`
+	ExportSource string             `json:"__export_source"`
+	Resources    []InsomniaResource `json:"resources"`
+}
+
+// InsomniaResource represents a workspace, environment, request group (folder)
+// or request of an Insomnia export
+type InsomniaResource struct {
+	ID          string            `json:"_id"`
+	Type        string            `json:"_type"`
+	ParentID    string            `json:"parentId,omitempty"`
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Data        map[string]string `json:"data,omitempty"`
+	Method      string            `json:"method,omitempty"`
+	URL         string            `json:"url,omitempty"`
+	Headers     []InsomniaPair    `json:"headers,omitempty"`
+	Parameters  []InsomniaPair    `json:"parameters,omitempty"`
+	Body        *InsomniaBody     `json:"body,omitempty"`
+}
+
+// InsomniaPair represents a header or query parameter of an Insomnia request
+type InsomniaPair struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// InsomniaBody represents the body of an Insomnia request
+type InsomniaBody struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// Fixed IDs of the Insomnia workspace and its base environment
+const (
+	insomniaWorkspaceID   = "wrk_docurift"
+	insomniaEnvironmentID = "env_docurift"
+)
+
+// GenerateInsomniaExport generates an Insomnia v4 export from analyzer data,
+// like the Postman collection: a request group per resource, as in the tags of
+// the OpenAPI specification, holding a request per endpoint with the first
+// example of its headers, query parameters and JSON body. Request URLs start
+// with the base_url variable of the base environment, set to the first server
+// of the specification. IDs are derived from the endpoints so importing a new
+// export updates the requests imported before.
+func (a *Analyzer) GenerateInsomniaExport() *InsomniaExport {
+	endpoints := a.GetData()
+
+	baseURL := a.baseURL()
+	export := &InsomniaExport{
+		Type:         "export",
+		ExportFormat: 4,
+		ExportSource: "docurift",
+		Resources: []InsomniaResource{
+			{
+				ID:          insomniaWorkspaceID,
+				Type:        "workspace",
+				Name:        "API Collection",
+				Description: "Generated API collection from analyzer data",
+			},
+			{
+				ID:       insomniaEnvironmentID,
+				Type:     "environment",
+				ParentID: insomniaWorkspaceID,
+				Name:     "Base Environment",
+				Data:     map[string]string{"base_url": baseURL},
+			},
+		},
+	}
+
+	// Group endpoints by resource, in order of path and method
+	keys := sortedKeys(endpoints)
+	sort.SliceStable(keys, func(i, j int) bool {
+		first, second := endpoints[keys[i]], endpoints[keys[j]]
+		if first.URL != second.URL {
+			return first.URL < second.URL
+		}
+		return first.Method < second.Method
+	})
+	endpointsByResource := make(map[string][]*EndpointData)
+	for _, key := range keys {
+		endpoint := endpoints[key]
+		resource := resourceTag(endpoint.URL)
+		endpointsByResource[resource] = append(endpointsByResource[resource], endpoint)
+	}
+
+	for _, resource := range sortedKeys(endpointsByResource) {
+		groupID := insomniaWorkspaceID
+		if resource != "" {
+			groupID = "fld_" + insomniaID(resource)
+			export.Resources = append(export.Resources, InsomniaResource{
+				ID:          groupID,
+				Type:        "request_group",
+				ParentID:    insomniaWorkspaceID,
+				Name:        resource,
+				Description: fmt.Sprintf("Endpoints for %s", resource),
+			})
+		}
+		for _, endpoint := range endpointsByResource[resource] {
+			export.Resources = append(export.Resources, createInsomniaRequest(endpoint, groupID))
+		}
+	}
+	return export
+}
+
+// createInsomniaRequest creates an Insomnia request from an endpoint
+func createInsomniaRequest(endpoint *EndpointData, parentID string) InsomniaResource {
+	path := templatePath(endpoint.URL)
+	request := InsomniaResource{
+		ID:       "req_" + insomniaID(endpoint.Method+" "+endpoint.URL),
+		Type:     "request",
+		ParentID: parentID,
+		Name:     fmt.Sprintf("%s %s", endpoint.Method, path),
+		Method:   endpoint.Method,
+		URL:      "{{ _.base_url }}" + path,
+	}
+
+	if endpoint.RequestHeaders != nil {
+		for _, header := range sortedKeys(endpoint.RequestHeaders.Examples) {
+			if values := endpoint.RequestHeaders.Examples[header]; len(values) > 0 {
+				request.Headers = append(request.Headers, InsomniaPair{Name: header, Value: fmt.Sprintf("%v", values[0])})
+			}
+		}
+	}
+
+	if endpoint.URLParameters != nil {
+		for _, param := range sortedKeys(endpoint.URLParameters.Examples) {
+			if values := endpoint.URLParameters.Examples[param]; len(values) > 0 {
+				request.Parameters = append(request.Parameters, InsomniaPair{Name: param, Value: exampleString(values[0])})
+			}
+		}
+	}
+
+	if example := createExampleFromStore(endpoint.RequestPayload); example != nil {
+		if jsonData, err := json.MarshalIndent(example, "", "  "); err == nil {
+			request.Body = &InsomniaBody{
+				MimeType: mediaTypeOrDefault(endpoint.RequestContentType),
+				Text:     string(jsonData),
+			}
+		}
+	}
+	return request
+}
+
+// insomniaID returns a stable resource ID suffix for a name
+func insomniaID(name string) string {
+	sum := sha256.Sum256([]byte(name))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,88 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateInsomniaExport(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	a.SetServerURL("https://api.example.com")
+
+	process := func(method, url, body string) {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Tenant", "acme")
+		a.ProcessRequest(method, url, req, &http.Response{StatusCode: 200}, []byte(body), []byte(`{"id":1}`))
+	}
+	process("POST", "http://example.com/api/v1/users?notify=true", `{"name":"alice","address":{"city":"Paris"}}`)
+	process("GET", "http://example.com/api/v1/users/1", "")
+	process("GET", "http://example.com/api/v1/orders", "")
+	process("GET", "http://example.com/", "")
+
+	export := a.GenerateInsomniaExport()
+	assert.Equal(t, "export", export.Type)
+	assert.Equal(t, 4, export.ExportFormat)
+
+	resources := make(map[string]InsomniaResource)
+	var order []string
+	for _, resource := range export.Resources {
+		_, duplicate := resources[resource.ID]
+		require.False(t, duplicate, "duplicate ID %s", resource.ID)
+		resources[resource.ID] = resource
+		order = append(order, resource.Type+" "+resource.Name)
+	}
+	assert.Equal(t, []string{
+		"workspace API Collection",
+		"environment Base Environment",
+		"request GET /",
+		"request_group orders",
+		"request GET /api/v1/orders",
+		"request_group users",
+		"request POST /api/v1/users",
+		"request GET /api/v1/users/{id}",
+	}, order)
+	assert.Equal(t, "https://api.example.com", export.Resources[1].Data["base_url"])
+
+	// Requests belong to the group of their resource, or else to the workspace
+	post := export.Resources[6]
+	assert.Equal(t, "users", resources[post.ParentID].Name)
+	assert.Equal(t, insomniaWorkspaceID, export.Resources[2].ParentID)
+
+	assert.Equal(t, "POST", post.Method)
+	assert.Equal(t, "{{ _.base_url }}/api/v1/users", post.URL)
+	assert.Contains(t, post.Headers, InsomniaPair{Name: "X-Tenant", Value: "acme"})
+	assert.Equal(t, []InsomniaPair{{Name: "notify", Value: "true"}}, post.Parameters)
+	require.NotNil(t, post.Body)
+	assert.Equal(t, "application/json", post.Body.MimeType)
+	assert.JSONEq(t, `{"name":"alice","address":{"city":"Paris"}}`, post.Body.Text)
+	assert.Nil(t, export.Resources[7].Body)
+
+	// IDs are stable across exports
+	assert.Equal(t, export, a.GenerateInsomniaExport())
+}
+
+func TestHandleInsomnia(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	req := httptest.NewRequest("GET", "https://example.com/api/users", nil)
+	a.ProcessRequest("GET", "https://example.com/api/users", req, &http.Response{StatusCode: 200}, nil, []byte(`[{"id":1}]`))
+
+	w := httptest.NewRecorder()
+	NewServer(a).Handler().ServeHTTP(w, httptest.NewRequest("GET", "/api/insomnia.json", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "attachment; filename=insomnia.json", w.Header().Get("Content-Disposition"))
+
+	var export map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &export))
+	assert.Equal(t, "export", export["_type"])
+	assert.Equal(t, float64(4), export["__export_format"])
+	assert.Len(t, export["resources"], 4)
+}
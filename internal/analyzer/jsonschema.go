@@ -0,0 +1,102 @@
+package analyzer
+
+// jsonSchemaDialect is the JSON Schema version of the exported schemas
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema is a standalone JSON Schema document or subschema. Type is a
+// string, or a list including "null" for nullable values.
+type JSONSchema struct {
+	Schema      string                 `json:"$schema,omitempty"`
+	Title       string                 `json:"title,omitempty"`
+	Type        interface{}            `json:"type,omitempty"`
+	Format      string                 `json:"format,omitempty"`
+	Pattern     string                 `json:"pattern,omitempty"`
+	Properties  map[string]*JSONSchema `json:"properties,omitempty"`
+	Items       *JSONSchema            `json:"items,omitempty"`
+	OneOf       []*JSONSchema          `json:"oneOf,omitempty"`
+	Required    []string               `json:"required,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Enum        []interface{}          `json:"enum,omitempty"`
+	Examples    []interface{}          `json:"examples,omitempty"`
+}
+
+// GenerateJSONSchemas generates a draft 2020-12 JSON Schema document for the
+// request body and each response body of every operation, for validating
+// payloads without a full OpenAPI specification. The documents are keyed like
+// "POST /users/{id} request" and "POST /users/{id} response 200". Bodies
+// without any observed field, e.g. of 204 responses, are left out.
+func (a *Analyzer) GenerateJSONSchemas() map[string]interface{} {
+	openAPI := a.generateOpenAPI()
+	schemas := make(map[string]interface{})
+
+	for _, path := range sortedKeys(openAPI.Paths) {
+		pathItem := openAPI.Paths[path]
+		for _, operation := range []struct {
+			method    string
+			operation *Operation
+		}{{"GET", pathItem.Get}, {"POST", pathItem.Post}, {"PUT", pathItem.Put}, {"DELETE", pathItem.Delete}} {
+			if operation.operation == nil {
+				continue
+			}
+			name := operation.method + " " + path
+			if requestBody := operation.operation.RequestBody; requestBody != nil && len(requestBody.Content) > 0 {
+				addJSONSchema(schemas, name+" request", bodySchema(requestBody.Content))
+			}
+			for status, response := range operation.operation.Responses {
+				if len(response.Content) > 0 {
+					addJSONSchema(schemas, name+" response "+status, bodySchema(response.Content))
+				}
+			}
+		}
+	}
+	return schemas
+}
+
+// addJSONSchema adds the JSON Schema document of a body, unless no field was observed
+func addJSONSchema(schemas map[string]interface{}, name string, schema Schema) {
+	if schema.Type == "object" && len(schema.Properties) == 0 && !schema.Nullable {
+		return
+	}
+	document := jsonSchema(schema)
+	document.Schema = jsonSchemaDialect
+	document.Title = name
+	schemas[name] = document
+}
+
+// jsonSchema converts an OpenAPI 3.0 schema, replacing nullable with a null
+// type and the singular example with the examples list
+func jsonSchema(schema Schema) *JSONSchema {
+	converted := &JSONSchema{
+		Format:      schema.Format,
+		Pattern:     schema.Pattern,
+		Required:    schema.Required,
+		Description: schema.Description,
+		Enum:        schema.Enum,
+		Examples:    schema.Examples,
+	}
+	if schema.Type != "" {
+		converted.Type = schema.Type
+		if schema.Nullable {
+			converted.Type = []string{schema.Type, "null"}
+		}
+	}
+	if schema.Nullable && len(schema.Enum) > 0 {
+		converted.Enum = append(append([]interface{}(nil), schema.Enum...), nil)
+	}
+	if len(converted.Examples) == 0 && schema.Example != nil {
+		converted.Examples = []interface{}{schema.Example}
+	}
+	if schema.Properties != nil {
+		converted.Properties = make(map[string]*JSONSchema, len(schema.Properties))
+		for name, property := range schema.Properties {
+			converted.Properties[name] = jsonSchema(property)
+		}
+	}
+	if schema.Items != nil {
+		converted.Items = jsonSchema(*schema.Items)
+	}
+	for _, variant := range schema.OneOf {
+		converted.OneOf = append(converted.OneOf, jsonSchema(variant))
+	}
+	return converted
+}
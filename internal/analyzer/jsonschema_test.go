@@ -0,0 +1,83 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// compileJSONSchema compiles a generated JSON Schema document
+func compileJSONSchema(t *testing.T, document interface{}) *jsonschema.Schema {
+	data, err := json.Marshal(document)
+	require.NoError(t, err)
+	compiler := jsonschema.NewCompiler()
+	require.NoError(t, compiler.AddResource("schema.json", bytes.NewReader(data)))
+	schema, err := compiler.Compile("schema.json")
+	require.NoError(t, err)
+	return schema
+}
+
+func TestGenerateJSONSchemas(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+
+	process := func(method, url, reqBody string, status int, respBody string) {
+		req := httptest.NewRequest(method, url, strings.NewReader(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		a.ProcessRequest(method, url, req, &http.Response{StatusCode: status}, []byte(reqBody), []byte(respBody))
+	}
+	process("POST", "http://example.com/users", `{"name":"alice","nickname":null,"roles":["admin"]}`, 201, `{"id":1,"address":{"city":"Paris"}}`)
+	process("POST", "http://example.com/users", `{"name":"bob","nickname":"b","roles":["user"]}`, 201, `{"id":2,"address":{"city":"Lyon"}}`)
+	process("DELETE", "http://example.com/users/1", "", 204, "")
+
+	schemas := a.GenerateJSONSchemas()
+	assert.ElementsMatch(t, []string{"POST /users request", "POST /users response 201"}, sortedKeys(schemas), "bodies without fields are left out")
+
+	request := schemas["POST /users request"].(*JSONSchema)
+	assert.Equal(t, "https://json-schema.org/draft/2020-12/schema", request.Schema)
+	assert.Equal(t, "object", request.Type)
+	assert.Equal(t, []string{"string", "null"}, request.Properties["nickname"].Type)
+	assert.Equal(t, "array", request.Properties["roles"].Type)
+	assert.Equal(t, "string", request.Properties["roles"].Items.Type)
+	assert.Empty(t, request.Properties["roles"].Schema, "only documents declare $schema")
+
+	// The documents are valid and accept payloads like the observed ones
+	schema := compileJSONSchema(t, request)
+	for _, payload := range []string{`{"name":"alice","nickname":null,"roles":[]}`, `{"name":"bob","nickname":"b","roles":["admin","user"]}`} {
+		var value interface{}
+		require.NoError(t, json.Unmarshal([]byte(payload), &value))
+		assert.NoError(t, schema.Validate(value), payload)
+	}
+	var invalid interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{"name":1,"roles":"admin"}`), &invalid))
+	assert.Error(t, schema.Validate(invalid))
+
+	response := compileJSONSchema(t, schemas["POST /users response 201"])
+	var value interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{"id":2,"address":{"city":"Paris"}}`), &value))
+	assert.NoError(t, response.Validate(value))
+}
+
+func TestHandleJSONSchema(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	req := httptest.NewRequest("GET", "https://example.com/api/users/1", nil)
+	a.ProcessRequest("GET", "https://example.com/api/users/1", req, &http.Response{StatusCode: 200}, nil, []byte(`{"id":1}`))
+
+	w := httptest.NewRecorder()
+	NewServer(a).Handler().ServeHTTP(w, httptest.NewRequest("GET", "/api/jsonschema?download=1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "attachment; filename=jsonschema.json", w.Header().Get("Content-Disposition"))
+
+	var documents map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &documents))
+	require.Contains(t, documents, "GET /api/users/{id} response 200")
+	compileJSONSchema(t, documents["GET /api/users/{id} response 200"])
+}
@@ -0,0 +1,112 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GenerateK6Script generates a k6 load test script sending the example request
+// of each endpoint, grouped by the first segment of their path like the
+// Postman folders. Requests go to the BASE_URL environment variable of the
+// k6 run, or else to the first server of the specifications, and carry the
+// recorded headers except hop-by-hop ones, with redacted values replaced by
+// a REDACTED placeholder. Each response is checked to have a recorded status.
+func (a *Analyzer) GenerateK6Script() string {
+	endpoints := a.GetData()
+
+	// Group endpoints by base path, in order of path and method so the script
+	// is the same for identical data
+	keys := sortedKeys(endpoints)
+	sort.SliceStable(keys, func(i, j int) bool {
+		first, second := endpoints[keys[i]], endpoints[keys[j]]
+		if first.URL != second.URL {
+			return first.URL < second.URL
+		}
+		return first.Method < second.Method
+	})
+	endpointsByPath := make(map[string][]*EndpointData)
+	for _, key := range keys {
+		endpoint := endpoints[key]
+		if len(endpoint.ResponseStatuses) == 0 {
+			continue
+		}
+		path := strings.Split(endpoint.URL, "/")[1] // Get the first segment after /
+		endpointsByPath[path] = append(endpointsByPath[path], endpoint)
+	}
+
+	var script strings.Builder
+	script.WriteString("// k6 load test generated by DocuRift from observed traffic\n")
+	script.WriteString("import http from 'k6/http';\n")
+	script.WriteString("import { check, group } from 'k6';\n\n")
+	fmt.Fprintf(&script, "const BASE_URL = __ENV.BASE_URL || %s;\n\n", jsString(strings.TrimSuffix(a.baseURL(), "/")))
+	script.WriteString("export default function () {\n")
+	for _, path := range sortedKeys(endpointsByPath) {
+		fmt.Fprintf(&script, "  group(%s, function () {\n", jsString("/"+path))
+		for _, endpoint := range endpointsByPath[path] {
+			a.writeK6Request(&script, endpoint)
+		}
+		script.WriteString("  });\n")
+	}
+	script.WriteString("}\n")
+	return script.String()
+}
+
+// writeK6Request writes the request of an endpoint and the check of its status
+func (a *Analyzer) writeK6Request(script *strings.Builder, endpoint *EndpointData) {
+	headers := make(map[string]string)
+	for key, value := range a.placeholderExamples(endpoint.RequestHeaders) {
+		if !replaySkippedHeaders[http.CanonicalHeaderKey(key)] {
+			headers[key] = value
+		}
+	}
+
+	body := "null"
+	if endpoint.RequestPayload != nil && len(endpoint.RequestPayload.Examples) > 0 {
+		if data, err := json.Marshal(createExampleFromStore(a.placeholderStore(endpoint.RequestPayload))); err == nil {
+			body = jsString(string(data))
+			headers["Content-Type"] = mediaTypeOrDefault(endpoint.RequestContentType)
+		}
+	}
+
+	statuses := make([]int, 0, len(endpoint.ResponseStatuses))
+	for status := range endpoint.ResponseStatuses {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+	statusList := make([]string, len(statuses))
+	for i, status := range statuses {
+		statusList[i] = strconv.Itoa(status)
+	}
+
+	fmt.Fprintf(script, "    // %s %s\n", endpoint.Method, endpoint.URL)
+	script.WriteString("    {\n")
+	fmt.Fprintf(script, "      const res = http.request(%s, BASE_URL + %s, %s, {\n", jsString(endpoint.Method), jsString(a.examplePath(endpoint)), body)
+	script.WriteString("        headers: {")
+	for i, key := range sortedKeys(headers) {
+		if i > 0 {
+			script.WriteString(",")
+		}
+		fmt.Fprintf(script, "\n          %s: %s", jsString(key), jsString(headers[key]))
+	}
+	if len(headers) > 0 {
+		script.WriteString("\n        ")
+	}
+	script.WriteString("},\n")
+	fmt.Fprintf(script, "        tags: { name: %s },\n", jsString(endpoint.Method+" "+endpoint.URL))
+	script.WriteString("      });\n")
+	fmt.Fprintf(script, "      check(res, { %s: (r) => [%s].includes(r.status) });\n",
+		jsString(fmt.Sprintf("%s %s status is %s", endpoint.Method, endpoint.URL, strings.Join(statusList, " or "))), strings.Join(statusList, ", "))
+	script.WriteString("    }\n")
+}
+
+// jsString returns a JavaScript string literal of s. JSON strings are valid
+// JavaScript, and encoding/json escapes the line separators JavaScript
+// doesn't allow in literals.
+func jsString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
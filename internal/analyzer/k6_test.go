@@ -0,0 +1,105 @@
+package analyzer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// balancedScript reports whether the brackets of a script are balanced and
+// its string literals terminated, skipping brackets inside strings and comments
+func balancedScript(script string) bool {
+	var stack []rune
+	pairs := map[rune]rune{')': '(', ']': '[', '}': '{'}
+	runes := []rune(script)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; r {
+		case '"', '\'', '`':
+			for i++; i < len(runes) && runes[i] != r; i++ {
+				if runes[i] == '\\' {
+					i++
+				} else if runes[i] == '\n' && r != '`' {
+					return false
+				}
+			}
+			if i >= len(runes) {
+				return false
+			}
+		case '/':
+			if i+1 < len(runes) && runes[i+1] == '/' {
+				for i < len(runes) && runes[i] != '\n' {
+					i++
+				}
+			}
+		case '(', '[', '{':
+			stack = append(stack, r)
+		case ')', ']', '}':
+			if len(stack) == 0 || stack[len(stack)-1] != pairs[r] {
+				return false
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+	return len(stack) == 0
+}
+
+func TestBalancedScript(t *testing.T) {
+	assert.True(t, balancedScript(`f({ "a}": [1, '\'('] }); // ) unbalanced comment`))
+	assert.False(t, balancedScript(`f({ "a": [1 }]);`))
+	assert.False(t, balancedScript(`f("unterminated);`))
+}
+
+func TestGenerateK6Script(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	a.SetRedactedFields([]string{"password", "Authorization"})
+	a.SetCaptureErrors(true)
+	a.SetHeaderFilters(nil, []string{"Connection"})
+
+	req := httptest.NewRequest("GET", "https://example.com/users/42?expand=orders", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Connection", "keep-alive")
+	req.Header.Set("X-Tenant", `acme "north"`)
+	a.ProcessRequest("GET", "https://example.com/users/42?expand=orders", req, &http.Response{StatusCode: 200}, nil, []byte(`{"id":42}`))
+	req = httptest.NewRequest("GET", "https://example.com/users/43", nil)
+	a.ProcessRequest("GET", "https://example.com/users/43", req, &http.Response{StatusCode: 404}, nil, []byte(`{"error":"not found"}`))
+	req = httptest.NewRequest("POST", "https://example.com/users", nil)
+	req.Header.Set("Content-Type", "application/json")
+	a.ProcessRequest("POST", "https://example.com/users", req, &http.Response{StatusCode: 201}, []byte(`{"name":"bob","password":"hunter2"}`), []byte(`{"id":1}`))
+	req = httptest.NewRequest("GET", "https://example.com/orders", nil)
+	a.ProcessRequest("GET", "https://example.com/orders", req, &http.Response{StatusCode: 200}, nil, []byte(`[]`))
+
+	script := a.GenerateK6Script()
+	assert.True(t, balancedScript(script), script)
+	assert.Contains(t, script, `const BASE_URL = __ENV.BASE_URL || "http://localhost:8080";`)
+	assert.Contains(t, script, `group("/orders", function () {`)
+	assert.Contains(t, script, `group("/users", function () {`)
+	assert.Contains(t, script, `const res = http.request("GET", BASE_URL + "/orders", null, {`)
+	assert.Contains(t, script, `const res = http.request("POST", BASE_URL + "/users", "{\"name\":\"bob\",\"password\":\"REDACTED\"}", {`)
+	assert.Contains(t, script, `const res = http.request("GET", BASE_URL + "/users/42?expand=orders", null, {`)
+	assert.Contains(t, script, `"Authorization": "REDACTED"`)
+	assert.Contains(t, script, `"X-Tenant": "acme \"north\""`)
+	assert.Contains(t, script, `"Content-Type": "application/json"`)
+	assert.Contains(t, script, `check(res, { "GET /users/{id} status is 200 or 404": (r) => [200, 404].includes(r.status) });`)
+	assert.NotContains(t, script, "Connection")
+	assert.NotContains(t, script, "hunter2")
+	assert.NotContains(t, script, "secret")
+}
+
+func TestHandleK6(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	a.SetServerURL("https://api.example.com/")
+	handler := NewServer(a).Handler()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/k6.js?download=1", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/javascript; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "attachment; filename=k6.js", w.Header().Get("Content-Disposition"))
+	assert.Contains(t, w.Body.String(), `__ENV.BASE_URL || "https://api.example.com";`)
+	assert.True(t, balancedScript(w.Body.String()))
+}
@@ -0,0 +1,62 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// RedactBody returns a request or response body for logging with the
+// configured redaction rules applied: redacted fields are replaced and, with
+// automatic PII redaction, detected PII is masked. Bodies that aren't JSON
+// can't be redacted and are replaced by their size. The result is truncated to
+// maxLength bytes unless maxLength is 0.
+func (a *Analyzer) RedactBody(body []byte, maxLength int) string {
+	if len(body) == 0 {
+		return ""
+	}
+	var payload interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return fmt.Sprintf("<%d bytes of non-JSON data>", len(body))
+	}
+	data, err := json.Marshal(a.redactJSON("", payload))
+	if err != nil {
+		return fmt.Sprintf("<%d bytes of JSON data>", len(body))
+	}
+	if maxLength > 0 && len(data) > maxLength {
+		return string(data[:maxLength]) + "..."
+	}
+	return string(data)
+}
+
+// redactJSON returns a copy of a decoded JSON value at the given schema path
+// with redacted fields and detected PII replaced as they are in examples.
+// Redacted objects and arrays are replaced as a whole.
+func (a *Analyzer) redactJSON(path string, value interface{}) interface{} {
+	if path != "" && a.shouldRedact(path) {
+		return a.redactValue(value)
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			redacted[key] = a.redactJSON(childPath, val)
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, val := range v {
+			redacted[i] = a.redactJSON(path+"[]", val)
+		}
+		return redacted
+	}
+
+	if a.shouldAutoRedactPII() {
+		return sanitizeWithPatterns(value, a.getSensitivePatterns())
+	}
+	return value
+}
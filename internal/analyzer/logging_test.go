@@ -0,0 +1,29 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactBody(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	a.SetRedactedFields([]string{"password", "card.number", "tokens"})
+
+	body := []byte(`{"user":"alice","password":"s3cret","card":{"number":"4111111111111111","brand":"visa"},"tokens":["a","b"],"items":[{"password":"x"}]}`)
+	assert.JSONEq(t,
+		`{"user":"alice","password":"REDACTED","card":{"number":"REDACTED","brand":"visa"},"tokens":"REDACTED","items":[{"password":"REDACTED"}]}`,
+		a.RedactBody(body, 0))
+
+	// Detected PII is masked when automatic redaction is enabled
+	a.SetAutoRedactPII(true)
+	assert.JSONEq(t, `[{"email":"john.doe@example.com","id":1}]`, a.RedactBody([]byte(`[{"email":"jane.doe@corp.io","id":1}]`), 0))
+
+	// Long bodies are truncated
+	assert.Equal(t, `{"user":"a...`, a.RedactBody([]byte(`{"user":"alice"}`), 10))
+
+	// Bodies that can't be redacted are not logged
+	assert.Equal(t, "<9 bytes of non-JSON data>", a.RedactBody([]byte("user=jane"), 0))
+	assert.Equal(t, "", a.RedactBody(nil, 0))
+}
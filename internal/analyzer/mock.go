@@ -0,0 +1,188 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+)
+
+// replaySkippedHeaders are the recorded headers left out when replaying them
+// with a reconstructed example body, as mock responses and load test requests
+// do: hop-by-hop headers, and headers describing the original encoding of the
+// body
+var replaySkippedHeaders = map[string]bool{
+	"Connection":          true,
+	"Keep-Alive":          true,
+	"Proxy-Authenticate":  true,
+	"Proxy-Authorization": true,
+	"Proxy-Connection":    true,
+	"Te":                  true,
+	"Trailer":             true,
+	"Transfer-Encoding":   true,
+	"Upgrade":             true,
+	"Content-Length":      true,
+	"Content-Encoding":    true,
+	"Content-Type":        true,
+}
+
+// MockServer answers requests with the examples recorded for their endpoint,
+// so it can stand in for the backend, e.g. for frontend development
+type MockServer struct {
+	endpoints []*EndpointData // Sorted by path and method
+}
+
+// mockRouteNotFound is the body of the 501 response to unknown routes
+type mockRouteNotFound struct {
+	Error     string   `json:"error"`
+	Endpoints []string `json:"endpoints"`
+}
+
+// NewMockServer creates a mock server replaying the examples of endpoints,
+// which must not be modified afterwards
+func NewMockServer(endpoints map[string]*EndpointData) *MockServer {
+	m := &MockServer{}
+	for _, key := range sortedKeys(endpoints) {
+		m.endpoints = append(m.endpoints, endpoints[key])
+	}
+	sort.SliceStable(m.endpoints, func(i, j int) bool {
+		if m.endpoints[i].URL != m.endpoints[j].URL {
+			return m.endpoints[i].URL < m.endpoints[j].URL
+		}
+		return m.endpoints[i].Method < m.endpoints[j].Method
+	})
+	return m
+}
+
+// LoadStateFile loads the endpoints saved in an analyzer.json file
+func LoadStateFile(path string) (map[string]*EndpointData, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	endpoints, err := decodeState(data, path)
+	if err != nil {
+		return nil, err
+	}
+	if endpoints == nil {
+		return nil, fmt.Errorf("%s was saved by a different version, expected version %s", path, SchemaVersion)
+	}
+	return endpoints, nil
+}
+
+// Endpoints returns the endpoints served by the mock server as "METHOD path"
+func (m *MockServer) Endpoints() []string {
+	endpoints := make([]string, len(m.endpoints))
+	for i, endpoint := range m.endpoints {
+		endpoints[i] = endpoint.Method + " " + endpoint.URL
+	}
+	return endpoints
+}
+
+// match returns the endpoint of a request, or nil if none was recorded. Path
+// parameters match any value, and literal segments are preferred to them, so
+// /users/me matches GET /users/me rather than GET /users/{id}.
+func (m *MockServer) match(method, path string) *EndpointData {
+	segments := strings.Split(path, "/")
+	var best *EndpointData
+	bestLiterals := -1
+	for _, endpoint := range m.endpoints {
+		if endpoint.Method != method {
+			continue
+		}
+		if literals, ok := matchPathTemplate(endpoint.URL, segments); ok && literals > bestLiterals {
+			best, bestLiterals = endpoint, literals
+		}
+	}
+	return best
+}
+
+// matchPathTemplate reports whether the segments of a request path match a
+// normalized path, and how many literal segments they matched
+func matchPathTemplate(template string, segments []string) (int, bool) {
+	templateSegments := strings.Split(template, "/")
+	if len(templateSegments) != len(segments) {
+		return 0, false
+	}
+	literals := 0
+	for i, segment := range templateSegments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			if segments[i] == "" {
+				return 0, false
+			}
+			continue
+		}
+		if segment != segments[i] {
+			return 0, false
+		}
+		literals++
+	}
+	return literals, true
+}
+
+// mockStatus returns the status code a mock response uses: the lowest
+// recorded 2xx status, or else the lowest recorded status
+func mockStatus(endpoint *EndpointData) (int, bool) {
+	statuses := make([]int, 0, len(endpoint.ResponseStatuses))
+	for status := range endpoint.ResponseStatuses {
+		statuses = append(statuses, status)
+	}
+	if len(statuses) == 0 {
+		return 0, false
+	}
+	sort.Ints(statuses)
+	for _, status := range statuses {
+		if status >= 200 && status < 300 {
+			return status, true
+		}
+	}
+	return statuses[0], true
+}
+
+// ServeHTTP answers a request with the recorded status code, headers and a
+// body example of its endpoint, or with 501 listing the known endpoints
+func (m *MockServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	endpoint := m.match(r.Method, r.URL.Path)
+	var status int
+	if endpoint != nil {
+		var ok bool
+		status, ok = mockStatus(endpoint)
+		if !ok {
+			endpoint = nil
+		}
+	}
+	if endpoint == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotImplemented)
+		json.NewEncoder(w).Encode(mockRouteNotFound{
+			Error:     fmt.Sprintf("No recorded endpoint matches %s %s", r.Method, r.URL.Path),
+			Endpoints: m.Endpoints(),
+		})
+		return
+	}
+
+	response := endpoint.ResponseStatuses[status]
+	if response.Headers != nil {
+		for _, key := range sortedKeys(response.Headers.Examples) {
+			values := response.Headers.Examples[key]
+			if len(values) == 0 || replaySkippedHeaders[http.CanonicalHeaderKey(key)] {
+				continue
+			}
+			w.Header().Set(key, fmt.Sprint(values[0]))
+		}
+	}
+
+	var body []byte
+	if example := createExampleFromStore(response.Payload); example != nil {
+		var err error
+		if body, err = json.Marshal(example); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to encode the example: %v", err), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", mediaTypeOrDefault(response.ContentType))
+	}
+	w.WriteHeader(status)
+	w.Write(body)
+}
@@ -0,0 +1,82 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockServer(t *testing.T) {
+	dir := t.TempDir()
+	a := NewAnalyzer(dir, 3600)
+	a.SetCaptureErrors(true)
+	process := func(method, url string, status int, header http.Header, respBody string) {
+		req := httptest.NewRequest(method, url, nil)
+		a.ProcessRequest(method, url, req, &http.Response{StatusCode: status, Header: header}, nil, []byte(respBody))
+	}
+	process("GET", "https://example.com/users/42", 404, nil, `{"error":"not found"}`)
+	process("GET", "https://example.com/users/42", 200, http.Header{
+		"Content-Type":      {"application/json"},
+		"Content-Length":    {"27"},
+		"Transfer-Encoding": {"chunked"},
+		"X-Request-Id":      {"abc"},
+	}, `{"id":42,"name":"alice"}`)
+	process("GET", "https://example.com/users/me", 200, nil, `{"id":1,"name":"me"}`)
+	process("DELETE", "https://example.com/users/42", 204, nil, "")
+	require.NoError(t, a.Save())
+	a.Stop()
+
+	endpoints, err := LoadStateFile(filepath.Join(dir, "analyzer.json"))
+	require.NoError(t, err)
+	mock := NewMockServer(endpoints)
+	assert.Equal(t, []string{"GET /users/me", "DELETE /users/{id}", "GET /users/{id}"}, mock.Endpoints())
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mock.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	// Path parameters match any value, and the 2xx response is preferred
+	w := serve("GET", "/users/7")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"id":42,"name":"alice"}`, w.Body.String())
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, "abc", w.Header().Get("X-Request-Id"))
+	assert.Empty(t, w.Header().Get("Transfer-Encoding"))
+	assert.Empty(t, w.Header().Get("Content-Length"))
+
+	// Literal segments win over path parameters
+	w = serve("GET", "/users/me")
+	assert.JSONEq(t, `{"id":1,"name":"me"}`, w.Body.String())
+
+	w = serve("DELETE", "/users/7")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	// Unknown routes list the known endpoints
+	w = serve("POST", "/users")
+	require.Equal(t, http.StatusNotImplemented, w.Code)
+	var notFound mockRouteNotFound
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &notFound))
+	assert.Equal(t, "No recorded endpoint matches POST /users", notFound.Error)
+	assert.Equal(t, mock.Endpoints(), notFound.Endpoints)
+	assert.Equal(t, http.StatusNotImplemented, serve("GET", "/users/7/orders").Code)
+}
+
+func TestLoadStateFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	_, err := LoadStateFile(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+
+	path := filepath.Join(dir, "analyzer.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"version":"0.1","endpoints":{}}`), 0644))
+	_, err = LoadStateFile(path)
+	assert.ErrorContains(t, err, "saved by a different version")
+}
@@ -1,7 +1,13 @@
 package analyzer
 
 import (
+	"encoding/json"
 	"fmt"
+	"maps"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -9,13 +15,39 @@ import (
 type OpenAPI struct {
 	OpenAPI    string              `json:"openapi"`
 	Info       Info                `json:"info"`
+	Servers    []APIServer         `json:"servers,omitempty"`
 	Paths      map[string]PathItem `json:"paths"`
 	Components Components          `json:"components"`
+	Tags       []Tag               `json:"tags,omitempty"`
+}
+
+// Tag groups the operations on a resource in documentation portals
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
 }
 
 type Info struct {
-	Title   string `json:"title"`
-	Version string `json:"version"`
+	Title       string   `json:"title"`
+	Version     string   `json:"version"`
+	Description string   `json:"description,omitempty"`
+	Contact     *Contact `json:"contact,omitempty"`
+	License     *License `json:"license,omitempty"`
+}
+
+type Contact struct {
+	Email string `json:"email,omitempty"`
+}
+
+type License struct {
+	Name string `json:"name"`
+}
+
+// APIServer is an entry of the servers of the API, not to be confused with the
+// analyzer's own Server
+type APIServer struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
 }
 
 type PathItem struct {
@@ -27,9 +59,12 @@ type PathItem struct {
 
 type Operation struct {
 	Summary     string              `json:"summary"`
+	OperationID string              `json:"operationId,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
 	Parameters  []Parameter         `json:"parameters,omitempty"`
 	RequestBody *RequestBody        `json:"requestBody,omitempty"`
 	Responses   map[string]Response `json:"responses"`
+	Deprecated  bool                `json:"deprecated,omitempty"`
 }
 
 type Parameter struct {
@@ -46,57 +81,109 @@ type RequestBody struct {
 }
 
 type Response struct {
-	Description string               `json:"description"`
-	Content     map[string]MediaType `json:"content,omitempty"`
-	Headers     map[string]Header    `json:"headers,omitempty"`
+	Description     string               `json:"description"`
+	Content         map[string]MediaType `json:"content,omitempty"`
+	Headers         map[string]Header    `json:"headers,omitempty"`
+	OccurrenceCount int64                `json:"x-occurrence-count,omitempty"` // Number of responses with the status analyzed
 }
 
+// MediaType describes a body of one media type with a complete example built
+// from the first example of each field, so tools like Swagger UI show a
+// coherent payload instead of scattered field values
 type MediaType struct {
-	Schema Schema `json:"schema"`
+	Schema  Schema      `json:"schema"`
+	Example interface{} `json:"example,omitempty"`
 }
 
 type Header struct {
-	Schema Schema `json:"schema"`
+	Description string `json:"description,omitempty"`
+	Schema      Schema `json:"schema"`
+}
+
+// cachingHeaderDescriptions describes the response headers that control HTTP
+// caching, keyed by canonical name, so clients know how responses may be reused
+var cachingHeaderDescriptions = map[string]string{
+	"Age":           "Time in seconds the response has been in a proxy cache",
+	"Cache-Control": "Caching directives of the response, e.g. max-age or no-store",
+	"Etag":          "Version of the resource, sent back in If-None-Match to revalidate the response",
+	"Expires":       "Date after which the response is considered stale",
+	"Last-Modified": "Date the resource was last modified, sent back in If-Modified-Since to revalidate the response",
+	"Vary":          "Request headers that select between cached responses",
+}
+
+// responseHeaderDescription describes a response header of the given status
+// if it is a well-known one
+func responseHeaderDescription(header string, status int) string {
+	if header == "Location" {
+		switch {
+		case status == http.StatusCreated:
+			return "URL of the created resource"
+		case status >= 300 && status < 400:
+			return "URL the request is redirected to"
+		}
+	}
+	return cachingHeaderDescriptions[header]
+}
+
+// isBodylessStatus reports whether responses of a status usually have no body,
+// such as 204 No Content and redirects
+func isBodylessStatus(status int) bool {
+	return status == http.StatusNoContent || (status >= 300 && status < 400)
 }
 
 type Schema struct {
+	Ref         string            `json:"$ref,omitempty"`
 	Type        string            `json:"type,omitempty"`
 	Format      string            `json:"format,omitempty"`
+	Pattern     string            `json:"pattern,omitempty"`
 	Properties  map[string]Schema `json:"properties,omitempty"`
 	Items       *Schema           `json:"items,omitempty"`
+	OneOf       []Schema          `json:"oneOf,omitempty"`
 	Required    []string          `json:"required,omitempty"`
 	Description string            `json:"description,omitempty"`
 	Example     interface{}       `json:"example,omitempty"`
 	Examples    []interface{}     `json:"examples,omitempty"`
-	Enum        []string          `json:"enum,omitempty"`
+	Enum        []interface{}     `json:"enum,omitempty"`
+	Nullable    bool              `json:"nullable,omitempty"`
 }
 
 type Components struct {
 	Schemas map[string]Schema `json:"schemas"`
 }
 
-// GenerateOpenAPI generates OpenAPI specification from analyzer data
+// GenerateOpenAPI generates OpenAPI specification from analyzer data, with
+// repeated object schemas moved to the components if enabled
 func (a *Analyzer) GenerateOpenAPI() *OpenAPI {
-	a.mu.RLock()
-	defer a.mu.RUnlock()
+	openAPI := a.generateOpenAPI()
+	if a.getUseRefs() {
+		openAPI.extractComponents(a.getEnumThreshold())
+	}
+	return openAPI
+}
+
+// generateOpenAPI generates OpenAPI specification from analyzer data with all
+// schemas inline, for the formats derived from it
+func (a *Analyzer) generateOpenAPI() *OpenAPI {
+	endpoints := a.GetData()
+	enumThreshold := a.getEnumThreshold()
 
 	openAPI := &OpenAPI{
-		OpenAPI: "3.0.0",
-		Info: Info{
-			Title:   "API Documentation",
-			Version: "1.0.0",
-		},
+		OpenAPI:    "3.0.0",
+		Info:       a.getInfo(),
+		Servers:    a.getServers(),
 		Paths:      make(map[string]PathItem),
 		Components: Components{Schemas: make(map[string]Schema)},
 	}
+	tags := make(map[string]bool)
 
-	for key, endpoint := range a.endpoints {
+	for key, endpoint := range endpoints {
 		// Split method and path
 		parts := strings.SplitN(key, " ", 2)
 		if len(parts) != 2 {
 			continue
 		}
-		method, path := parts[0], parts[1]
+		method, normalizedPath := parts[0], parts[1]
+		path := templatePath(normalizedPath)
 
 		// Create or get path item
 		pathItem, exists := openAPI.Paths[path]
@@ -106,32 +193,48 @@ func (a *Analyzer) GenerateOpenAPI() *OpenAPI {
 
 		// Create operation
 		operation := &Operation{
-			Summary:   fmt.Sprintf("%s %s", method, path),
-			Responses: make(map[string]Response),
+			Summary:    fmt.Sprintf("%s %s", method, path),
+			Responses:  make(map[string]Response),
+			Deprecated: a.isDeprecated(endpoint),
+		}
+		if tag := resourceTag(path); tag != "" {
+			operation.Tags = []string{tag}
+			tags[tag] = true
 		}
 
-		// Add path parameters
-		segments := strings.Split(path, "/")
+		// Add path parameters with the values seen for them, named like the
+		// segments of the template path so repeated ones are numbered
+		paramKeys := pathParamKeys(normalizedPath)
+		nextPathParam := func() (string, []interface{}) {
+			key := paramKeys[0]
+			paramKeys = paramKeys[1:]
+			if endpoint.PathParams == nil {
+				return key, nil
+			}
+			return key, endpoint.PathParams.Examples[key]
+		}
+		segments := strings.Split(normalizedPath, "/")
 		for _, segment := range segments {
 			if segment == "{id}" {
+				name, examples := nextPathParam()
 				operation.Parameters = append(operation.Parameters, Parameter{
-					Name:        "id",
+					Name:        name,
 					In:          "path",
 					Required:    true,
 					Description: "Resource ID",
-					Schema: Schema{
-						Type: "integer",
-					},
+					Schema:      numericIDSchema(examples),
 				})
 			} else if segment == "{uuid}" {
+				name, examples := nextPathParam()
 				operation.Parameters = append(operation.Parameters, Parameter{
-					Name:        "uuid",
+					Name:        name,
 					In:          "path",
 					Required:    true,
 					Description: "Resource UUID",
 					Schema: Schema{
-						Type:   "string",
-						Format: "uuid",
+						Type:     "string",
+						Format:   "uuid",
+						Examples: sortedExamples(examples),
 					},
 				})
 			}
@@ -139,66 +242,27 @@ func (a *Analyzer) GenerateOpenAPI() *OpenAPI {
 
 		// Add URL parameters if they exist
 		if endpoint.URLParameters != nil {
-			for param, store := range endpoint.URLParameters.Examples {
-				// Skip common parameters that are handled separately
-				if param == "page" || param == "page_size" || param == "sort_by" || param == "order" || param == "search" {
-					continue
+			for _, name := range sortedKeys(endpoint.URLParameters.Examples) {
+				store := endpoint.URLParameters.Examples[name]
+				param := Parameter{
+					Name:     name,
+					In:       "query",
+					Required: !endpoint.URLParameters.Optional[name],
+					Schema:   Schema{Examples: sortedExamples(store)},
 				}
-
-				// Determine parameter type based on examples
-				paramType := "string"
-				if len(store) > 0 {
-					switch store[0].(type) {
-					case bool:
-						paramType = "boolean"
-					case float64:
-						paramType = "number"
-					case int:
-						paramType = "integer"
-					}
+				if common, isCommon := commonQueryParams[strings.ToLower(name)]; isCommon {
+					param.Description = common.description
+					param.Schema.Type = common.schemaType
+				} else {
+					param.Description = fmt.Sprintf("Query parameter: %s", name)
+					param.Schema.Type = queryParamType(store)
 				}
-
-				// Create parameter
-				param := Parameter{
-					Name:        param,
-					In:          "query",
-					Required:    !endpoint.URLParameters.Optional[param],
-					Description: fmt.Sprintf("Query parameter: %s", param),
-					Schema: Schema{
-						Type:     paramType,
-						Examples: store,
-					},
+				// Repeated parameters, e.g. ?tag=a&tag=b, are arrays of their values
+				if endpoint.URLParameters.Repeated[name] {
+					param.Schema = Schema{Type: "array", Items: &Schema{Type: param.Schema.Type, Examples: sortedExamples(store)}}
 				}
 				operation.Parameters = append(operation.Parameters, param)
 			}
-
-			// Add common query parameters
-			commonParams := []struct {
-				name        string
-				description string
-				type_       string
-			}{
-				{"page", "Page number for pagination", "integer"},
-				{"page_size", "Number of items per page", "integer"},
-				{"sort_by", "Field to sort by", "string"},
-				{"order", "Sort order (asc/desc)", "string"},
-				{"search", "Search query", "string"},
-			}
-
-			for _, cp := range commonParams {
-				if store, exists := endpoint.URLParameters.Examples[cp.name]; exists {
-					operation.Parameters = append(operation.Parameters, Parameter{
-						Name:        cp.name,
-						In:          "query",
-						Required:    !endpoint.URLParameters.Optional[cp.name],
-						Description: cp.description,
-						Schema: Schema{
-							Type:     cp.type_,
-							Examples: store,
-						},
-					})
-				}
-			}
 		}
 
 		// Add request parameters from headers
@@ -211,53 +275,110 @@ func (a *Analyzer) GenerateOpenAPI() *OpenAPI {
 					Description: fmt.Sprintf("Header: %s", header),
 					Schema: Schema{
 						Type:     "string",
-						Examples: store,
+						Examples: sortedExamples(store),
 					},
 				}
 				operation.Parameters = append(operation.Parameters, param)
 			}
 		}
 
+		// Add request cookies
+		if endpoint.Cookies != nil {
+			for cookie, store := range endpoint.Cookies.Examples {
+				operation.Parameters = append(operation.Parameters, Parameter{
+					Name:        cookie,
+					In:          "cookie",
+					Required:    !endpoint.Cookies.Optional[cookie],
+					Description: fmt.Sprintf("Cookie: %s", cookie),
+					Schema: Schema{
+						Type:     "string",
+						Examples: sortedExamples(store),
+					},
+				})
+			}
+		}
+
 		// Add request body schema if exists
 		if endpoint.RequestPayload != nil && len(endpoint.RequestPayload.Examples) > 0 {
 			requestBody := &RequestBody{
 				Required: true,
 				Content: map[string]MediaType{
-					"application/json": {
-						Schema: generateSchemaFromStore(endpoint.RequestPayload),
+					mediaTypeOrDefault(endpoint.RequestContentType): {
+						Schema:  generateSchemaFromStore(endpoint.RequestPayload, enumThreshold),
+						Example: createExampleFromStore(endpoint.RequestPayload),
 					},
 				},
 			}
 			operation.RequestBody = requestBody
 		}
 
+		// Add request bodies of other media types, e.g. form data, each with its own schema
+		for _, mediaType := range sortedKeys(endpoint.RequestBodies) {
+			store := endpoint.RequestBodies[mediaType]
+			if len(store.Examples) == 0 {
+				continue
+			}
+			if operation.RequestBody == nil {
+				operation.RequestBody = &RequestBody{Required: true, Content: make(map[string]MediaType)}
+			}
+			operation.RequestBody.Content[mediaType] = MediaType{
+				Schema:  generateSchemaFromStore(store, enumThreshold),
+				Example: createExampleFromStore(store),
+			}
+		}
+
 		// Add responses
 		for status, responseData := range endpoint.ResponseStatuses {
 			response := Response{
-				Description: fmt.Sprintf("Status %d", status),
-				Content: map[string]MediaType{
-					"application/json": {
-						Schema: generateSchemaFromStore(responseData.Payload),
+				Description:     a.responseDescription(method, normalizedPath, status),
+				Headers:         make(map[string]Header),
+				OccurrenceCount: responseData.Count,
+			}
+			// Redirects and 204 responses without a JSON body have no content
+			if !isBodylessStatus(status) || (responseData.Payload != nil && len(responseData.Payload.Examples) > 0) {
+				response.Content = map[string]MediaType{
+					mediaTypeOrDefault(responseData.ContentType): {
+						Schema:  generateSchemaFromStore(responseData.Payload, enumThreshold),
+						Example: createExampleFromStore(responseData.Payload),
 					},
-				},
-				Headers: make(map[string]Header),
+				}
 			}
 
 			// Add response headers
 			if responseData.Headers != nil {
 				for header, store := range responseData.Headers.Examples {
 					response.Headers[header] = Header{
+						Description: responseHeaderDescription(header, status),
 						Schema: Schema{
 							Type:     "string",
-							Examples: store,
+							Examples: sortedExamples(store),
 						},
 					}
 				}
 			}
 
+			// Add cookies set by the response
+			if responseData.SetCookies != nil && len(responseData.SetCookies.Examples) > 0 {
+				var examples []interface{}
+				for _, cookie := range sortedKeys(responseData.SetCookies.Examples) {
+					for _, value := range sortedExamples(responseData.SetCookies.Examples[cookie]) {
+						examples = append(examples, fmt.Sprintf("%s=%v", cookie, value))
+					}
+				}
+				response.Headers["Set-Cookie"] = Header{
+					Schema: Schema{
+						Type:     "string",
+						Examples: examples,
+					},
+				}
+			}
+
 			operation.Responses[fmt.Sprintf("%d", status)] = response
 		}
 
+		operation.Parameters = dedupeParameters(operation.Parameters)
+		sortParameters(operation.Parameters)
+
 		// Add operation to path item
 		switch method {
 		case "GET":
@@ -273,16 +394,253 @@ func (a *Analyzer) GenerateOpenAPI() *OpenAPI {
 		openAPI.Paths[path] = pathItem
 	}
 
+	for _, tag := range sortedKeys(tags) {
+		openAPI.Tags = append(openAPI.Tags, Tag{Name: tag, Description: fmt.Sprintf("Endpoints for %s", tag)})
+	}
+	openAPI.assignOperationIDs()
+
 	return openAPI
 }
 
+// assignOperationIDs sets the operationId of every operation, visiting them in
+// a fixed order so that a counter appended to colliding IDs is stable
+func (o *OpenAPI) assignOperationIDs() {
+	used := make(map[string]bool)
+	for _, path := range sortedKeys(o.Paths) {
+		pathItem := o.Paths[path]
+		for _, operation := range []struct {
+			method    string
+			operation *Operation
+		}{{"GET", pathItem.Get}, {"POST", pathItem.Post}, {"PUT", pathItem.Put}, {"DELETE", pathItem.Delete}} {
+			if operation.operation == nil {
+				continue
+			}
+			base := operationID(operation.method, path)
+			id := base
+			for i := 2; used[id]; i++ {
+				id = base + strconv.Itoa(i)
+			}
+			used[id] = true
+			operation.operation.OperationID = id
+		}
+	}
+}
+
+// operationID builds the operationId of an operation from its method and path,
+// e.g. getUsersByIdOrders for GET /users/{id}/orders
+func operationID(method, path string) string {
+	var id strings.Builder
+	id.WriteString(strings.ToLower(method))
+	for _, segment := range strings.Split(path, "/") {
+		if param, ok := strings.CutPrefix(segment, "{"); ok {
+			id.WriteString("By")
+			segment = strings.TrimSuffix(param, "}")
+		}
+		id.WriteString(pascalCase(segment))
+	}
+	return id.String()
+}
+
+// resourceTag returns the tag of the operations on a path: its first segment
+// that is not an API prefix such as api or v1, or a parameter
+func resourceTag(path string) string {
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" || segment == "api" || strings.HasPrefix(segment, "{") || isVersionSegment(segment) {
+			continue
+		}
+		return segment
+	}
+	return ""
+}
+
+// isVersionSegment reports whether a path segment is an API version, e.g. v1
+func isVersionSegment(segment string) bool {
+	if len(segment) < 2 || segment[0] != 'v' {
+		return false
+	}
+	for _, r := range segment[1:] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// numericIDSchema returns the schema of a numeric ID path parameter: an integer,
+// or a string of digits if IDs too long for an integer or zero-padded were seen
+func numericIDSchema(examples []interface{}) Schema {
+	for _, example := range examples {
+		if _, ok := example.(string); ok {
+			return Schema{Type: "string", Pattern: "^[0-9]+$", Examples: sortedExamples(examples)}
+		}
+	}
+	return Schema{Type: "integer", Examples: sortedExamples(examples)}
+}
+
+// templatePath numbers the repeated parameters of a normalized path, e.g.
+// /users/{id}/orders/{id} becomes /users/{id}/orders/{id2}, since parameter
+// names must be unique within an OpenAPI path template
+func templatePath(path string) string {
+	keys := pathParamKeys(path)
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			segments[i] = "{" + keys[0] + "}"
+			keys = keys[1:]
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// dedupeParameters keeps the first parameter of each name and location, as an
+// operation can't define the same parameter twice
+func dedupeParameters(params []Parameter) []Parameter {
+	seen := make(map[string]bool, len(params))
+	unique := params[:0]
+	for _, param := range params {
+		key := param.In + " " + param.Name
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, param)
+	}
+	return unique
+}
+
+// parameterLocationOrder orders parameters by location before sorting them by name
+var parameterLocationOrder = map[string]int{
+	"path":   0,
+	"query":  1,
+	"header": 2,
+	"cookie": 3,
+}
+
+// commonQueryParams describes the pagination, sorting and search query
+// parameters used by most APIs, keyed by lower case name so they are
+// recognized whatever their case, e.g. Page
+var commonQueryParams = map[string]struct {
+	description string
+	schemaType  string
+}{
+	"page":      {"Page number for pagination", "integer"},
+	"page_size": {"Number of items per page", "integer"},
+	"sort_by":   {"Field to sort by", "string"},
+	"order":     {"Sort order (asc/desc)", "string"},
+	"search":    {"Search query", "string"},
+}
+
+// queryParamType determines the type of a custom query parameter from all its
+// examples: integer or number if they are all numbers, boolean if they are all
+// booleans and string otherwise. Examples recorded as strings by older
+// versions are inferred again.
+func queryParamType(examples []interface{}) string {
+	common := ""
+	for _, example := range examples {
+		if str, ok := example.(string); ok {
+			example = queryValue(str)
+		}
+		valueType := jsonType(example)
+		if valueType == "number" {
+			if number, _ := toFloat64(example); number == math.Trunc(number) {
+				valueType = "integer"
+			}
+		}
+		switch {
+		case common == "" || common == valueType:
+			common = valueType
+		case common == "integer" && valueType == "number", common == "number" && valueType == "integer":
+			common = "number"
+		default:
+			return "string"
+		}
+	}
+	if common == "" {
+		return "string"
+	}
+	return common
+}
+
+// queryValue returns a query parameter value as a boolean or number if it is
+// written as one, or else as is. Numbers must be written the way they are
+// formatted back, so values such as 007, 1.50 or large IDs stay strings, and
+// finite as JSON has no NaN or infinity.
+func queryValue(value string) interface{} {
+	switch value {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err == nil && !math.IsNaN(number) && !math.IsInf(number, 0) && strconv.FormatFloat(number, 'f', -1, 64) == value {
+		return number
+	}
+	return value
+}
+
+// exampleString returns the text form of a header or query parameter example,
+// writing numbers without exponents as in requests
+func exampleString(example interface{}) string {
+	if number, ok := example.(float64); ok {
+		return strconv.FormatFloat(number, 'f', -1, 64)
+	}
+	return fmt.Sprint(example)
+}
+
+// sortParameters sorts parameters by location and name so the generated
+// specification is the same for identical data
+func sortParameters(params []Parameter) {
+	sort.SliceStable(params, func(i, j int) bool {
+		if params[i].In != params[j].In {
+			return parameterLocationOrder[params[i].In] < parameterLocationOrder[params[j].In]
+		}
+		return params[i].Name < params[j].Name
+	})
+}
+
+// sortedKeys returns the keys of a map, such as a schema store examples map, in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// mediaTypeOrDefault returns the observed media type, falling back to application/json
+func mediaTypeOrDefault(mediaType string) string {
+	if mediaType == "" {
+		return "application/json"
+	}
+	return mediaType
+}
+
 // generateSchemaFromStore generates OpenAPI schema from SchemaStore
-func generateSchemaFromStore(store *SchemaStore) Schema {
-	if store == nil || len(store.Examples) == 0 {
+func generateSchemaFromStore(store *SchemaStore, enumThreshold int) Schema {
+	if store == nil || (len(store.Examples) == 0 && len(store.Truncated) == 0) {
 		return Schema{Type: "object"}
 	}
+	store = withTruncationMarkers(store)
+
+	// Bodies of different shapes, e.g. a list or an error object, are
+	// documented as alternatives instead of being merged into one schema
+	if shapes := splitRootShapes(store); len(shapes) > 1 {
+		var schema Schema
+		for _, shape := range shapes {
+			schema.OneOf = append(schema.OneOf, generateSchemaFromStore(shape, enumThreshold))
+		}
+		return schema
+	}
+
+	// A root-level scalar payload such as "ok", 42 or true
+	if examples, exists := store.Examples[rootPath]; exists && len(store.Examples) == 1 {
+		return createPropertySchema(examples, store.Nullable[rootPath], enumThreshold)
+	}
 
-	// Collect all top-level keys' prefixes
+	// Collect all top-level keys' prefixes. Elements of a root array have
+	// paths starting with [], unlike array fields of a root object such as tags[].
 	var (
 		arrayKey string
 		allArray = true
@@ -291,7 +649,7 @@ func generateSchemaFromStore(store *SchemaStore) Schema {
 	for path := range store.Examples {
 		parts := strings.Split(path, ".")
 		if len(parts) > 0 {
-			if strings.HasSuffix(parts[0], "[]") {
+			if strings.HasPrefix(parts[0], "[]") {
 				if first {
 					arrayKey = parts[0]
 					first = false
@@ -311,6 +669,7 @@ func generateSchemaFromStore(store *SchemaStore) Schema {
 		itemStore := &SchemaStore{
 			Examples: make(map[string][]interface{}),
 			Optional: make(map[string]bool),
+			Nullable: make(map[string]bool),
 		}
 		for path, examples := range store.Examples {
 			parts := strings.Split(path, ".")
@@ -321,10 +680,19 @@ func generateSchemaFromStore(store *SchemaStore) Schema {
 					if optional, exists := store.Optional[path]; exists {
 						itemStore.Optional[newPath] = optional
 					}
+					itemStore.Nullable[newPath] = store.Nullable[path]
 				}
 			}
 		}
-		itemSchema := buildObjectSchemaFromStore(itemStore)
+		// A root array of primitives such as ["a", "b"]
+		if examples, exists := store.Examples[arrayKey]; exists && len(itemStore.Examples) == 0 {
+			itemSchema := createPropertySchema(examples, store.Nullable[arrayKey], enumThreshold)
+			return Schema{
+				Type:  "array",
+				Items: &itemSchema,
+			}
+		}
+		itemSchema := buildObjectSchemaFromStore(itemStore, enumThreshold)
 		if itemSchema.Type == "" {
 			itemSchema.Type = "object"
 		}
@@ -339,16 +707,118 @@ func generateSchemaFromStore(store *SchemaStore) Schema {
 	}
 
 	// Otherwise, build as an object
-	return buildObjectSchemaFromStore(store)
+	return buildObjectSchemaFromStore(store, enumThreshold)
+}
+
+// withTruncationMarkers returns the store with a _truncated marker property in
+// each object whose content was cut off by a processing limit, e.g.
+// a.c._truncated, so the schema shows that it is incomplete. Arrays of scalars
+// cut to their first elements are marked in the object containing them, and
+// are not marked at the root of a body, which has no such object. A payload
+// field with the name of a marker is documented as it was observed instead.
+func withTruncationMarkers(store *SchemaStore) *SchemaStore {
+	if len(store.Truncated) == 0 {
+		return store
+	}
+	marked := &SchemaStore{
+		Examples: make(map[string][]interface{}, len(store.Examples)+len(store.Truncated)),
+		Optional: make(map[string]bool, len(store.Optional)+len(store.Truncated)),
+		Nullable: store.Nullable,
+	}
+	maps.Copy(marked.Examples, store.Examples)
+	maps.Copy(marked.Optional, store.Optional)
+	for path := range store.Truncated {
+		if _, scalars := store.Examples[path]; scalars && strings.HasSuffix(path, "[]") {
+			if path == "[]" {
+				continue
+			}
+			path = strings.TrimSuffix(path, "[]")
+			if i := strings.LastIndex(path, "."); i >= 0 {
+				path = path[:i]
+			} else {
+				path = ""
+			}
+		}
+		marker := truncatedKey
+		if path != "" {
+			marker = path + "." + truncatedKey
+		}
+		if _, exists := marked.Examples[marker]; !exists {
+			marked.Examples[marker] = []interface{}{true}
+			marked.Optional[marker] = true
+		}
+	}
+	return marked
 }
 
-// createPropertySchema creates a schema for a property based on its examples
-func createPropertySchema(examples []interface{}) Schema {
-	propertySchema := Schema{}
+// splitRootShapes splits a store whose bodies had different shapes at the
+// root, e.g. a list on success and an error object otherwise, into one store
+// per shape: objects, arrays and scalars, in that order. A store of a single
+// shape is returned as is.
+func splitRootShapes(store *SchemaStore) []*SchemaStore {
+	const (
+		objectShape = iota
+		arrayShape
+		scalarShape
+	)
+	shapeOf := func(path string) int {
+		switch {
+		case path == rootPath:
+			return scalarShape
+		case strings.HasPrefix(path, "[]"):
+			return arrayShape
+		}
+		return objectShape
+	}
+
+	var found [3]bool
+	count := 0
+	for path := range store.Examples {
+		if shape := shapeOf(path); !found[shape] {
+			found[shape] = true
+			count++
+		}
+	}
+	if count < 2 {
+		return []*SchemaStore{store}
+	}
+
+	var shapes []*SchemaStore
+	for shape := range found {
+		if !found[shape] {
+			continue
+		}
+		shapeStore := &SchemaStore{
+			Examples: make(map[string][]interface{}),
+			Optional: make(map[string]bool),
+			Nullable: make(map[string]bool),
+		}
+		for path, examples := range store.Examples {
+			if shapeOf(path) == shape {
+				shapeStore.Examples[path] = examples
+				shapeStore.Optional[path] = store.Optional[path]
+				shapeStore.Nullable[path] = store.Nullable[path]
+			}
+		}
+		shapes = append(shapes, shapeStore)
+	}
+	return shapes
+}
+
+// createPropertySchema creates a schema for a property based on its examples.
+// The type is taken from the non-null examples; a property whose examples have
+// different types, or that was only ever null, has no type and accepts any value.
+// String and number properties with at most enumThreshold distinct values are
+// documented as enums, typed integer when all values are whole. Booleans get no
+// enum: it would either restate the type or, with a single observed value,
+// reject the other one.
+func createPropertySchema(examples []interface{}, nullable bool, enumThreshold int) Schema {
+	examples = sortedExamples(examples)
+	propertySchema := Schema{Nullable: nullable}
 	if len(examples) > 0 {
-		switch examples[0].(type) {
-		case string:
-			propertySchema.Type = "string"
+		propertySchema.Type = commonJSONType(examples)
+		switch propertySchema.Type {
+		case "string":
 			// Check if we have a limited set of unique string values
 			uniqueValues := make(map[string]bool)
 			for _, ex := range examples {
@@ -356,31 +826,197 @@ func createPropertySchema(examples []interface{}) Schema {
 					uniqueValues[str] = true
 				}
 			}
-			// If we have less than 5 unique values, add them as enum
-			if len(uniqueValues) > 0 && len(uniqueValues) < 5 {
-				enumValues := make([]string, 0, len(uniqueValues))
+			if len(uniqueValues) > 0 && len(uniqueValues) <= enumThreshold {
+				strValues := make([]string, 0, len(uniqueValues))
+				for val := range uniqueValues {
+					strValues = append(strValues, val)
+				}
+				sort.Strings(strValues)
+				enumValues := make([]interface{}, len(strValues))
+				for i, val := range strValues {
+					enumValues[i] = val
+				}
+				propertySchema.Enum = enumValues
+			}
+		case "number":
+			// Small sets of numbers such as ratings or status codes
+			uniqueValues := make(map[float64]bool)
+			for _, ex := range examples {
+				if num, ok := toFloat64(ex); ok {
+					uniqueValues[num] = true
+				}
+			}
+			if len(uniqueValues) > 0 && len(uniqueValues) <= enumThreshold {
+				numValues := make([]float64, 0, len(uniqueValues))
 				for val := range uniqueValues {
-					enumValues = append(enumValues, val)
+					numValues = append(numValues, val)
+				}
+				sort.Float64s(numValues)
+				enumValues := make([]interface{}, len(numValues))
+				whole := true
+				for i, val := range numValues {
+					enumValues[i] = val
+					whole = whole && val == math.Trunc(val)
 				}
 				propertySchema.Enum = enumValues
+				if whole {
+					propertySchema.Type = "integer"
+				}
 			}
-		case float64:
-			propertySchema.Type = "number"
-		case bool:
-			propertySchema.Type = "boolean"
-		case []interface{}:
-			propertySchema.Type = "array"
-			propertySchema.Items = &Schema{Type: "object"}
-		case map[string]interface{}:
-			propertySchema.Type = "object"
+		case "array":
+			propertySchema.Items = arrayItemsSchema(examples)
 		}
 		propertySchema.Examples = examples
+		// Tools reading only the singular example, such as Swagger 2.0 importers,
+		// get the first non-null one
+		for _, example := range examples {
+			if example != nil {
+				propertySchema.Example = example
+				break
+			}
+		}
 	}
 	return propertySchema
 }
 
+// arrayItemsSchema creates the items schema of arrays from the elements of all
+// array examples. Items of arrays mixing element types, or that were always
+// empty, have no type.
+func arrayItemsSchema(arrays []interface{}) *Schema {
+	var elements []interface{}
+	items := &Schema{}
+	for _, array := range arrays {
+		values, _ := array.([]interface{})
+		for _, value := range values {
+			if value == nil {
+				items.Nullable = true
+			}
+			elements = append(elements, value)
+		}
+	}
+
+	items.Type = commonJSONType(elements)
+	if items.Type == "array" {
+		items.Items = arrayItemsSchema(elements)
+	}
+	return items
+}
+
+// commonJSONType returns the JSON schema type shared by all non-null values,
+// or "" if there are none or their types differ
+func commonJSONType(values []interface{}) string {
+	common := ""
+	for _, value := range values {
+		if value == nil {
+			continue
+		}
+		valueType := jsonType(value)
+		if common != "" && valueType != common {
+			return ""
+		}
+		common = valueType
+	}
+	return common
+}
+
+// toFloat64 converts a numeric example to float64
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// sortedExamples returns a copy of examples in an order that doesn't depend on
+// the order they were observed in, so concurrent requests can't change the
+// generated documents: booleans, numbers and strings in their natural order,
+// then arrays and objects by their JSON encoding, then null
+func sortedExamples(examples []interface{}) []interface{} {
+	sorted := append([]interface{}(nil), examples...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return lessExample(sorted[i], sorted[j])
+	})
+	return sorted
+}
+
+// lessExample reports whether example a sorts before example b
+func lessExample(a, b interface{}) bool {
+	rankA, rankB := exampleRank(a), exampleRank(b)
+	if rankA != rankB {
+		return rankA < rankB
+	}
+	switch rankA {
+	case 0:
+		return !a.(bool) && b.(bool)
+	case 1:
+		x, _ := toFloat64(a)
+		y, _ := toFloat64(b)
+		return x < y
+	case 2:
+		return a.(string) < b.(string)
+	case 3:
+		x, _ := json.Marshal(a)
+		y, _ := json.Marshal(b)
+		return string(x) < string(y)
+	}
+	return false
+}
+
+// exampleRank returns the position of the type of an example in the order of
+// sortedExamples
+func exampleRank(example interface{}) int {
+	if _, isNumber := toFloat64(example); isNumber {
+		return 1
+	}
+	switch example.(type) {
+	case bool:
+		return 0
+	case string:
+		return 2
+	case nil:
+		return 4
+	}
+	return 3
+}
+
+// jsonType returns the JSON schema type of a decoded JSON value
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case float64, int, int64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return ""
+}
+
+// isUnknownSchema checks if a property schema has no type because no value
+// except placeholders for empty arrays was observed
+func isUnknownSchema(schema Schema) bool {
+	if schema.Type != "" || schema.Nullable {
+		return false
+	}
+	for _, example := range schema.Examples {
+		if example != nil {
+			return false
+		}
+	}
+	return true
+}
+
 // buildObjectSchemaFromStore builds an object schema from a SchemaStore
-func buildObjectSchemaFromStore(store *SchemaStore) Schema {
+func buildObjectSchemaFromStore(store *SchemaStore, enumThreshold int) Schema {
 	type node struct {
 		children map[string]*node
 		leaf     bool
@@ -391,6 +1027,9 @@ func buildObjectSchemaFromStore(store *SchemaStore) Schema {
 
 	// Build the tree
 	for path := range store.Examples {
+		if path == rootPath {
+			continue
+		}
 		parts := strings.Split(path, ".")
 		cur := root
 		for i, part := range parts {
@@ -405,11 +1044,26 @@ func buildObjectSchemaFromStore(store *SchemaStore) Schema {
 		}
 	}
 
+	// A field holding an object or array is present in every body if one of
+	// the fields nested in it is
+	var hasRequiredLeaf func(n *node) bool
+	hasRequiredLeaf = func(n *node) bool {
+		if optional, exists := store.Optional[n.path]; n.leaf && exists && !optional {
+			return true
+		}
+		for _, child := range n.children {
+			if hasRequiredLeaf(child) {
+				return true
+			}
+		}
+		return false
+	}
+
 	var build func(n *node, isRoot bool) Schema
 	build = func(n *node, isRoot bool) Schema {
 		if n.leaf {
 			examples := store.Examples[n.path]
-			return createPropertySchema(examples)
+			return createPropertySchema(examples, store.Nullable[n.path], enumThreshold)
 		}
 
 		// Only check for all-arrays if not at root
@@ -429,7 +1083,7 @@ func buildObjectSchemaFromStore(store *SchemaStore) Schema {
 				for k, child := range n.children {
 					name := strings.TrimSuffix(k, "[]")
 					childSchema := build(child, false)
-					if childSchema.Type == "" {
+					if isUnknownSchema(childSchema) {
 						childSchema.Type = "object"
 					}
 					objSchema.Properties[name] = Schema{
@@ -452,7 +1106,7 @@ func buildObjectSchemaFromStore(store *SchemaStore) Schema {
 			if strings.HasSuffix(name, "[]") {
 				name = strings.TrimSuffix(name, "[]")
 				childSchema := build(child, false)
-				if childSchema.Type == "" {
+				if isUnknownSchema(childSchema) {
 					childSchema.Type = "object"
 				}
 				objSchema.Properties[name] = Schema{
@@ -461,7 +1115,7 @@ func buildObjectSchemaFromStore(store *SchemaStore) Schema {
 				}
 			} else {
 				childSchema := build(child, false)
-				if childSchema.Type == "" {
+				if isUnknownSchema(childSchema) {
 					childSchema.Type = "object"
 				}
 				objSchema.Properties[name] = childSchema
@@ -484,10 +1138,11 @@ func buildObjectSchemaFromStore(store *SchemaStore) Schema {
 					fullPath = strings.Join(pathParts, ".")
 				}
 			}
-			if fullPath != "" && !store.Optional[fullPath] {
+			if fullPath != "" && !store.Optional[fullPath] || fullPath == "" && hasRequiredLeaf(child) {
 				objSchema.Required = append(objSchema.Required, name)
 			}
 		}
+		sort.Strings(objSchema.Required)
 		return objSchema
 	}
 
@@ -1,15 +1,22 @@
 package analyzer
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGenerateOpenAPI(t *testing.T) {
 	// Create a test analyzer with sample data
 	a := &Analyzer{
-		endpoints: map[string]*EndpointData{
+		endpoints: newEndpointMap(map[string]*EndpointData{
 			"GET /users": {
 				URLParameters: &SchemaStore{
 					Examples: map[string][]interface{}{
@@ -140,7 +147,7 @@ func TestGenerateOpenAPI(t *testing.T) {
 					},
 				},
 			},
-		},
+		}),
 	}
 
 	// Generate OpenAPI spec
@@ -176,7 +183,7 @@ func TestGenerateOpenAPI(t *testing.T) {
 	// Test response schema
 	response200 := getOp.Responses["200"]
 	assert.NotNil(t, response200)
-	assert.Equal(t, "Status 200", response200.Description)
+	assert.Equal(t, "OK", response200.Description)
 
 	// Test POST /users endpoint
 	postUsersPath, exists := openAPI.Paths["/users"]
@@ -265,16 +272,16 @@ func TestGenerateSchemaFromStore(t *testing.T) {
 	// Test array schema
 	arrayStore := &SchemaStore{
 		Examples: map[string][]interface{}{
-			"items[].id":   {1, 2},
-			"items[].name": {"John", "Jane"},
+			"[].id":   {1, 2},
+			"[].name": {"John", "Jane"},
 		},
 		Optional: map[string]bool{
-			"items[].id":   false,
-			"items[].name": false,
+			"[].id":   false,
+			"[].name": false,
 		},
 	}
 
-	arraySchema := generateSchemaFromStore(arrayStore)
+	arraySchema := generateSchemaFromStore(arrayStore, defaultEnumThreshold)
 	assert.Equal(t, "array", arraySchema.Type)
 	assert.NotNil(t, arraySchema.Items)
 	assert.Equal(t, "object", arraySchema.Items.Type)
@@ -282,6 +289,22 @@ func TestGenerateSchemaFromStore(t *testing.T) {
 	assert.Contains(t, arraySchema.Items.Properties, "id")
 	assert.Contains(t, arraySchema.Items.Properties, "name")
 
+	// An object whose only field is an array is not a root array
+	arrayFieldStore := &SchemaStore{
+		Examples: map[string][]interface{}{
+			"items[].id":   {1, 2},
+			"items[].name": {"John", "Jane"},
+		},
+		Optional: map[string]bool{
+			"items[].id":   false,
+			"items[].name": false,
+		},
+	}
+	arrayFieldSchema := generateSchemaFromStore(arrayFieldStore, defaultEnumThreshold)
+	assert.Equal(t, "object", arrayFieldSchema.Type)
+	assert.Equal(t, "array", arrayFieldSchema.Properties["items"].Type)
+	assert.Contains(t, arrayFieldSchema.Properties["items"].Items.Properties, "name")
+
 	// Test nested object schema
 	nestedStore := &SchemaStore{
 		Examples: map[string][]interface{}{
@@ -296,7 +319,7 @@ func TestGenerateSchemaFromStore(t *testing.T) {
 		},
 	}
 
-	nestedSchema := generateSchemaFromStore(nestedStore)
+	nestedSchema := generateSchemaFromStore(nestedStore, defaultEnumThreshold)
 	assert.Equal(t, "object", nestedSchema.Type)
 	assert.Contains(t, nestedSchema.Properties, "user")
 
@@ -315,7 +338,7 @@ func TestCreatePropertySchema(t *testing.T) {
 	}{
 		{
 			name:     "string property",
-			examples: []interface{}{"test", "example"},
+			examples: []interface{}{"example", "test"},
 			wantType: "string",
 		},
 		{
@@ -325,7 +348,7 @@ func TestCreatePropertySchema(t *testing.T) {
 		},
 		{
 			name:     "boolean property",
-			examples: []interface{}{true, false},
+			examples: []interface{}{false, true},
 			wantType: "boolean",
 		},
 		{
@@ -342,9 +365,979 @@ func TestCreatePropertySchema(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			schema := createPropertySchema(tt.examples)
+			schema := createPropertySchema(tt.examples, false, defaultEnumThreshold)
 			assert.Equal(t, tt.wantType, schema.Type)
 			assert.Equal(t, tt.examples, schema.Examples)
+			assert.Equal(t, tt.examples[0], schema.Example)
 		})
 	}
 }
+
+func TestEnumInference(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+
+	for _, rating := range []int{4, 2, 5, 1, 3, 5, 4} {
+		body := fmt.Sprintf(`{"rating":%d,"status":"published","score":%d.5,"views":%d}`, rating, rating%2, rating*100)
+		req := httptest.NewRequest("POST", "http://example.com/api/reviews", strings.NewReader(body))
+		a.ProcessRequest("POST", "http://example.com/api/reviews", req, &http.Response{StatusCode: 201}, []byte(body), nil)
+	}
+
+	schema := a.GenerateOpenAPI().Paths["/api/reviews"].Post.RequestBody.Content["application/json"].Schema
+	rating := schema.Properties["rating"]
+	assert.Equal(t, "integer", rating.Type)
+	assert.Equal(t, []interface{}{1.0, 2.0, 3.0, 4.0, 5.0}, rating.Enum)
+	assert.Equal(t, []interface{}{"published"}, schema.Properties["status"].Enum)
+	assert.Equal(t, []interface{}{0.5, 1.5}, schema.Properties["score"].Enum)
+	assert.Equal(t, "number", schema.Properties["score"].Type)
+
+	// Enum values keep their JSON type
+	encoded, err := json.Marshal(rating)
+	require.NoError(t, err)
+	assert.Contains(t, string(encoded), `"enum":[1,2,3,4,5]`)
+
+	// Booleans, whose enum would restate the type or reject the value not yet
+	// observed, and fields with more distinct values than the threshold have
+	// no enum; whole numbers without an enum stay numbers
+	assert.Nil(t, createPropertySchema([]interface{}{true, false}, false, defaultEnumThreshold).Enum)
+	assert.Nil(t, createPropertySchema([]interface{}{true}, false, defaultEnumThreshold).Enum)
+	a.SetEnumThreshold(4)
+	schema = a.GenerateOpenAPI().Paths["/api/reviews"].Post.RequestBody.Content["application/json"].Schema
+	assert.Nil(t, schema.Properties["rating"].Enum)
+	assert.Equal(t, "number", schema.Properties["rating"].Type)
+	assert.Nil(t, schema.Properties["views"].Enum)
+	assert.Equal(t, []interface{}{0.5, 1.5}, schema.Properties["score"].Enum)
+}
+
+func TestGenerateSchemaFromRootScalars(t *testing.T) {
+	tests := []struct {
+		name     string
+		payloads []interface{}
+		wantType string
+	}{
+		{"string", []interface{}{"ok"}, "string"},
+		{"number", []interface{}{float64(7), 42.5}, "number"},
+		{"integer", []interface{}{float64(7), float64(42)}, "integer"}, // Typed by its enum
+		{"boolean", []interface{}{true}, "boolean"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewSchemaStore()
+			for _, payload := range tt.payloads {
+				processJSONPayload(store, "", payload)
+			}
+			schema := generateSchemaFromStore(store, defaultEnumThreshold)
+			assert.Equal(t, tt.wantType, schema.Type)
+			assert.Empty(t, schema.Properties)
+			assert.Equal(t, tt.payloads, schema.Examples)
+			assert.Equal(t, tt.payloads[0], createExampleFromStore(store))
+		})
+	}
+
+	// Root array of primitives
+	store := NewSchemaStore()
+	processJSONPayload(store, "", []interface{}{"a", "b"})
+	schema := generateSchemaFromStore(store, defaultEnumThreshold)
+	assert.Equal(t, "array", schema.Type)
+	assert.NotNil(t, schema.Items)
+	assert.Equal(t, "string", schema.Items.Type)
+	assert.Empty(t, schema.Items.Properties)
+	assert.Equal(t, []interface{}{"a"}, createExampleFromStore(store))
+}
+
+func TestArrayItemSchemas(t *testing.T) {
+	tests := []struct {
+		name      string
+		examples  []interface{}
+		wantItems string
+	}{
+		{
+			name:      "strings",
+			examples:  []interface{}{[]interface{}{"a", "b"}, []interface{}{"c"}},
+			wantItems: `{"type":"string"}`,
+		},
+		{
+			name:      "numbers",
+			examples:  []interface{}{[]interface{}{1.5, 2.0}},
+			wantItems: `{"type":"number"}`,
+		},
+		{
+			name:      "booleans with null",
+			examples:  []interface{}{[]interface{}{true, nil}},
+			wantItems: `{"type":"boolean","nullable":true}`,
+		},
+		{
+			name:      "objects",
+			examples:  []interface{}{[]interface{}{map[string]interface{}{"id": 1.0}}},
+			wantItems: `{"type":"object"}`,
+		},
+		{
+			name:      "nested arrays",
+			examples:  []interface{}{[]interface{}{[]interface{}{"x"}}},
+			wantItems: `{"type":"array","items":{"type":"string"}}`,
+		},
+		{
+			name:      "mixed",
+			examples:  []interface{}{[]interface{}{"a", 1.0}, []interface{}{true}},
+			wantItems: `{}`,
+		},
+		{
+			name:      "empty",
+			examples:  []interface{}{[]interface{}{}},
+			wantItems: `{}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := createPropertySchema(tt.examples, false, defaultEnumThreshold)
+			assert.Equal(t, "array", schema.Type)
+			items, err := json.Marshal(schema.Items)
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.wantItems, string(items))
+		})
+	}
+}
+
+func TestArrayItemSchemasFromPayloads(t *testing.T) {
+	store := NewSchemaStore()
+	payloads := []string{
+		`{"ids":["a1","b2"],"scores":[1,2],"mixed":["a",1],"matrix":[[1,2],[3]],"empty":[]}`,
+		`{"ids":["c3"],"scores":[3],"mixed":[true],"matrix":[[4]],"empty":[]}`,
+	}
+	for _, payload := range payloads {
+		var data interface{}
+		assert.NoError(t, json.Unmarshal([]byte(payload), &data))
+		processJSONPayload(store, "", data)
+	}
+
+	schema := generateSchemaFromStore(store, defaultEnumThreshold)
+	items := func(name string) Schema {
+		property := schema.Properties[name]
+		assert.Equal(t, "array", property.Type, name)
+		if !assert.NotNil(t, property.Items, name) {
+			return Schema{}
+		}
+		return *property.Items
+	}
+
+	assert.Equal(t, "string", items("ids").Type)
+	assert.Equal(t, "integer", items("scores").Type) // A small set of whole numbers is an integer enum
+	assert.Empty(t, items("mixed").Type)
+	assert.False(t, items("mixed").Nullable)
+	matrix := items("matrix")
+	assert.Equal(t, "array", matrix.Type)
+	assert.Equal(t, "number", matrix.Items.Type)
+
+	// Arrays that were always empty keep documenting object items
+	assert.Equal(t, "object", items("empty").Type)
+	assert.False(t, items("empty").Nullable)
+}
+
+func TestNullableProperties(t *testing.T) {
+	store := NewSchemaStore()
+	payloads := []string{
+		`{"nickname":null,"name":"alice","deleted":null,"tags":[null,"a"]}`,
+		`{"nickname":"bob","name":"bob","deleted":null,"tags":["b"]}`,
+	}
+	for _, payload := range payloads {
+		var data interface{}
+		assert.NoError(t, json.Unmarshal([]byte(payload), &data))
+		processJSONPayload(store, "", data)
+	}
+
+	schema := generateSchemaFromStore(store, defaultEnumThreshold)
+
+	// The type comes from the first non-null example
+	nickname := schema.Properties["nickname"]
+	assert.Equal(t, "string", nickname.Type)
+	assert.True(t, nickname.Nullable)
+	assert.Equal(t, []interface{}{"bob", nil}, nickname.Examples)
+	assert.Equal(t, "bob", nickname.Example)
+
+	name := schema.Properties["name"]
+	assert.Equal(t, "string", name.Type)
+	assert.False(t, name.Nullable)
+
+	// A property that was only ever null has no type
+	deleted := schema.Properties["deleted"]
+	assert.Empty(t, deleted.Type)
+	assert.True(t, deleted.Nullable)
+
+	tags := schema.Properties["tags"]
+	assert.Equal(t, "array", tags.Type)
+	assert.Equal(t, "string", tags.Items.Type)
+	assert.True(t, tags.Items.Nullable)
+
+	encoded, err := json.Marshal(deleted)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"nullable":true,"examples":[null]}`, string(encoded))
+}
+
+func TestNullableTrackedBeyondExampleLimit(t *testing.T) {
+	store := NewSchemaStore()
+	for i := 0; i < store.maxExamples; i++ {
+		processJSONPayload(store, "", map[string]interface{}{"note": fmt.Sprintf("note %d", i)})
+	}
+	processJSONPayload(store, "", map[string]interface{}{"note": nil})
+	assert.NotContains(t, store.Examples["note"], nil)
+
+	schema := createPropertySchema(store.Examples["note"], store.Nullable["note"], defaultEnumThreshold)
+	assert.Equal(t, "string", schema.Type)
+	assert.True(t, schema.Nullable)
+}
+
+func TestGenerateOpenAPIDeterministic(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+
+	for i := 0; i < 3; i++ {
+		url := fmt.Sprintf("http://example.com/api/users/%d?zeta=1&alpha=a%d&mid=x&page=%d", i, i, i)
+		req := httptest.NewRequest("GET", url, nil)
+		req.Header.Set("X-Tenant", fmt.Sprintf("tenant-%d", i))
+		req.Header.Set("X-Api-Version", "2")
+		req.Header.Set("Cookie", "theme=dark; cart=1; lang=en")
+		resp := &http.Response{
+			StatusCode: 200,
+			Header:     http.Header{"Set-Cookie": []string{"b=2", "a=1", "c=3"}},
+		}
+		body := fmt.Sprintf(`{"zip":"1000%d","name":"user","role":"r%d","status":"s%d","email":"u@example.com","tags":["t%d","u"]}`, i, i, i%2, i)
+		a.ProcessRequest("GET", url, req, resp, nil, []byte(body))
+	}
+
+	first, err := json.Marshal(a.GenerateOpenAPI())
+	assert.NoError(t, err)
+	for i := 0; i < 20; i++ {
+		next, err := json.Marshal(a.GenerateOpenAPI())
+		assert.NoError(t, err)
+		if !assert.Equal(t, string(first), string(next), "Expected identical output on run %d", i) {
+			break
+		}
+	}
+
+	operation := a.GenerateOpenAPI().Paths["/api/users/{id}"].Get
+	var names []string
+	for _, param := range operation.Parameters {
+		names = append(names, param.In+":"+param.Name)
+	}
+	assert.Equal(t, []string{
+		"path:id",
+		"query:alpha", "query:mid", "query:page", "query:zeta",
+		"header:X-Api-Version", "header:X-Tenant",
+		"cookie:cart", "cookie:lang", "cookie:theme",
+	}, names)
+
+	schema := operation.Responses["200"].Content["application/json"].Schema
+	assert.Equal(t, []interface{}{"s0", "s1"}, schema.Properties["status"].Enum)
+
+	store := &SchemaStore{
+		Examples: map[string][]interface{}{"zip": {"1"}, "name": {"a"}, "email": {"b"}, "role": {"c"}, "note": {"d"}},
+		Optional: map[string]bool{"zip": false, "name": false, "email": false, "role": false, "note": true},
+	}
+	assert.Equal(t, []string{"email", "name", "role", "zip"}, generateSchemaFromStore(store, defaultEnumThreshold).Required)
+}
+
+func TestSortedExamples(t *testing.T) {
+	bodies := []string{
+		`{"name":"carol","age":41,"tags":["b"],"admin":true,"note":null}`,
+		`{"name":"alice","age":7,"tags":["a","c"],"admin":false,"note":"vip"}`,
+		`{"name":"bob","age":19.5,"tags":[],"admin":true,"note":{"text":"x"}}`,
+	}
+	generate := func(order []int) (string, *SchemaStore) {
+		a := NewAnalyzer(t.TempDir(), 3600)
+		defer a.Stop()
+		for _, i := range order {
+			req := httptest.NewRequest("GET", "http://example.com/users/1?sort="+[]string{"z", "a", "m"}[i], nil)
+			a.ProcessRequest("GET", req.URL.String(), req, &http.Response{StatusCode: 200}, nil, []byte(bodies[i]))
+		}
+		output, err := json.Marshal(a.GenerateOpenAPI())
+		require.NoError(t, err)
+		return string(output), a.GetEndpoint("GET", "/users/{id}").ResponseStatuses[200].Payload
+	}
+
+	// The same examples observed in different orders give identical documents
+	first, store := generate([]int{0, 1, 2})
+	for _, order := range [][]int{{2, 1, 0}, {1, 2, 0}} {
+		next, _ := generate(order)
+		assert.Equal(t, first, next, "order %v", order)
+	}
+
+	// The store keeps the observation order
+	assert.Equal(t, []interface{}{"carol", "alice", "bob"}, store.Examples["name"])
+	schema := generateSchemaFromStore(store, defaultEnumThreshold)
+	assert.Equal(t, []interface{}{"alice", "bob", "carol"}, schema.Properties["name"].Examples)
+	assert.Equal(t, "alice", schema.Properties["name"].Example)
+	assert.Equal(t, []interface{}{float64(7), 19.5, float64(41)}, schema.Properties["age"].Examples)
+	example := createExampleFromStore(store).(map[string]interface{})
+	assert.Equal(t, "alice", example["name"])
+	assert.Equal(t, false, example["admin"])
+
+	// Scalars sort naturally before arrays and objects, which sort by their
+	// JSON encoding, and null comes last
+	assert.Equal(t,
+		[]interface{}{false, true, -1, 2.5, "", "a", []interface{}{"a"}, map[string]interface{}{"a": 1}, nil},
+		sortedExamples([]interface{}{nil, map[string]interface{}{"a": 1}, "a", 2.5, []interface{}{"a"}, true, "", -1, false}))
+}
+
+func TestRequiredFromPresence(t *testing.T) {
+	bodies := []string{
+		`{"id":1,"name":"a","items":[{"sku":"x","qty":1}]}`,
+		`{"id":2,"name":"b","note":"gift","items":[{"sku":"y","qty":2},{"sku":"w"}]}`,
+		`{"id":3,"items":[{"sku":"z","qty":3}]}`,
+	}
+	required := func(order []int) ([]string, []string) {
+		a := NewAnalyzer(t.TempDir(), 3600)
+		defer a.Stop()
+		for _, i := range order {
+			req := httptest.NewRequest("POST", "http://example.com/orders", strings.NewReader(bodies[i]))
+			req.Header.Set("Content-Type", "application/json")
+			a.ProcessRequest("POST", "http://example.com/orders", req, &http.Response{StatusCode: 201}, []byte(bodies[i]), []byte(bodies[i]))
+		}
+		operation := a.GenerateOpenAPI().Paths["/orders"].Post
+		request := operation.RequestBody.Content["application/json"].Schema
+		response := operation.Responses["201"].Content["application/json"].Schema
+		assert.Equal(t, request.Required, response.Required)
+		return request.Required, request.Properties["items"].Items.Required
+	}
+
+	// Fields present in every body are required whatever the order of the
+	// bodies, and fields of array items only if present in every item
+	fields, itemFields := required([]int{0, 1, 2})
+	assert.Equal(t, []string{"id", "items"}, fields)
+	assert.Equal(t, []string{"sku"}, itemFields)
+	for _, order := range [][]int{{2, 1, 0}, {1, 0, 2}, {1, 2, 0}} {
+		nextFields, nextItemFields := required(order)
+		assert.Equal(t, fields, nextFields, "order %v", order)
+		assert.Equal(t, itemFields, nextItemFields, "order %v", order)
+	}
+
+	// Bodies of stores saved before presence was counted keep their fields optional
+	store := &SchemaStore{
+		Examples: map[string][]interface{}{"id": {1}, "name": {"a"}},
+		Optional: map[string]bool{"id": true, "name": true},
+	}
+	store.countUncountedBodies()
+	processJSONPayload(store, "", map[string]interface{}{"id": 2})
+	assert.Equal(t, map[string]bool{"id": true, "name": true}, store.Optional)
+
+	// and so do the fields of array items whose elements weren't counted
+	store = &SchemaStore{
+		Examples:     map[string][]interface{}{"items[].sku": {"x"}},
+		Optional:     map[string]bool{"items[].sku": false},
+		Presence:     map[string]int64{"items[].sku": 1},
+		Observations: 1,
+	}
+	store.countUncountedBodies()
+	processJSONPayload(store, "", map[string]interface{}{"items": []interface{}{map[string]interface{}{"sku": "y"}}})
+	assert.Equal(t, map[string]bool{"items[].sku": true}, store.Optional)
+}
+
+func TestCommonQueryParams(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+
+	for _, url := range []string{
+		"http://example.com/products?Page=2&page_size=20&category=books",
+		"http://example.com/products?Page=3&page_size=50&category=games",
+	} {
+		req := httptest.NewRequest("GET", url, nil)
+		a.ProcessRequest("GET", url, req, &http.Response{StatusCode: 200}, nil, []byte(`[]`))
+	}
+
+	params := make(map[string]Parameter)
+	for _, param := range a.GenerateOpenAPI().Paths["/products"].Get.Parameters {
+		_, duplicate := params[param.Name]
+		assert.False(t, duplicate, "Parameter %s documented twice", param.Name)
+		params[param.Name] = param
+	}
+	require.Len(t, params, 3)
+
+	// Common parameters are recognized whatever their case
+	assert.Equal(t, "Page number for pagination", params["Page"].Description)
+	assert.Equal(t, "integer", params["Page"].Schema.Type)
+	assert.Equal(t, "Number of items per page", params["page_size"].Description)
+
+	// Other parameters are documented as custom
+	assert.Equal(t, "Query parameter: category", params["category"].Description)
+	assert.Equal(t, "string", params["category"].Schema.Type)
+	assert.Equal(t, []interface{}{"books", "games"}, params["category"].Schema.Examples)
+}
+
+func TestQueryParameterTypes(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	for _, url := range []string{
+		"http://example.com/products?limit=10&ratio=0.5&active=true&code=007&tag=a&tag=b",
+		"http://example.com/products?limit=20&ratio=2&active=false&code=42&tag=c",
+	} {
+		req := httptest.NewRequest("GET", url, nil)
+		a.ProcessRequest("GET", url, req, &http.Response{StatusCode: 200}, nil, []byte(`[]`))
+	}
+
+	endpoint := a.GetData()["GET /products"]
+	require.NotNil(t, endpoint)
+	assert.Equal(t, []interface{}{float64(10), float64(20)}, endpoint.URLParameters.Examples["limit"])
+	assert.Equal(t, []interface{}{"007", float64(42)}, endpoint.URLParameters.Examples["code"])
+	assert.True(t, endpoint.URLParameters.Repeated["tag"])
+	assert.False(t, endpoint.URLParameters.Repeated["limit"])
+
+	params := make(map[string]Parameter)
+	for _, param := range a.GenerateOpenAPI().Paths["/products"].Get.Parameters {
+		params[param.Name] = param
+	}
+	assert.Equal(t, "integer", params["limit"].Schema.Type)
+	assert.Equal(t, "number", params["ratio"].Schema.Type)
+	assert.Equal(t, "boolean", params["active"].Schema.Type)
+	assert.Equal(t, "string", params["code"].Schema.Type)
+
+	// Repeated parameters are arrays of their values
+	assert.Equal(t, "array", params["tag"].Schema.Type)
+	require.NotNil(t, params["tag"].Schema.Items)
+	assert.Equal(t, "string", params["tag"].Schema.Items.Type)
+	assert.Equal(t, []interface{}{"a", "b", "c"}, params["tag"].Schema.Items.Examples)
+
+	for _, param := range a.GenerateSwagger2().Paths["/products"].Get.Parameters {
+		if param.Name == "tag" {
+			assert.Equal(t, "array", param.Type)
+			assert.Equal(t, "multi", param.CollectionFormat)
+			require.NotNil(t, param.Items)
+			assert.Equal(t, "string", param.Items.Type)
+		}
+	}
+}
+
+func TestQueryValue(t *testing.T) {
+	assert.Equal(t, float64(1), queryValue("1"))
+	assert.Equal(t, -2.5, queryValue("-2.5"))
+	assert.Equal(t, true, queryValue("true"))
+	for _, value := range []string{"007", "1.50", "1e3", "NaN", "Inf", "12345678901234567890", "True", ""} {
+		assert.Equal(t, value, queryValue(value))
+	}
+	assert.Equal(t, "1000000", exampleString(float64(1000000)))
+	assert.Equal(t, "integer", queryParamType([]interface{}{"1", float64(2)}))
+	assert.Equal(t, "number", queryParamType([]interface{}{float64(1), 2.5}))
+	assert.Equal(t, "string", queryParamType([]interface{}{float64(1), true}))
+}
+
+func TestBodyExamples(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	a.SetRedactedFields([]string{"password"})
+
+	reqBody := `{"name":"alice","password":"hunter2","address":{"city":"Paris"},"roles":[{"id":1,"name":"admin"}]}`
+	req := httptest.NewRequest("POST", "http://example.com/users", strings.NewReader(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	a.ProcessRequest("POST", "http://example.com/users", req, &http.Response{StatusCode: 201}, []byte(reqBody), []byte(`{"id":1,"tags":["a","b"]}`))
+	req = httptest.NewRequest("DELETE", "http://example.com/users/1", nil)
+	a.ProcessRequest("DELETE", "http://example.com/users/1", req, &http.Response{StatusCode: 204}, nil, nil)
+
+	paths := a.GenerateOpenAPI().Paths
+	post := paths["/users"].Post
+	assert.Equal(t, map[string]interface{}{
+		"name":     "alice",
+		"password": "REDACTED",
+		"address":  map[string]interface{}{"city": "Paris"},
+		"roles":    []interface{}{map[string]interface{}{"id": float64(1), "name": "admin"}},
+	}, post.RequestBody.Content["application/json"].Example)
+	assert.Equal(t, map[string]interface{}{
+		"id": float64(1), "tags": []interface{}{"a"},
+	}, post.Responses["201"].Content["application/json"].Example)
+
+	// Bodies without any observed field have no example
+	assert.Nil(t, paths["/users/{id}"].Delete.Responses["204"].Content["application/json"].Example)
+}
+
+func TestMixedRootShapes(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+
+	// A list on success and an error object or a message otherwise, all with status 200
+	for _, body := range []string{`[{"id":1,"name":"a"}]`, `{"error":"boom","code":5}`, `"maintenance"`, `[{"id":2,"name":"b"}]`} {
+		req := httptest.NewRequest("GET", "http://example.com/users", nil)
+		a.ProcessRequest("GET", "http://example.com/users", req, &http.Response{StatusCode: 200}, nil, []byte(body))
+	}
+
+	content := a.GenerateOpenAPI().Paths["/users"].Get.Responses["200"].Content["application/json"]
+	schema := content.Schema
+	assert.Empty(t, schema.Type)
+	assert.Empty(t, schema.Properties)
+	require.Len(t, schema.OneOf, 3)
+	assert.Equal(t, "object", schema.OneOf[0].Type)
+	assert.ElementsMatch(t, []string{"error", "code"}, sortedKeys(schema.OneOf[0].Properties))
+	assert.Equal(t, "array", schema.OneOf[1].Type)
+	assert.Equal(t, "object", schema.OneOf[1].Items.Type)
+	assert.ElementsMatch(t, []string{"id", "name"}, sortedKeys(schema.OneOf[1].Items.Properties))
+	assert.Equal(t, "string", schema.OneOf[2].Type)
+
+	// The body example is one of the shapes, not a mix of them
+	assert.Equal(t, map[string]interface{}{"error": "boom", "code": float64(5)}, content.Example)
+
+	// Alternatives become a union in TypeScript
+	assert.Contains(t, a.GenerateTypeScript(false, true), "export type GetUsersResponse200 = GetUsersResponse200Object | GetUsersResponse200ArrayItem[] | \"maintenance\";")
+
+	// An array field of a root object is not mistaken for a root array
+	store := NewSchemaStore()
+	processJSONPayload(store, "", map[string]interface{}{"tags": []interface{}{"a", "b"}})
+	schema = generateSchemaFromStore(store, defaultEnumThreshold)
+	assert.Equal(t, "object", schema.Type)
+	assert.Equal(t, "array", schema.Properties["tags"].Type)
+	assert.Equal(t, "string", schema.Properties["tags"].Items.Type)
+}
+
+func TestPathParamExamples(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+
+	for _, url := range []string{
+		"http://example.com/users/123",
+		"http://example.com/users/456",
+		"http://example.com/users/123",
+		"http://example.com/users/7/files/123e4567-e89b-12d3-a456-426614174000",
+		"http://example.com/users/7/orders/42",
+	} {
+		req := httptest.NewRequest("GET", url, nil)
+		a.ProcessRequest("GET", url, req, &http.Response{StatusCode: 200}, nil, nil)
+	}
+
+	paths := a.GenerateOpenAPI().Paths
+	params := paths["/users/{id}"].Get.Parameters
+	require.Len(t, params, 1)
+	assert.Equal(t, "id", params[0].Name)
+	assert.Equal(t, []interface{}{123, 456}, params[0].Schema.Examples)
+
+	// Each parameter of a route gets its own examples
+	params = paths["/users/{id}/files/{uuid}"].Get.Parameters
+	require.Len(t, params, 2)
+	assert.Equal(t, []interface{}{7}, params[0].Schema.Examples)
+	assert.Equal(t, []interface{}{"123e4567-e89b-12d3-a456-426614174000"}, params[1].Schema.Examples)
+
+	// Repeated parameters are numbered in the path template
+	assert.NotContains(t, paths, "/users/{id}/orders/{id}")
+	params = paths["/users/{id}/orders/{id2}"].Get.Parameters
+	require.Len(t, params, 2)
+	assert.Equal(t, "id", params[0].Name)
+	assert.Equal(t, []interface{}{7}, params[0].Schema.Examples)
+	assert.Equal(t, "id2", params[1].Name)
+	assert.Equal(t, []interface{}{42}, params[1].Schema.Examples)
+	assert.Equal(t, []string{"id", "id2"}, pathParamKeys("/users/{id}/orders/{id}"))
+}
+
+func TestNoDuplicateParameters(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+
+	for _, method := range []string{"GET", "PUT", "DELETE"} {
+		for _, url := range []string{
+			"http://example.com/users/7/orders/42/items/3?id=5",
+			"http://example.com/users/8/orders/43/items/4",
+			"http://example.com/orgs/123e4567-e89b-12d3-a456-426614174000/teams/123e4567-e89b-12d3-a456-426614174001",
+		} {
+			req := httptest.NewRequest(method, url, nil)
+			a.ProcessRequest(method, url, req, &http.Response{StatusCode: 200}, nil, nil)
+		}
+	}
+
+	paths := a.GenerateOpenAPI().Paths
+	require.Contains(t, paths, "/users/{id}/orders/{id2}/items/{id3}")
+	require.Contains(t, paths, "/orgs/{uuid}/teams/{uuid2}")
+	for path, pathItem := range paths {
+		for _, operation := range []*Operation{pathItem.Get, pathItem.Put, pathItem.Delete} {
+			require.NotNil(t, operation, path)
+			seen := make(map[string]bool)
+			for _, param := range operation.Parameters {
+				key := param.In + " " + param.Name
+				assert.False(t, seen[key], "%s %s: duplicate parameter %s", operation.Summary, path, key)
+				seen[key] = true
+			}
+		}
+	}
+
+	// A query parameter may share the name of a path parameter
+	var names []string
+	for _, param := range paths["/users/{id}/orders/{id2}/items/{id3}"].Get.Parameters {
+		names = append(names, param.In+":"+param.Name)
+	}
+	assert.Equal(t, []string{"path:id", "path:id2", "path:id3", "query:id"}, names)
+
+	assert.Equal(t, []Parameter{{Name: "id", In: "path"}, {Name: "id", In: "query"}},
+		dedupeParameters([]Parameter{{Name: "id", In: "path"}, {Name: "id", In: "query"}, {Name: "id", In: "path", Description: "again"}}))
+}
+
+func TestResponseDescriptions(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	a.SetCaptureErrors(true)
+	a.SetResponseDescriptions(map[string]string{
+		"get  /api/users/{id} 404": "User does not exist",
+	})
+
+	for _, status := range []int{200, 404, 599} {
+		req := httptest.NewRequest("GET", "http://example.com/api/users/1", nil)
+		a.ProcessRequest("GET", "http://example.com/api/users/1", req, &http.Response{StatusCode: status}, nil, []byte(`{"id":1}`))
+	}
+
+	responses := a.GenerateOpenAPI().Paths["/api/users/{id}"].Get.Responses
+	assert.Equal(t, "OK", responses["200"].Description)
+	assert.Equal(t, "User does not exist", responses["404"].Description)
+	assert.Equal(t, "Status 599", responses["599"].Description)
+}
+
+func TestLocationHeader(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	req := httptest.NewRequest("POST", "http://example.com/orders", strings.NewReader(`{"sku":"A-1"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp := &http.Response{StatusCode: 201, Header: http.Header{"Location": []string{"/orders/1"}}}
+	a.ProcessRequest("POST", "http://example.com/orders", req, resp, []byte(`{"sku":"A-1"}`), []byte(`{"id":1}`))
+	req = httptest.NewRequest("GET", "http://example.com/orders/latest", nil)
+	resp = &http.Response{StatusCode: 302, Header: http.Header{"Location": []string{"https://example.com/orders/1"}}}
+	a.ProcessRequest("GET", "http://example.com/orders/latest", req, resp, nil, nil)
+
+	openAPI := a.GenerateOpenAPI()
+	created := openAPI.Paths["/orders"].Post.Responses["201"]
+	assert.Equal(t, Header{
+		Description: "URL of the created resource",
+		Schema:      Schema{Type: "string", Examples: []interface{}{"/orders/1"}},
+	}, created.Headers["Location"])
+	assert.Contains(t, created.Content, "application/json")
+
+	// Redirects are documented with their target and without a body
+	redirect, exists := openAPI.Paths["/orders/latest"].Get.Responses["302"]
+	require.True(t, exists)
+	assert.Equal(t, "Found", redirect.Description)
+	assert.Equal(t, "URL the request is redirected to", redirect.Headers["Location"].Description)
+	assert.Equal(t, []interface{}{"https://example.com/orders/1"}, redirect.Headers["Location"].Schema.Examples)
+	assert.Empty(t, redirect.Content)
+
+	swagger := a.GenerateSwagger2().Paths["/orders/latest"].Get.Responses["302"]
+	assert.Nil(t, swagger.Schema)
+	assert.Equal(t, "https://example.com/orders/1", swagger.Headers["Location"].Example)
+}
+
+func TestInfo(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+
+	info := a.GenerateOpenAPI().Info
+	assert.Equal(t, "API Documentation", info.Title)
+	assert.Equal(t, "1.0.0", info.Version)
+	assert.Nil(t, info.Contact)
+	assert.Nil(t, info.License)
+
+	a.SetInfo(Info{
+		Title:       "Shop API",
+		Version:     "2.3.0",
+		Description: "Orders and products",
+		Contact:     &Contact{Email: "api@example.com"},
+		License:     &License{Name: "MIT"},
+	})
+	info = a.GenerateOpenAPI().Info
+	assert.Equal(t, "Shop API", info.Title)
+	assert.Equal(t, "2.3.0", info.Version)
+	assert.Equal(t, "Orders and products", info.Description)
+	assert.Equal(t, "api@example.com", info.Contact.Email)
+	assert.Equal(t, "MIT", info.License.Name)
+	assert.Equal(t, info, a.GenerateSwagger2().Info)
+
+	data, err := json.Marshal(info)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"title":"Shop API","version":"2.3.0","description":"Orders and products","contact":{"email":"api@example.com"},"license":{"name":"MIT"}}`, string(data))
+
+	// Unset values keep the defaults
+	a.SetInfo(Info{Description: "Orders"})
+	info = a.GenerateOpenAPI().Info
+	assert.Equal(t, "API Documentation", info.Title)
+	assert.Equal(t, "1.0.0", info.Version)
+}
+
+func TestRequestBodyMediaTypes(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+
+	process := func(contentType, body string) {
+		req := httptest.NewRequest("POST", "http://example.com/api/login", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		a.ProcessRequest("POST", "http://example.com/api/login", req, &http.Response{StatusCode: 200}, []byte(body), []byte(`{"ok":true}`))
+	}
+	process("application/json", `{"username":"alice","remember":true}`)
+	process("application/x-www-form-urlencoded; charset=utf-8", "username=bob&password=secret&tags=a&tags=b")
+
+	content := a.GenerateOpenAPI().Paths["/api/login"].Post.RequestBody.Content
+	require.Len(t, content, 2)
+
+	jsonSchema := content["application/json"].Schema
+	assert.Equal(t, "boolean", jsonSchema.Properties["remember"].Type)
+	assert.NotContains(t, jsonSchema.Properties, "password")
+
+	formSchema := content["application/x-www-form-urlencoded"].Schema
+	assert.Equal(t, "object", formSchema.Type)
+	assert.Equal(t, "string", formSchema.Properties["username"].Type)
+	assert.Equal(t, []interface{}{"bob"}, formSchema.Properties["username"].Examples)
+	assert.Equal(t, []interface{}{"a", "b"}, formSchema.Properties["tags"].Examples)
+	assert.NotContains(t, formSchema.Properties, "remember")
+
+	// Swagger 2.0 can't describe both, so the JSON body is kept
+	swagger := a.GenerateSwagger2()
+	post := swagger.Paths["/api/login"].Post
+	assert.Equal(t, []string{"application/json", "application/x-www-form-urlencoded"}, post.Consumes)
+	require.Len(t, post.Parameters, 1)
+	assert.Equal(t, "body", post.Parameters[0].In)
+	validateSwagger2(t, swagger)
+}
+
+func TestFormOnlyRequestBody(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+
+	body := "email=alice%40example.com&plan=pro"
+	req := httptest.NewRequest("POST", "http://example.com/api/signup", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	a.ProcessRequest("POST", "http://example.com/api/signup", req, &http.Response{StatusCode: 201}, []byte(body), nil)
+
+	operation := a.GenerateOpenAPI().Paths["/api/signup"].Post
+	require.NotNil(t, operation.RequestBody)
+	assert.Equal(t, []interface{}{"alice@example.com"}, operation.RequestBody.Content["application/x-www-form-urlencoded"].Schema.Properties["email"].Examples)
+	assert.NotContains(t, operation.RequestBody.Content, "application/json")
+
+	// Swagger 2.0 describes form fields as formData parameters
+	swagger := a.GenerateSwagger2()
+	var params []string
+	for _, param := range swagger.Paths["/api/signup"].Post.Parameters {
+		params = append(params, param.In+":"+param.Name)
+	}
+	assert.Equal(t, []string{"formData:email", "formData:plan"}, params)
+	validateSwagger2(t, swagger)
+}
+
+func TestServers(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	assert.Empty(t, a.GenerateOpenAPI().Servers)
+
+	// Without a proxy port the backend URL is documented
+	a.SetProxyConfig(0, "http://localhost:8080")
+	assert.Equal(t, []APIServer{{URL: "http://localhost:8080"}}, a.GenerateOpenAPI().Servers)
+
+	// The proxy's own URL is documented by default
+	a.SetProxyConfig(9876, "http://localhost:8080")
+	assert.Equal(t, []APIServer{{URL: "http://localhost:9876", Description: "DocuRift proxy"}}, a.GenerateOpenAPI().Servers)
+
+	// A public URL wins over the proxy and backend URLs
+	a.SetServerURL("https://api.example.com/v1")
+	assert.Equal(t, []APIServer{{URL: "https://api.example.com/v1"}}, a.GenerateOpenAPI().Servers)
+
+	data, err := json.Marshal(a.GenerateOpenAPI())
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"servers":[{"url":"https://api.example.com/v1"}]`)
+	assert.NotContains(t, string(data), "localhost:8080")
+
+	// Configured servers win over the public URL
+	servers := []APIServer{
+		{URL: "https://api.example.com/v1", Description: "Production"},
+		{URL: "https://staging.example.com/v1", Description: "Staging"},
+	}
+	a.SetServers(servers)
+	assert.Equal(t, servers, a.GenerateOpenAPI().Servers)
+
+	data, err = json.Marshal(a.GenerateOpenAPI())
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"servers":[{"url":"https://api.example.com/v1","description":"Production"},{"url":"https://staging.example.com/v1","description":"Staging"}]`)
+}
+
+func TestTags(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+
+	for _, url := range []string{"http://example.com/users/1", "http://example.com/api/v2/invoices", "http://example.com/"} {
+		req := httptest.NewRequest("GET", url, nil)
+		a.ProcessRequest("GET", url, req, &http.Response{StatusCode: 200}, nil, []byte(`{"ok":true}`))
+	}
+
+	spec := a.GenerateOpenAPI()
+	assert.Equal(t, []string{"users"}, spec.Paths["/users/{id}"].Get.Tags)
+	assert.Equal(t, []string{"invoices"}, spec.Paths["/api/v2/invoices"].Get.Tags)
+	assert.Empty(t, spec.Paths["/"].Get.Tags)
+	assert.Equal(t, []Tag{
+		{Name: "invoices", Description: "Endpoints for invoices"},
+		{Name: "users", Description: "Endpoints for users"},
+	}, spec.Tags)
+
+	swagger := a.GenerateSwagger2()
+	assert.Equal(t, []string{"users"}, swagger.Paths["/users/{id}"].Get.Tags)
+	validateSwagger2(t, swagger)
+}
+
+func TestResourceTag(t *testing.T) {
+	assert.Equal(t, "orders", resourceTag("/api/v1/orders/{id}/items"))
+	assert.Equal(t, "payment-methods", resourceTag("/payment-methods"))
+	assert.Equal(t, "version", resourceTag("/version"))
+	assert.Equal(t, "", resourceTag("/api/{uuid}"))
+}
+
+func TestCachingHeaders(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+
+	// Caching headers are not excluded by default
+	for header := range cachingHeaderDescriptions {
+		assert.False(t, a.isExcludedHeader(header), header)
+	}
+
+	respHeaders := http.Header{}
+	respHeaders.Set("ETag", `"33a64df5"`)
+	respHeaders.Set("Cache-Control", "max-age=3600")
+	respHeaders.Set("Content-Length", "11")
+	req := httptest.NewRequest("GET", "http://example.com/api/products/1", nil)
+	a.ProcessRequest("GET", "http://example.com/api/products/1", req, &http.Response{StatusCode: 200, Header: respHeaders}, nil, []byte(`{"id":1}`))
+
+	headers := a.GenerateOpenAPI().Paths["/api/products/{id}"].Get.Responses["200"].Headers
+	require.Contains(t, headers, "Etag")
+	require.Contains(t, headers, "Cache-Control")
+	assert.NotContains(t, headers, "Content-Length")
+	assert.Equal(t, []interface{}{`"33a64df5"`}, headers["Etag"].Schema.Examples)
+	assert.Equal(t, cachingHeaderDescriptions["Etag"], headers["Etag"].Description)
+	assert.Equal(t, []interface{}{"max-age=3600"}, headers["Cache-Control"].Schema.Examples)
+	assert.Contains(t, headers["Cache-Control"].Description, "max-age")
+
+	swagger := a.GenerateSwagger2()
+	assert.Equal(t, cachingHeaderDescriptions["Etag"], swagger.Paths["/api/products/{id}"].Get.Responses["200"].Headers["Etag"].Description)
+	validateSwagger2(t, swagger)
+}
+
+func TestOperationIDs(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+
+	process := func(method, url string) {
+		req := httptest.NewRequest(method, url, nil)
+		a.ProcessRequest(method, url, req, &http.Response{StatusCode: 200}, nil, []byte(`{"ok":true}`))
+	}
+	process("GET", "http://example.com/users/1")
+	process("DELETE", "http://example.com/users/1")
+	process("POST", "http://example.com/invoices")
+	// Both paths map to getUsersList, so the later one gets a counter
+	process("GET", "http://example.com/users/list")
+	process("GET", "http://example.com/users-list")
+
+	spec := a.GenerateOpenAPI()
+	assert.Equal(t, "getUsersById", spec.Paths["/users/{id}"].Get.OperationID)
+	assert.Equal(t, "deleteUsersById", spec.Paths["/users/{id}"].Delete.OperationID)
+	assert.Equal(t, "postInvoices", spec.Paths["/invoices"].Post.OperationID)
+	assert.Equal(t, "getUsersList", spec.Paths["/users-list"].Get.OperationID)
+	assert.Equal(t, "getUsersList2", spec.Paths["/users/list"].Get.OperationID)
+
+	// Operation IDs are unique and stable across generations
+	seen := make(map[string]bool)
+	for path, pathItem := range spec.Paths {
+		for _, operation := range []*Operation{pathItem.Get, pathItem.Post, pathItem.Put, pathItem.Delete} {
+			if operation == nil {
+				continue
+			}
+			assert.False(t, seen[operation.OperationID], "%s: duplicate operationId %s", path, operation.OperationID)
+			seen[operation.OperationID] = true
+		}
+	}
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, "getUsersList2", a.GenerateOpenAPI().Paths["/users/list"].Get.OperationID)
+	}
+
+	swagger := a.GenerateSwagger2()
+	assert.Equal(t, "getUsersById", swagger.Paths["/users/{id}"].Get.OperationID)
+	validateSwagger2(t, swagger)
+}
+
+func TestOperationID(t *testing.T) {
+	assert.Equal(t, "getUsersByIdOrders", operationID("GET", "/users/{id}/orders"))
+	assert.Equal(t, "putApiPaymentMethodsByUuid", operationID("PUT", "/api/payment-methods/{uuid}"))
+	assert.Equal(t, "get", operationID("GET", "/"))
+}
+
+func TestNumericStringIDs(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+
+	for _, url := range []string{
+		"http://example.com/orders/00042",
+		"http://example.com/orders/43",
+		"http://example.com/tweets/1234567890123456789",
+		"http://example.com/users/7",
+	} {
+		req := httptest.NewRequest("GET", url, nil)
+		a.ProcessRequest("GET", url, req, &http.Response{StatusCode: 200}, nil, nil)
+	}
+
+	// Zero-padded and long IDs keep their digits and are documented as strings
+	paths := a.GenerateOpenAPI().Paths
+	order := paths["/orders/{id}"].Get.Parameters[0].Schema
+	assert.Equal(t, "string", order.Type)
+	assert.Equal(t, "^[0-9]+$", order.Pattern)
+	assert.Equal(t, []interface{}{43, "00042"}, order.Examples)
+
+	tweet := paths["/tweets/{id}"].Get.Parameters[0].Schema
+	assert.Equal(t, "string", tweet.Type)
+	assert.Equal(t, []interface{}{"1234567890123456789"}, tweet.Examples)
+
+	user := paths["/users/{id}"].Get.Parameters[0].Schema
+	assert.Equal(t, "integer", user.Type)
+	assert.Empty(t, user.Pattern)
+
+	validateSwagger2(t, a.GenerateSwagger2())
+}
+
+func TestDeprecatedOperations(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	for _, url := range []string{"https://example.com/v1/users", "https://example.com/v2/users"} {
+		req := httptest.NewRequest("GET", url, nil)
+		a.ProcessRequest("GET", url, req, &http.Response{StatusCode: 200}, nil, []byte(`[{"id":1}]`))
+	}
+	// Traffic moved away from /v1 a month ago
+	stale := a.endpoints.shard("GET /v1/users").endpoints["GET /v1/users"]
+	stale.LastSeen = time.Now().Add(-30 * 24 * time.Hour)
+
+	// Nothing is deprecated unless a threshold is configured
+	openAPI := a.GenerateOpenAPI()
+	assert.False(t, openAPI.Paths["/v1/users"].Get.Deprecated)
+	assert.False(t, openAPI.Paths["/v2/users"].Get.Deprecated)
+
+	a.SetDeprecateAfter(7 * 24 * time.Hour)
+	openAPI = a.GenerateOpenAPI()
+	assert.True(t, openAPI.Paths["/v1/users"].Get.Deprecated)
+	assert.False(t, openAPI.Paths["/v2/users"].Get.Deprecated)
+	assert.True(t, a.GenerateSwagger2().Paths["/v1/users"].Get.Deprecated)
+
+	data, err := json.Marshal(openAPI.Paths)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(data), `"deprecated":true`))
+
+	// Endpoints loaded from a state without a last seen time are never deprecated
+	stale.LastSeen = time.Time{}
+	assert.False(t, a.GenerateOpenAPI().Paths["/v1/users"].Get.Deprecated)
+}
+
+func TestResponseOccurrenceCount(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	a.SetCaptureErrors(true)
+	for i, status := range []int{200, 200, 404, 200, 500, 200} {
+		url := fmt.Sprintf("https://example.com/users/%d", i+1)
+		req := httptest.NewRequest("GET", url, nil)
+		a.ProcessRequest("GET", url, req, &http.Response{StatusCode: status}, nil, []byte(`{"id":1}`))
+	}
+
+	endpoint := a.GetData()["GET /users/{id}"]
+	require.NotNil(t, endpoint)
+	assert.Equal(t, int64(4), endpoint.ResponseStatuses[200].Count)
+	assert.Equal(t, int64(1), endpoint.ResponseStatuses[404].Count)
+
+	responses := a.GenerateOpenAPI().Paths["/users/{id}"].Get.Responses
+	assert.Equal(t, int64(4), responses["200"].OccurrenceCount)
+	assert.Equal(t, int64(1), responses["404"].OccurrenceCount)
+	assert.Equal(t, int64(1), responses["500"].OccurrenceCount)
+	assert.Equal(t, int64(1), a.GenerateSwagger2().Paths["/users/{id}"].Get.Responses["500"].OccurrenceCount)
+
+	data, err := json.Marshal(responses["200"])
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"x-occurrence-count":4`)
+}
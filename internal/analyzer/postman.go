@@ -3,6 +3,8 @@ package analyzer
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"sort"
 	"strings"
 )
 
@@ -42,11 +44,19 @@ type PostmanHeader struct {
 
 // PostmanURL represents a URL in a Postman request
 type PostmanURL struct {
-	Raw      string         `json:"raw"`
-	Protocol string         `json:"protocol"`
-	Host     []string       `json:"host"`
-	Path     []string       `json:"path"`
-	Query    []PostmanQuery `json:"query,omitempty"`
+	Raw      string            `json:"raw"`
+	Protocol string            `json:"protocol"`
+	Host     []string          `json:"host"`
+	Port     string            `json:"port,omitempty"`
+	Path     []string          `json:"path"`
+	Query    []PostmanQuery    `json:"query,omitempty"`
+	Variable []PostmanVariable `json:"variable,omitempty"`
+}
+
+// PostmanVariable represents a path variable, e.g. :id, in a Postman request
+type PostmanVariable struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
 }
 
 // PostmanQuery represents a query parameter in a Postman request
@@ -62,25 +72,38 @@ type PostmanRequestBody struct {
 	Options map[string]interface{} `json:"options,omitempty"`
 }
 
-// GeneratePostmanCollection generates a Postman collection from analyzer data
+// GeneratePostmanCollection generates a Postman collection from analyzer data.
+// Requests are sent to the configured Postman base URL, or else to the first
+// server of the specifications.
 func (a *Analyzer) GeneratePostmanCollection() *PostmanCollection {
-	a.mu.RLock()
-	defer a.mu.RUnlock()
+	endpoints := a.GetData()
+	server := postmanServer(a.getPostmanBaseURL())
 
 	collection := &PostmanCollection{}
 	collection.Info.Name = "API Collection"
 	collection.Info.Description = "Generated API collection from analyzer data"
 	collection.Info.Schema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
 
-	// Group endpoints by base path
+	// Group endpoints by base path, in order of path and method so the
+	// collection is the same for identical data
+	keys := sortedKeys(endpoints)
+	sort.SliceStable(keys, func(i, j int) bool {
+		first, second := endpoints[keys[i]], endpoints[keys[j]]
+		if first.URL != second.URL {
+			return first.URL < second.URL
+		}
+		return first.Method < second.Method
+	})
 	endpointsByPath := make(map[string][]*EndpointData)
-	for _, endpoint := range a.endpoints {
+	for _, key := range keys {
+		endpoint := endpoints[key]
 		path := strings.Split(endpoint.URL, "/")[1] // Get the first segment after /
 		endpointsByPath[path] = append(endpointsByPath[path], endpoint)
 	}
 
 	// Create items for each group
-	for path, endpoints := range endpointsByPath {
+	for _, path := range sortedKeys(endpointsByPath) {
+		endpoints := endpointsByPath[path]
 		item := PostmanItem{
 			Name:        path,
 			Description: fmt.Sprintf("Endpoints for %s", path),
@@ -89,7 +112,7 @@ func (a *Analyzer) GeneratePostmanCollection() *PostmanCollection {
 
 		// Add each endpoint as a request
 		for _, endpoint := range endpoints {
-			request := createPostmanRequest(endpoint)
+			request := createPostmanRequest(endpoint, server)
 			if request != nil {
 				item.Item = append(item.Item, PostmanItem{
 					Name:        fmt.Sprintf("%s %s", endpoint.Method, endpoint.URL),
@@ -105,23 +128,73 @@ func (a *Analyzer) GeneratePostmanCollection() *PostmanCollection {
 	return collection
 }
 
-// createPostmanRequest creates a Postman request from an endpoint
-func createPostmanRequest(endpoint *EndpointData) *PostmanRequest {
+// postmanServer returns a server URL as a Postman URL, whose host is split
+// into its dot separated segments and whose path holds the segments of the
+// base path requests are sent under
+func postmanServer(serverURL string) PostmanURL {
+	parsed, err := url.Parse(serverURL)
+	if err != nil || parsed.Host == "" {
+		parsed, _ = url.Parse(defaultBaseURL)
+	}
+	basePath := strings.TrimSuffix(parsed.Path, "/")
+	return PostmanURL{
+		Raw:      parsed.Scheme + "://" + parsed.Host + basePath,
+		Protocol: parsed.Scheme,
+		Host:     strings.Split(parsed.Hostname(), "."),
+		Port:     parsed.Port(),
+		Path:     postmanPath(basePath),
+	}
+}
+
+// postmanPath returns the segments of a path, without the empty segments
+// produced by leading, trailing or repeated slashes
+func postmanPath(path string) []string {
+	segments := make([]string, 0)
+	for _, segment := range strings.Split(path, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// createPostmanRequest creates a Postman request from an endpoint sent to
+// server. Path parameters become Postman variables such as :id, set to their
+// first example.
+func createPostmanRequest(endpoint *EndpointData, server PostmanURL) *PostmanRequest {
 	request := &PostmanRequest{
 		Method: endpoint.Method,
 		Header: make([]PostmanHeader, 0),
 		URL: PostmanURL{
-			Raw:      endpoint.URL,
-			Protocol: "http",
-			Host:     []string{"localhost:8080"},
-			Path:     strings.Split(endpoint.URL, "/"),
+			Protocol: server.Protocol,
+			Host:     server.Host,
+			Port:     server.Port,
+			Path:     append([]string{}, server.Path...),
 		},
 	}
 
+	// Add path segments, with variables for path parameters
+	segments := postmanPath(templatePath(endpoint.URL))
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			continue
+		}
+		name := strings.Trim(segment, "{}")
+		segments[i] = ":" + name
+		variable := PostmanVariable{Key: name}
+		if endpoint.PathParams != nil {
+			if values := endpoint.PathParams.Examples[name]; len(values) > 0 {
+				variable.Value = fmt.Sprintf("%v", values[0])
+			}
+		}
+		request.URL.Variable = append(request.URL.Variable, variable)
+	}
+	request.URL.Path = append(request.URL.Path, segments...)
+
 	// Add headers
 	if endpoint.RequestHeaders != nil {
-		for header, values := range endpoint.RequestHeaders.Examples {
-			if len(values) > 0 {
+		for _, header := range sortedKeys(endpoint.RequestHeaders.Examples) {
+			if values := endpoint.RequestHeaders.Examples[header]; len(values) > 0 {
 				request.Header = append(request.Header, PostmanHeader{
 					Key:   header,
 					Value: fmt.Sprintf("%v", values[0]),
@@ -133,16 +206,26 @@ func createPostmanRequest(endpoint *EndpointData) *PostmanRequest {
 
 	// Add query parameters
 	if endpoint.URLParameters != nil {
-		for param, values := range endpoint.URLParameters.Examples {
-			if len(values) > 0 {
+		for _, param := range sortedKeys(endpoint.URLParameters.Examples) {
+			if values := endpoint.URLParameters.Examples[param]; len(values) > 0 {
 				request.URL.Query = append(request.URL.Query, PostmanQuery{
 					Key:   param,
-					Value: fmt.Sprintf("%v", values[0]),
+					Value: exampleString(values[0]),
 				})
 			}
 		}
 	}
 
+	// Build the raw URL from the path and query parameters
+	request.URL.Raw = server.Raw + "/" + strings.Join(segments, "/")
+	if len(request.URL.Query) > 0 {
+		query := make([]string, len(request.URL.Query))
+		for i, param := range request.URL.Query {
+			query[i] = url.QueryEscape(param.Key) + "=" + url.QueryEscape(param.Value)
+		}
+		request.URL.Raw += "?" + strings.Join(query, "&")
+	}
+
 	// Add request body if exists
 	if endpoint.RequestPayload != nil && len(endpoint.RequestPayload.Examples) > 0 {
 		// Convert the first example to JSON
@@ -166,26 +249,42 @@ func createPostmanRequest(endpoint *EndpointData) *PostmanRequest {
 	return request
 }
 
-// createExampleFromStore creates an example object from a SchemaStore
+// createExampleFromStore creates an example object from a SchemaStore, with
+// the first example of each path in the order of sortedExamples
 func createExampleFromStore(store *SchemaStore) interface{} {
 	if store == nil || len(store.Examples) == 0 {
 		return nil
 	}
 
+	// Bodies of different shapes get an example of the first one, like the
+	// first alternative of their schema
+	if shapes := splitRootShapes(store); len(shapes) > 1 {
+		return createExampleFromStore(shapes[0])
+	}
+
+	// A root-level scalar payload
+	if values := store.Examples[rootPath]; len(values) > 0 && len(store.Examples) == 1 {
+		return sortedExamples(values)[0]
+	}
+
 	// Create a map to hold the example
 	example := make(map[string]interface{})
 
-	// Process each path and its examples
-	for path, values := range store.Examples {
-		if len(values) == 0 {
+	// Process each path and its examples, in a fixed order so conflicting
+	// paths always resolve the same way
+	for _, path := range sortedKeys(store.Examples) {
+		values := store.Examples[path]
+		if len(values) == 0 || path == rootPath {
 			continue
 		}
+		value := sortedExamples(values)[0]
 
 		// Split the path into parts
 		parts := strings.Split(path, ".")
 		current := example
 
-		// Navigate through the path
+		// Navigate through the path, skipping paths that conflict with the
+		// example built so far, e.g. a field seen both as a string and an object
 		for i, part := range parts {
 			isLast := i == len(parts)-1
 			isArray := strings.HasSuffix(part, "[]")
@@ -195,29 +294,42 @@ func createExampleFromStore(store *SchemaStore) interface{} {
 				if _, exists := current[part]; !exists {
 					current[part] = make([]interface{}, 0)
 				}
+				arr, ok := current[part].([]interface{})
+				if !ok {
+					break
+				}
 				if isLast {
-					arr := current[part].([]interface{})
-					arr = append(arr, values[0])
-					current[part] = arr
+					current[part] = append(arr, value)
 				} else {
-					arr := current[part].([]interface{})
 					if len(arr) == 0 {
 						arr = append(arr, make(map[string]interface{}))
+						current[part] = arr
+					}
+					if current, ok = arr[0].(map[string]interface{}); !ok {
+						break
 					}
-					current = arr[0].(map[string]interface{})
 				}
 			} else {
 				if isLast {
-					current[part] = values[0]
+					current[part] = value
 				} else {
 					if _, exists := current[part]; !exists {
 						current[part] = make(map[string]interface{})
 					}
-					current = current[part].(map[string]interface{})
+					next, ok := current[part].(map[string]interface{})
+					if !ok {
+						break
+					}
+					current = next
 				}
 			}
 		}
 	}
 
+	// Paths of a root array ("[]", "[].id") are collected under an empty key
+	if root, exists := example[""]; exists && len(example) == 1 {
+		return root
+	}
+
 	return example
 }
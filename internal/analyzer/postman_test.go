@@ -0,0 +1,131 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratePostmanCollectionDeterministic(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+
+	for _, resource := range []string{"users", "orders", "carts", "products"} {
+		for _, method := range []string{"PUT", "GET", "POST", "DELETE"} {
+			url := fmt.Sprintf("http://example.com/%s/1?zeta=1&alpha=a&mid=x", resource)
+			body := `{"zip":"10001","name":"user","email":"u@example.com","tags":["a"]}`
+			req := httptest.NewRequest(method, url, strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Tenant", "acme")
+			req.Header.Set("X-Api-Version", "2")
+			a.ProcessRequest(method, url, req, &http.Response{StatusCode: 200}, []byte(body), []byte(body))
+		}
+	}
+
+	first, err := json.Marshal(a.GeneratePostmanCollection())
+	require.NoError(t, err)
+	for i := 0; i < 20; i++ {
+		next, err := json.Marshal(a.GeneratePostmanCollection())
+		require.NoError(t, err)
+		if !assert.Equal(t, string(first), string(next), "Expected identical output on run %d", i) {
+			break
+		}
+	}
+
+	collection := a.GeneratePostmanCollection()
+	var folders []string
+	for _, folder := range collection.Item {
+		folders = append(folders, folder.Name)
+	}
+	assert.Equal(t, []string{"carts", "orders", "products", "users"}, folders)
+
+	var names []string
+	for _, item := range collection.Item[0].Item {
+		names = append(names, item.Name)
+	}
+	assert.Equal(t, []string{"DELETE /carts/{id}", "GET /carts/{id}", "POST /carts/{id}", "PUT /carts/{id}"}, names)
+
+	request := collection.Item[0].Item[1].Request
+	var queries []string
+	for _, query := range request.URL.Query {
+		queries = append(queries, query.Key)
+	}
+	assert.Equal(t, []string{"alpha", "mid", "zeta"}, queries)
+}
+
+func TestGeneratePostmanCollectionServer(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	req := httptest.NewRequest("GET", "http://example.com/users?active=true", nil)
+	req.Header.Set("X-Tenant", "acme")
+	a.ProcessRequest("GET", "http://example.com/users?active=true", req, &http.Response{StatusCode: 200}, nil, []byte(`[{"id":1}]`))
+
+	url := func() PostmanURL {
+		collection := a.GeneratePostmanCollection()
+		require.Len(t, collection.Item, 1)
+		require.Len(t, collection.Item[0].Item, 1)
+		request := collection.Item[0].Item[0].Request
+		assert.Equal(t, []PostmanHeader{{Key: "X-Tenant", Value: "acme", Type: "text"}}, request.Header)
+		return request.URL
+	}
+
+	// Without any configured server, requests go to the example backend
+	assert.Equal(t, PostmanURL{
+		Raw:      "http://localhost:8080/users?active=true",
+		Protocol: "http",
+		Host:     []string{"localhost"},
+		Port:     "8080",
+		Path:     []string{"users"},
+		Query:    []PostmanQuery{{Key: "active", Value: "true"}},
+	}, url())
+
+	a.SetProxyConfig(9876, "http://backend:3000")
+	got := url()
+	assert.Equal(t, "http://localhost:9876/users?active=true", got.Raw)
+	assert.Equal(t, "9876", got.Port)
+
+	a.SetServerURL("https://api.example.com")
+	got = url()
+	assert.Equal(t, "https://api.example.com/users?active=true", got.Raw)
+	assert.Equal(t, "https", got.Protocol)
+	assert.Equal(t, []string{"api", "example", "com"}, got.Host)
+	assert.Empty(t, got.Port)
+
+	// A configured base URL takes precedence, with its path prepended
+	a.SetPostmanBaseURL("https://staging.example.com:8443/v2/")
+	got = url()
+	assert.Equal(t, "https://staging.example.com:8443/v2/users?active=true", got.Raw)
+	assert.Equal(t, "https", got.Protocol)
+	assert.Equal(t, []string{"staging", "example", "com"}, got.Host)
+	assert.Equal(t, "8443", got.Port)
+	assert.Equal(t, []string{"v2", "users"}, got.Path)
+}
+
+func TestPostmanPath(t *testing.T) {
+	assert.Equal(t, []string{}, postmanPath("/"))
+	assert.Equal(t, []string{"users", "{id}", "orders"}, postmanPath("/users/{id}/orders/"))
+	assert.Equal(t, []string{"users"}, postmanPath("//users"))
+}
+
+func TestPostmanPathVariables(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	a.SetServerURL("https://api.example.com")
+	url := "https://example.com/users/42/orders/7?expand=items&note=a b"
+	req := httptest.NewRequest("GET", "https://example.com/users/42/orders/7?expand=items&note=a+b", nil)
+	a.ProcessRequest("GET", url, req, &http.Response{StatusCode: 200}, nil, []byte(`{"id":7}`))
+
+	collection := a.GeneratePostmanCollection()
+	require.Len(t, collection.Item, 1)
+	require.Len(t, collection.Item[0].Item, 1)
+	got := collection.Item[0].Item[0].Request.URL
+	assert.Equal(t, []string{"users", ":id", "orders", ":id2"}, got.Path)
+	assert.Equal(t, []PostmanVariable{{Key: "id", Value: "42"}, {Key: "id2", Value: "7"}}, got.Variable)
+	assert.Equal(t, "https://api.example.com/users/:id/orders/:id2?expand=items&note=a+b", got.Raw)
+}
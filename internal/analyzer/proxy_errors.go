@@ -0,0 +1,62 @@
+package analyzer
+
+import (
+	"sync"
+	"time"
+)
+
+// maxProxyErrors is the number of recent proxy errors kept for diagnostics
+const maxProxyErrors = 100
+
+// ProxyError describes a request the proxy failed to forward to the backend
+type ProxyError struct {
+	Time   time.Time `json:"time"`
+	Method string    `json:"method"`
+	Path   string    `json:"path"`
+	Error  string    `json:"error"`
+}
+
+// proxyErrorLog is a ring buffer of the most recent proxy errors
+type proxyErrorLog struct {
+	mu      sync.Mutex
+	entries []ProxyError
+	next    int // Index overwritten by the next error once the buffer is full
+}
+
+// add records an error, evicting the oldest one if the buffer is full
+func (l *proxyErrorLog) add(entry ProxyError) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.entries) < maxProxyErrors {
+		l.entries = append(l.entries, entry)
+		return
+	}
+	l.entries[l.next] = entry
+	l.next = (l.next + 1) % maxProxyErrors
+}
+
+// list returns the recorded errors, oldest first
+func (l *proxyErrorLog) list() []ProxyError {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries := make([]ProxyError, 0, len(l.entries))
+	entries = append(entries, l.entries[l.next:]...)
+	return append(entries, l.entries[:l.next]...)
+}
+
+// AddProxyError records a request that could not be forwarded to the backend
+func (a *Analyzer) AddProxyError(method, path string, err error) {
+	a.proxyErrors.add(ProxyError{
+		Time:   time.Now(),
+		Method: method,
+		Path:   path,
+		Error:  err.Error(),
+	})
+}
+
+// GetProxyErrors returns the most recent proxy errors, oldest first
+func (a *Analyzer) GetProxyErrors() []ProxyError {
+	return a.proxyErrors.list()
+}
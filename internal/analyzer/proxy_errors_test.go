@@ -0,0 +1,84 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vulcand/oxy/forward"
+	"github.com/vulcand/oxy/utils"
+)
+
+func TestProxyErrorsFromForwarder(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+
+	// A backend that is no longer listening
+	backend := httptest.NewServer(http.NotFoundHandler())
+	backendURL := backend.URL
+	backend.Close()
+
+	errorHandler := utils.ErrorHandlerFunc(func(w http.ResponseWriter, req *http.Request, err error) {
+		a.AddProxyError(req.Method, req.URL.Path, err)
+		utils.DefaultHandler.ServeHTTP(w, req, err)
+	})
+	fwd, err := forward.New(forward.ErrorHandler(errorHandler))
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("POST", backendURL+"/api/orders", nil)
+	w := httptest.NewRecorder()
+	fwd.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+
+	proxyErrors := a.GetProxyErrors()
+	require.Len(t, proxyErrors, 1)
+	assert.Equal(t, "POST", proxyErrors[0].Method)
+	assert.Equal(t, "/api/orders", proxyErrors[0].Path)
+	assert.Contains(t, proxyErrors[0].Error, "connection refused")
+	assert.False(t, proxyErrors[0].Time.IsZero())
+}
+
+func TestProxyErrorsBounded(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	assert.Empty(t, a.GetProxyErrors())
+
+	for i := 0; i < maxProxyErrors+5; i++ {
+		a.AddProxyError("GET", fmt.Sprintf("/api/items/%d", i), errors.New("dial tcp: connection refused"))
+	}
+
+	// Only the most recent errors are kept, oldest first
+	proxyErrors := a.GetProxyErrors()
+	require.Len(t, proxyErrors, maxProxyErrors)
+	assert.Equal(t, "/api/items/5", proxyErrors[0].Path)
+	assert.Equal(t, fmt.Sprintf("/api/items/%d", maxProxyErrors+4), proxyErrors[maxProxyErrors-1].Path)
+}
+
+func TestHandleErrors(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	server := NewServer(a)
+
+	w := httptest.NewRecorder()
+	server.handleErrors(w, httptest.NewRequest("GET", "/api/errors", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[]`, w.Body.String())
+
+	a.AddProxyError("GET", "/api/users", errors.New("no such host"))
+	w = httptest.NewRecorder()
+	server.handleErrors(w, httptest.NewRequest("GET", "/api/errors", nil))
+	var proxyErrors []ProxyError
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &proxyErrors))
+	require.Len(t, proxyErrors, 1)
+	assert.Equal(t, "/api/users", proxyErrors[0].Path)
+	assert.Equal(t, "no such host", proxyErrors[0].Error)
+
+	w = httptest.NewRecorder()
+	server.handleErrors(w, httptest.NewRequest("POST", "/api/errors", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
@@ -0,0 +1,63 @@
+package analyzer
+
+import (
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+)
+
+const redocTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="utf-8" />
+    <meta name="viewport" content="width=device-width, initial-scale=1" />
+    <meta name="description" content="ReDoc" />
+    <title>ReDoc</title>
+</head>
+<body>
+<redoc spec-url="/api/openapi.json"></redoc>
+<script src="{{.AssetsURL}}/redoc.standalone.js"></script>
+</body>
+</html>`
+
+// redocHandlers returns the handlers of the ReDoc page and of its assets under
+// /redoc/. The page loads the embedded assets, or the pinned version from a
+// CDN if they are missing, which is logged as an error when the server starts
+// as the page then needs network access.
+func (s *Server) redocHandlers() (http.HandlerFunc, http.Handler) {
+	assetsURL := "/redoc"
+	var assets http.Handler
+	if fileSystem, err := getAssetsFileSystem(s.redocFS, "redoc", "redoc.standalone.js"); err != nil {
+		slog.Error("ReDoc assets are not embedded, loading them from a CDN; run make redoc and rebuild", "error", err)
+		assetsURL = "https://unpkg.com/redoc@" + redocVersion + "/bundles"
+		assets = http.NotFoundHandler()
+	} else {
+		assets = http.StripPrefix("/redoc/", http.FileServer(fileSystem))
+	}
+
+	page := func(w http.ResponseWriter, r *http.Request) {
+		s.handleRedoc(w, r, assetsURL)
+	}
+	return page, assets
+}
+
+// handleRedoc serves the ReDoc HTML page loading its assets from assetsURL
+func (s *Server) handleRedoc(w http.ResponseWriter, r *http.Request, assetsURL string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	tmpl, err := template.New("redoc").Parse(redocTemplate)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error creating template: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	if err := tmpl.Execute(w, struct{ AssetsURL string }{assetsURL}); err != nil {
+		http.Error(w, fmt.Sprintf("Error executing template: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,67 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Remote client settings
+const (
+	remoteQueueSize     = 1000             // Records buffered before new ones are dropped
+	remoteBatchSize     = 50               // Records sent in a single ingest request
+	remoteFlushInterval = time.Second      // Longest time a record waits to be sent
+	remoteMaxAttempts   = 5                // Attempts per batch, including the first one
+	remoteTimeout       = 10 * time.Second // Timeout of a single ingest request
+)
+
+// RemoteClient sends captured exchanges in batches to the ingest endpoint of a
+// central analyzer instead of analyzing them in-process
+type RemoteClient struct {
+	poster *batchPoster[IngestRecord]
+}
+
+// NewRemoteClient creates a client sending records to the analyzer at baseURL,
+// authenticating with the given credentials, and starts its sending goroutine
+func NewRemoteClient(baseURL string, auth AuthConfig) *RemoteClient {
+	return newRemoteClient(baseURL, auth, remoteBatchSize, remoteFlushInterval, 500*time.Millisecond, remoteTimeout)
+}
+
+// newRemoteClient creates a client sending batches of batchSize records
+func newRemoteClient(baseURL string, auth AuthConfig, batchSize int, flushInterval, backoff, timeout time.Duration) *RemoteClient {
+	poster := &batchPoster[IngestRecord]{
+		url:           strings.TrimSuffix(baseURL, "/") + "/api/ingest",
+		client:        &http.Client{Timeout: timeout},
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		maxAttempts:   remoteMaxAttempts,
+		backoff:       backoff,
+		encode:        func(batch []IngestRecord) ([]byte, error) { return json.Marshal(batch) },
+		authorize:     auth.setCredentials,
+		receiver:      "analyzer",
+		description:   "captured requests",
+	}
+	return &RemoteClient{poster: poster.start(remoteQueueSize)}
+}
+
+// Add queues a record for sending. The record is dropped if the queue is full,
+// so a slow or unreachable analyzer never blocks proxied traffic.
+func (c *RemoteClient) Add(record IngestRecord) {
+	c.poster.Add(record)
+}
+
+// Close sends the queued records and stops the client
+func (c *RemoteClient) Close() {
+	c.poster.Close()
+}
+
+// Sent returns the number of records accepted by the remote analyzer
+func (c *RemoteClient) Sent() int64 {
+	return c.poster.sent.Load()
+}
+
+// Dropped returns the number of records that could not be delivered
+func (c *RemoteClient) Dropped() int64 {
+	return c.poster.dropped.Load()
+}
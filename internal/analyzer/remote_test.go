@@ -0,0 +1,154 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRemoteClient creates a remote client with short intervals for tests
+func newTestRemoteClient(baseURL string, batchSize int, auth AuthConfig) *RemoteClient {
+	return newRemoteClient(baseURL, auth, batchSize, 10*time.Millisecond, time.Millisecond, time.Second)
+}
+
+// newCapturingProxy creates a proxy to backendURL sending captured requests to remote
+func newCapturingProxy(t *testing.T, backendURL string, remote *RemoteClient) *httptest.Server {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		reqBody, _ := io.ReadAll(req.Body)
+		outReq, err := http.NewRequest(req.Method, backendURL+req.URL.RequestURI(), bytes.NewReader(reqBody))
+		require.NoError(t, err)
+		outReq.Header = req.Header.Clone()
+		resp, err := http.DefaultClient.Do(outReq)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(resp.Body)
+
+		for key, values := range resp.Header {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(resp.StatusCode)
+		w.Write(respBody)
+
+		req.URL.Scheme, req.URL.Host = "http", req.Host
+		remote.Add(NewIngestRecord(req, reqBody, resp, respBody))
+	}))
+	t.Cleanup(proxy.Close)
+	return proxy
+}
+
+func TestRemoteIngestFromTwoProxies(t *testing.T) {
+	central := NewAnalyzer(t.TempDir(), 3600)
+	defer central.Stop()
+	analyzerServer := httptest.NewServer(http.HandlerFunc(NewServer(central).handleIngest))
+	defer analyzerServer.Close()
+
+	users := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":1,"name":"alice"}`))
+	}))
+	defer users.Close()
+	orders := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":7,"total":9.5}`))
+	}))
+	defer orders.Close()
+
+	usersRemote := newTestRemoteClient(analyzerServer.URL, 2, AuthConfig{})
+	ordersRemote := newTestRemoteClient(analyzerServer.URL, 2, AuthConfig{})
+	usersProxy := newCapturingProxy(t, users.URL, usersRemote)
+	ordersProxy := newCapturingProxy(t, orders.URL, ordersRemote)
+
+	for i := 0; i < 3; i++ {
+		resp, err := http.Get(usersProxy.URL + "/api/users/1")
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		resp, err = http.Post(ordersProxy.URL+"/api/orders", "application/json", bytes.NewReader([]byte(`{"sku":"A-1","qty":2}`)))
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+	usersRemote.Close()
+	ordersRemote.Close()
+	assert.Equal(t, int64(3), usersRemote.Sent())
+	assert.Equal(t, int64(3), ordersRemote.Sent())
+
+	// The central analyzer documents the endpoints of both services
+	spec := central.GenerateOpenAPI()
+	require.Contains(t, spec.Paths, "/api/users/{id}")
+	require.Contains(t, spec.Paths, "/api/orders")
+	assert.NotNil(t, spec.Paths["/api/users/{id}"].Get)
+	orderOperation := spec.Paths["/api/orders"].Post
+	require.NotNil(t, orderOperation)
+	assert.Contains(t, orderOperation.Responses, "201")
+	assert.Contains(t, orderOperation.RequestBody.Content["application/json"].Schema.Properties, "sku")
+}
+
+func TestRemoteClientRetries(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		attempts int
+		received []IngestRecord
+	)
+	analyzerServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		if attempts <= 2 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		var batch []IngestRecord
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&batch))
+		received = append(received, batch...)
+	}))
+	defer analyzerServer.Close()
+
+	remote := newTestRemoteClient(analyzerServer.URL, 10, AuthConfig{})
+	remote.Add(IngestRecord{Method: "GET", URL: "http://api.example.com/api/users", Status: 200})
+	remote.Add(IngestRecord{Method: "GET", URL: "http://api.example.com/api/orders", Status: 200})
+	remote.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 3, attempts)
+	require.Len(t, received, 2)
+	assert.Equal(t, "http://api.example.com/api/users", received[0].URL)
+	assert.Equal(t, int64(2), remote.Sent())
+	assert.Equal(t, int64(0), remote.Dropped())
+}
+
+func TestRemoteClientDropsRejectedBatches(t *testing.T) {
+	var attempts int
+	var mu sync.Mutex
+	analyzerServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts++
+		mu.Unlock()
+		http.Error(w, "Invalid record 0: url is required", http.StatusBadRequest)
+	}))
+	defer analyzerServer.Close()
+
+	remote := newTestRemoteClient(analyzerServer.URL, 10, AuthConfig{})
+	remote.Add(IngestRecord{Method: "GET", Status: 200})
+	remote.Close()
+
+	// Rejected records are not retried
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, attempts)
+	assert.Equal(t, int64(0), remote.Sent())
+	assert.Equal(t, int64(1), remote.Dropped())
+}
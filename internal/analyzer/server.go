@@ -1,39 +1,106 @@
 package analyzer
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
-	"log"
+	"fmt"
+	"go/token"
+	"io"
+	"io/fs"
+	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 )
 
 // Server represents the analyzer HTTP server
 type Server struct {
-	analyzer *Analyzer
+	analyzer   *Analyzer
+	uiFS       fs.FS             // Filesystem containing the "ui" directory
+	swaggerFS  fs.FS             // Filesystem containing the "swagger-ui" directory
+	redocFS    fs.FS             // Filesystem containing the "redoc" directory
+	auth       AuthConfig        // Credentials required for the API and the UI
+	snapshots  endpointSnapshots // Endpoint lists being paged through
+	mu         sync.Mutex
+	httpServer *http.Server // Server created by Start, nil before
 }
 
 // NewServer creates a new analyzer server
 func NewServer(analyzer *Analyzer) *Server {
 	return &Server{
-		analyzer: analyzer,
+		analyzer:  analyzer,
+		uiFS:      uiFS,
+		swaggerFS: swaggerUIFS,
+		redocFS:   redocFS,
 	}
 }
 
-// Start starts the analyzer server
+// Start starts the analyzer server and blocks until it fails or is shut down,
+// returning http.ErrServerClosed after Shutdown
 func (s *Server) Start(addr string) error {
+	httpServer := &http.Server{Addr: addr, Handler: s.Handler()}
+	s.mu.Lock()
+	s.httpServer = httpServer
+	s.mu.Unlock()
+
+	slog.Info("Analyzer server listening", "addr", addr)
+	return httpServer.ListenAndServe()
+}
+
+// Shutdown gracefully stops the server started by Start, waiting for active
+// requests until the context is done
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	httpServer := s.httpServer
+	s.mu.Unlock()
+	if httpServer == nil {
+		return nil
+	}
+	return httpServer.Shutdown(ctx)
+}
+
+// Handler returns the handler serving the API and the UI on its own ServeMux,
+// so several servers can run in one process, e.g. with httptest
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
 	// API endpoints
-	http.HandleFunc("/api/health", s.handleHealth)
-	http.HandleFunc("/api/analyzer", s.handleAnalyzer)
-	http.HandleFunc("/api/openapi.json", s.handleOpenAPI)
-	http.HandleFunc("/api/postman.json", s.handlePostman)
-	http.HandleFunc("/api/config", s.handleConfig)
-	http.HandleFunc("/swagger", s.handleSwaggerUI)
+	mux.HandleFunc("/api/health", s.handleHealth)
+	mux.HandleFunc("/api/analyzer", s.handleAnalyzer)
+	mux.HandleFunc("/api/endpoints", s.handleEndpoints)
+	mux.HandleFunc("/api/endpoints/{method}/{path}", s.handleEndpoint)
+	mux.HandleFunc("/api/changes", s.handleChanges)
+	mux.HandleFunc("/api/openapi.json", s.handleOpenAPI)
+	mux.HandleFunc("/api/swagger.json", s.handleSwagger2)
+	mux.HandleFunc("/api/swagger2.json", s.handleSwagger2)
+	mux.HandleFunc("/api/postman.json", s.handlePostman)
+	mux.HandleFunc("/api/insomnia.json", s.handleInsomnia)
+	mux.HandleFunc("/api/types.ts", s.handleTypeScript)
+	mux.HandleFunc("/api/tests/go", s.handleGoTests)
+	mux.HandleFunc("/api/k6.js", s.handleK6)
+	mux.HandleFunc("/api/snippets/curl", s.handleCurlSnippet)
+	mux.HandleFunc("/api/jsonschema", s.handleJSONSchema)
+	mux.HandleFunc("/api/config", s.handleConfig)
+	mux.HandleFunc("/api/save", s.handleSave)
+	mux.HandleFunc("/api/errors", s.handleErrors)
+	mux.HandleFunc("/api/ingest", s.handleIngest)
+	swaggerPage, swaggerAssets := s.swaggerUIHandlers()
+	mux.HandleFunc("/swagger", swaggerPage)
+	mux.Handle("/swagger/", swaggerAssets)
+	redocPage, redocAssets := s.redocHandlers()
+	mux.HandleFunc("/redoc", redocPage)
+	mux.Handle("/redoc/", redocAssets)
 
 	// Handle OPTIONS requests for CORS
-	http.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodOptions {
 			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
 			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 			w.WriteHeader(http.StatusOK)
 			return
@@ -42,8 +109,22 @@ func (s *Server) Start(addr string) error {
 	})
 
 	// Serve static UI files
-	fs := http.FileServer(getUIFileSystem())
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/", s.uiHandler())
+
+	return s.requireAuth(mux)
+}
+
+// uiHandler returns the handler serving the embedded UI, or a minimal index page
+// if the UI assets are missing
+func (s *Server) uiHandler() http.HandlerFunc {
+	uiFileSystem, err := getUIFileSystem(s.uiFS)
+	if err != nil {
+		slog.Warn("Serving fallback index page", "error", err)
+		return handleFallbackIndex
+	}
+
+	fs := http.FileServer(uiFileSystem)
+	return func(w http.ResponseWriter, r *http.Request) {
 		// If the request is for an API endpoint, return 404
 		if strings.HasPrefix(r.URL.Path, "/api/") {
 			http.NotFound(w, r)
@@ -57,13 +138,11 @@ func (s *Server) Start(addr string) error {
 			path = "/index.html"
 		}
 		fs.ServeHTTP(w, r)
-	})
-
-	log.Printf("Analyzer server listening on %s", addr)
-	return http.ListenAndServe(addr, nil)
+	}
 }
 
-// handleAnalyzer handles requests to the analyzer endpoint
+// handleAnalyzer handles requests to the analyzer endpoint. The endpoints can
+// be narrowed down with ?method= and a path prefix given as ?path=.
 func (s *Server) handleAnalyzer(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -76,8 +155,24 @@ func (s *Server) handleAnalyzer(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 
 	data := s.analyzer.GetData()
+	query := r.URL.Query()
+	if method, prefix := query.Get("method"), query.Get("path"); method != "" || prefix != "" {
+		for key, endpoint := range data {
+			if !strings.HasPrefix(endpoint.URL, prefix) || (method != "" && !strings.EqualFold(endpoint.Method, method)) {
+				delete(data, key)
+			}
+		}
+	}
+	for _, endpoint := range data {
+		endpoint.Snippets = map[string]string{"curl": s.analyzer.CurlSnippet(endpoint)}
+	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(data)
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(data); err != nil {
+		http.Error(w, "Error encoding analyzer data", http.StatusInternalServerError)
+		return
+	}
+	writeCacheable(w, r, body.Bytes())
 }
 
 // handleOpenAPI handles requests to the OpenAPI endpoint
@@ -92,9 +187,216 @@ func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
 	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 
-	openAPI := s.analyzer.GenerateOpenAPI()
+	writeSpecification(w, r, s.analyzer.GenerateOpenAPI(), "openapi.json")
+}
+
+// handleSwagger2 handles requests to the Swagger 2.0 endpoint, which is served
+// as both swagger.json and swagger2.json
+func (s *Server) handleSwagger2(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Add CORS headers
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+
+	writeSpecification(w, r, s.analyzer.GenerateSwagger2(), strings.TrimPrefix(r.URL.Path, "/api/"))
+}
+
+// handleJSONSchema handles requests to the JSON Schema endpoint
+func (s *Server) handleJSONSchema(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Add CORS headers
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+
+	writeSpecification(w, r, s.analyzer.GenerateJSONSchemas(), "jsonschema.json")
+}
+
+// writeSpecification writes a generated specification as JSON, as an attachment
+// with the given file name when requested with ?download=1
+func writeSpecification(w http.ResponseWriter, r *http.Request, specification interface{}, filename string) {
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(openAPI)
+	if isTruthyQuery(r, "download") {
+		w.Header().Set("Content-Disposition", "attachment; filename="+filename)
+	}
+
+	// Compact output by default, indented when requested with ?pretty=1
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	if isTruthyQuery(r, "pretty") {
+		encoder.SetIndent("", "  ")
+	}
+	if err := encoder.Encode(specification); err != nil {
+		http.Error(w, "Error encoding specification", http.StatusInternalServerError)
+		return
+	}
+	writeCacheable(w, r, body.Bytes())
+}
+
+// writeCacheable writes a response body with an ETag computed from its
+// content, answering 304 Not Modified if the client already has it, e.g. when
+// a documentation portal polls an unchanged specification. The body is gzip
+// compressed for clients accepting it. The ETag is weak since it is the same
+// for the compressed and uncompressed body.
+func writeCacheable(w http.ResponseWriter, r *http.Request, body []byte) {
+	sum := sha256.Sum256(body)
+	etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Add("Vary", "Accept-Encoding")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if !acceptsGzip(r) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.Write(body)
+		return
+	}
+	w.Header().Set("Content-Encoding", "gzip")
+	gz := gzip.NewWriter(w)
+	gz.Write(body)
+	gz.Close()
+}
+
+// etagMatches checks if an If-None-Match header lists an ETag, comparing them
+// weakly as required for If-None-Match
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptsGzip checks if the Accept-Encoding header of a request allows gzip
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(encoding, ";")
+		if !strings.EqualFold(strings.TrimSpace(name), "gzip") {
+			continue
+		}
+		// gzip;q=0 explicitly refuses gzip
+		_, quality, found := strings.Cut(params, "=")
+		if !found {
+			return true
+		}
+		q, err := strconv.ParseFloat(strings.TrimSpace(quality), 64)
+		return err != nil || q > 0
+	}
+	return false
+}
+
+// isTruthyQuery checks if a query parameter is set to a true value such as 1 or true
+func isTruthyQuery(r *http.Request, name string) bool {
+	enabled, err := strconv.ParseBool(r.URL.Query().Get(name))
+	return err == nil && enabled
+}
+
+// handleTypeScript handles requests to the TypeScript types endpoint. Request
+// body types are left out with ?responses_only=1, and strings with an enum are
+// unions of their literals with ?enums=1.
+func (s *Server) handleTypeScript(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Add CORS headers
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+
+	w.Header().Set("Content-Type", "application/typescript")
+	if isTruthyQuery(r, "download") {
+		w.Header().Set("Content-Disposition", "attachment; filename=types.ts")
+	}
+	w.Write([]byte(s.analyzer.GenerateTypeScript(isTruthyQuery(r, "responses_only"), isTruthyQuery(r, "enums"))))
+}
+
+// handleGoTests handles requests to the generated Go contract tests. The
+// package name and base URL of the tests can be set with ?package= and
+// ?base_url=.
+func (s *Server) handleGoTests(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Add CORS headers
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+
+	packageName := r.URL.Query().Get("package")
+	if packageName != "" && !token.IsIdentifier(packageName) {
+		http.Error(w, fmt.Sprintf("Invalid package %q, expected a Go identifier", packageName), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/x-go; charset=utf-8")
+	if isTruthyQuery(r, "download") {
+		w.Header().Set("Content-Disposition", "attachment; filename=contract_test.go")
+	}
+	w.Write([]byte(s.analyzer.GenerateGoTests(packageName, r.URL.Query().Get("base_url"))))
+}
+
+// handleK6 handles requests to the k6 load test script endpoint
+func (s *Server) handleK6(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Add CORS headers
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+
+	w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+	if isTruthyQuery(r, "download") {
+		w.Header().Set("Content-Disposition", "attachment; filename=k6.js")
+	}
+	w.Write([]byte(s.analyzer.GenerateK6Script()))
+}
+
+// handleCurlSnippet handles requests to the curl command of an endpoint,
+// identified by ?endpoint= as its method and normalized path, e.g. POST /users
+func (s *Server) handleCurlSnippet(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Add CORS headers
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+
+	method, path, found := strings.Cut(r.URL.Query().Get("endpoint"), " ")
+	if !found || method == "" || path == "" {
+		http.Error(w, "Missing endpoint, expected ?endpoint=METHOD%20/path", http.StatusBadRequest)
+		return
+	}
+	endpoint := s.analyzer.GetEndpoint(strings.ToUpper(method), path)
+	if endpoint == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, s.analyzer.CurlSnippet(endpoint))
 }
 
 // handlePostman handles requests to the Postman collection endpoint
@@ -112,7 +414,35 @@ func (s *Server) handlePostman(w http.ResponseWriter, r *http.Request) {
 	collection := s.analyzer.GeneratePostmanCollection()
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Content-Disposition", "attachment; filename=api-collection.json")
-	json.NewEncoder(w).Encode(collection)
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(collection); err != nil {
+		http.Error(w, "Error encoding collection", http.StatusInternalServerError)
+		return
+	}
+	writeCacheable(w, r, body.Bytes())
+}
+
+// handleInsomnia handles requests to the Insomnia export endpoint
+func (s *Server) handleInsomnia(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Add CORS headers
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+
+	export := s.analyzer.GenerateInsomniaExport()
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", "attachment; filename=insomnia.json")
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(export); err != nil {
+		http.Error(w, "Error encoding export", http.StatusInternalServerError)
+		return
+	}
+	writeCacheable(w, r, body.Bytes())
 }
 
 // handleHealth handles requests to the health check endpoint
@@ -128,7 +458,125 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"status": "healthy"})
+	health, enabled := s.analyzer.CheckBackendHealth()
+	if !enabled {
+		json.NewEncoder(w).Encode(map[string]string{"status": "healthy"})
+		return
+	}
+
+	status := "healthy"
+	if !health.Reachable {
+		status = "degraded"
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  status,
+		"backend": health,
+	})
+}
+
+// handleSave handles requests to immediately save the analyzer state
+func (s *Server) handleSave(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Add CORS headers
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+
+	if err := s.analyzer.Save(); err != nil {
+		slog.Error("Failed to save state", "error", err)
+		http.Error(w, "Error saving state", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "saved"})
+}
+
+// handleErrors handles requests to the proxy errors endpoint
+func (s *Server) handleErrors(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Add CORS headers
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.analyzer.GetProxyErrors())
+}
+
+// maxIngestBodySize is the largest request body accepted by the ingest endpoint
+const maxIngestBodySize = 32 << 20
+
+// handleIngest handles records captured by remote proxies. The body is a
+// single record or an array of records.
+func (s *Server) handleIngest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Add CORS headers
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIngestBodySize))
+	if err != nil {
+		http.Error(w, "Error reading request body", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	var records []IngestRecord
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &records)
+	} else {
+		var record IngestRecord
+		err = json.Unmarshal(trimmed, &record)
+		records = append(records, record)
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid record: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// Validate all records first so a batch is either ingested or rejected as a whole
+	for i := range records {
+		if err := records[i].Validate(); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid record %d: %v", i, err), http.StatusBadRequest)
+			return
+		}
+	}
+	for _, record := range records {
+		if err := s.analyzer.Ingest(record); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid record: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "ingested", "count": len(records)})
+}
+
+// documentationLinks lists the generated documentation served by the analyzer
+var documentationLinks = map[string]string{
+	"openapi":    "/api/openapi.json",
+	"swagger2":   "/api/swagger2.json",
+	"postman":    "/api/postman.json",
+	"insomnia":   "/api/insomnia.json",
+	"typescript": "/api/types.ts",
+	"jsonSchema": "/api/jsonschema",
+	"goTests":    "/api/tests/go",
+	"k6":         "/api/k6.js",
+	"swaggerUI":  "/swagger",
+	"redoc":      "/redoc",
 }
 
 // handleConfig handles requests to the config endpoint
@@ -153,11 +601,14 @@ func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
 			"port":       s.analyzer.GetProxyPort(),
 			"backendURL": s.analyzer.GetBackendURL(),
 		},
+		"docs":          documentationLinks,
+		"schemaVersion": SchemaVersion,
+		"build":         s.analyzer.GetBuildInfo(),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(config); err != nil {
-		log.Printf("Error encoding config: %v", err)
+		slog.Error("Failed to encode config", "error", err)
 		http.Error(w, "Error encoding config", http.StatusInternalServerError)
 		return
 	}
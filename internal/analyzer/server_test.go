@@ -0,0 +1,466 @@
+package analyzer
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetUIFileSystem(t *testing.T) {
+	// Embedded UI assets are available
+	_, err := getUIFileSystem(uiFS)
+	assert.NoError(t, err)
+
+	// Missing ui directory
+	_, err = getUIFileSystem(fstest.MapFS{})
+	assert.Error(t, err)
+
+	// ui directory without index.html
+	_, err = getUIFileSystem(fstest.MapFS{"ui/static/app.js": &fstest.MapFile{Data: []byte("")}})
+	assert.Error(t, err)
+}
+
+func TestServerStartWithMissingUI(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	s := NewServer(a)
+	s.uiFS = fstest.MapFS{}
+
+	// Start must not panic; an invalid address makes it return after wiring the handlers
+	assert.NotPanics(t, func() {
+		err := s.Start("invalid-address")
+		assert.Error(t, err)
+	})
+
+	// The fallback index page links to the generated documentation
+	handler := s.Handler()
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "text/html"))
+	assert.Contains(t, w.Body.String(), `href="/api/openapi.json"`)
+	assert.Contains(t, w.Body.String(), `href="/swagger"`)
+
+	// Unknown paths are not found
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/static/missing.js", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestSwaggerUIAssets(t *testing.T) {
+	serve := func(s *Server, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	// Embedded assets are served locally, so the page works without network access
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	s := NewServer(a)
+	s.swaggerFS = fstest.MapFS{
+		"swagger-ui/swagger-ui.css":       &fstest.MapFile{Data: []byte(".swagger-ui {}")},
+		"swagger-ui/swagger-ui-bundle.js": &fstest.MapFile{Data: []byte("var SwaggerUIBundle;")},
+	}
+	w := serve(s, "/swagger")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `href="/swagger/swagger-ui.css"`)
+	assert.Contains(t, w.Body.String(), `src="/swagger/swagger-ui-bundle.js"`)
+	assert.Contains(t, w.Body.String(), `url: "/api/openapi.json"`)
+	assert.NotContains(t, w.Body.String(), "unpkg.com")
+
+	w = serve(s, "/swagger/swagger-ui-bundle.js")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "var SwaggerUIBundle;", w.Body.String())
+	assert.Equal(t, http.StatusNotFound, serve(s, "/swagger/missing.js").Code)
+
+	// Without the assets the page is an error instead of loading them from a CDN
+	s = NewServer(a)
+	s.swaggerFS = fstest.MapFS{"swagger-ui/README.md": &fstest.MapFile{Data: []byte("")}}
+	w = serve(s, "/swagger")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "swagger-ui.css")
+	assert.NotContains(t, w.Body.String(), "unpkg.com")
+	assert.Equal(t, http.StatusNotFound, serve(s, "/swagger/swagger-ui-bundle.js").Code)
+}
+
+func TestSwaggerUIEmbeddedAssets(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	handler := NewServer(a).Handler()
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	w := get("/swagger")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `src="/swagger/swagger-ui-bundle.js"`)
+	assert.NotContains(t, w.Body.String(), "unpkg.com")
+
+	w = get("/swagger/swagger-ui-bundle.js")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "SwaggerUIBundle")
+	assert.Contains(t, w.Body.String(), `PACKAGE_VERSION:"`+swaggerUIVersion+`"`)
+	w = get("/swagger/swagger-ui.css")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "text/css"), w.Header().Get("Content-Type"))
+	assert.Contains(t, get("/swagger/LICENSE").Body.String(), "Apache License")
+}
+
+func TestRedoc(t *testing.T) {
+	serve := func(s *Server, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	s := NewServer(a)
+	s.redocFS = fstest.MapFS{"redoc/redoc.standalone.js": &fstest.MapFile{Data: []byte("var Redoc;")}}
+	w := serve(s, "/redoc")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "text/html"))
+	assert.Contains(t, w.Body.String(), `<redoc spec-url="/api/openapi.json"></redoc>`)
+	assert.Contains(t, w.Body.String(), `src="/redoc/redoc.standalone.js"`)
+
+	w = serve(s, "/redoc/redoc.standalone.js")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "text/javascript"), w.Header().Get("Content-Type"))
+	assert.Equal(t, "var Redoc;", w.Body.String())
+
+	// Without the assets the page loads the pinned version from a CDN
+	s.redocFS = fstest.MapFS{}
+	w = serve(s, "/redoc")
+	assert.Contains(t, w.Body.String(), `src="https://unpkg.com/redoc@`+redocVersion+`/bundles/redoc.standalone.js"`)
+
+	// The page is protected by the analyzer authentication
+	s.SetAuth(AuthConfig{Token: "s3cret"})
+	assert.Equal(t, http.StatusUnauthorized, serve(s, "/redoc").Code)
+
+	// The configuration links to the documentation pages
+	s = NewServer(a)
+	w = serve(s, "/api/config")
+	var config struct {
+		Docs map[string]string `json:"docs"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &config))
+	assert.Equal(t, "/redoc", config.Docs["redoc"])
+	assert.Equal(t, "/swagger", config.Docs["swaggerUI"])
+}
+
+func TestRedocEmbeddedAssets(t *testing.T) {
+	if _, err := fs.Stat(redocFS, "redoc/redoc.standalone.js"); err != nil {
+		t.Skip("redoc.standalone.js is not vendored, run make redoc")
+	}
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	handler := NewServer(a).Handler()
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	w := get("/redoc")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `src="/redoc/redoc.standalone.js"`)
+	assert.NotContains(t, w.Body.String(), "unpkg.com")
+
+	w = get("/redoc/redoc.standalone.js")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Redoc")
+	assert.Equal(t, http.StatusOK, get("/redoc/LICENSE").Code)
+}
+
+func TestConfigBuildInfo(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	a.SetBuildInfo(BuildInfo{Version: "1.4.0", Commit: "abc1234", Date: "2026-10-01T12:00:00Z"})
+	w := httptest.NewRecorder()
+	NewServer(a).Handler().ServeHTTP(w, httptest.NewRequest("GET", "/api/config", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var config struct {
+		SchemaVersion string    `json:"schemaVersion"`
+		Build         BuildInfo `json:"build"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &config))
+	assert.Equal(t, SchemaVersion, config.SchemaVersion)
+	assert.Equal(t, BuildInfo{Version: "1.4.0", Commit: "abc1234", Date: "2026-10-01T12:00:00Z"}, config.Build)
+}
+
+func TestServerStartAndShutdown(t *testing.T) {
+	// Two servers with their own analyzers run in the same process
+	a1 := NewAnalyzer(t.TempDir(), 3600)
+	defer a1.Stop()
+	a2 := NewAnalyzer(t.TempDir(), 3600)
+	defer a2.Stop()
+	req := httptest.NewRequest("GET", "https://example.com/api/users", nil)
+	a1.ProcessRequest("GET", "https://example.com/api/users", req, &http.Response{StatusCode: 200}, nil, []byte(`{"id":1}`))
+
+	ts := httptest.NewServer(NewServer(a2).Handler())
+	defer ts.Close()
+
+	s := NewServer(a1)
+	addr := freeAddr(t)
+	errc := make(chan error, 1)
+	go func() { errc <- s.Start(addr) }()
+
+	var resp *http.Response
+	require.Eventually(t, func() bool {
+		var err error
+		resp, err = http.Get("http://" + addr + "/api/analyzer")
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	var data map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&data))
+	resp.Body.Close()
+	assert.Contains(t, data, "GET /api/users")
+
+	resp, err := http.Get(ts.URL + "/api/analyzer")
+	require.NoError(t, err)
+	data = nil
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&data))
+	resp.Body.Close()
+	assert.Empty(t, data)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, s.Shutdown(ctx))
+	assert.ErrorIs(t, <-errc, http.ErrServerClosed)
+	_, err = http.Get("http://" + addr + "/api/analyzer")
+	assert.Error(t, err)
+
+	// Shutting down a server that was never started is a no-op
+	assert.NoError(t, NewServer(a2).Shutdown(ctx))
+}
+
+// freeAddr returns a local address with a free port
+func freeAddr(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+func TestHandleOpenAPIQueryFlags(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	req := httptest.NewRequest("GET", "https://example.com/api/users", nil)
+	a.ProcessRequest("GET", "https://example.com/api/users", req, &http.Response{StatusCode: 200}, nil, []byte(`{"id":1}`))
+	s := NewServer(a)
+
+	// Default is compact inline JSON
+	w := httptest.NewRecorder()
+	s.handleOpenAPI(w, httptest.NewRequest("GET", "/api/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Empty(t, w.Header().Get("Content-Disposition"))
+	assert.Equal(t, 1, strings.Count(w.Body.String(), "\n"))
+	assert.True(t, json.Valid(w.Body.Bytes()))
+
+	// Download sets an attachment filename
+	w = httptest.NewRecorder()
+	s.handleOpenAPI(w, httptest.NewRequest("GET", "/api/openapi.json?download=1", nil))
+	assert.Equal(t, "attachment; filename=openapi.json", w.Header().Get("Content-Disposition"))
+	assert.Equal(t, 1, strings.Count(w.Body.String(), "\n"))
+
+	// Pretty output is indented
+	w = httptest.NewRecorder()
+	s.handleOpenAPI(w, httptest.NewRequest("GET", "/api/openapi.json?pretty=1", nil))
+	assert.Empty(t, w.Header().Get("Content-Disposition"))
+	assert.Contains(t, w.Body.String(), "\n  \"openapi\": \"3.0.0\"")
+	assert.True(t, json.Valid(w.Body.Bytes()))
+
+	// Both flags combined
+	w = httptest.NewRecorder()
+	s.handleOpenAPI(w, httptest.NewRequest("GET", "/api/openapi.json?download=true&pretty=true", nil))
+	assert.Equal(t, "attachment; filename=openapi.json", w.Header().Get("Content-Disposition"))
+	assert.Contains(t, w.Body.String(), "\n  \"openapi\": \"3.0.0\"")
+}
+
+func TestHandleAnalyzerFilters(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	for _, request := range []struct{ method, url string }{
+		{"GET", "https://example.com/users"},
+		{"POST", "https://example.com/users"},
+		{"GET", "https://example.com/users/1"},
+		{"POST", "https://example.com/orders"},
+	} {
+		req := httptest.NewRequest(request.method, request.url, nil)
+		a.ProcessRequest(request.method, request.url, req, &http.Response{StatusCode: 200}, nil, []byte(`{"id":1}`))
+	}
+	s := NewServer(a)
+
+	keys := func(path string) []string {
+		w := httptest.NewRecorder()
+		s.handleAnalyzer(w, httptest.NewRequest("GET", path, nil))
+		require.Equal(t, http.StatusOK, w.Code, path)
+		var data map[string]json.RawMessage
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &data), path)
+		return sortedKeys(data)
+	}
+
+	assert.Equal(t, []string{"GET /users", "GET /users/{id}", "POST /orders", "POST /users"}, keys("/api/analyzer"))
+	assert.Equal(t, []string{"POST /orders", "POST /users"}, keys("/api/analyzer?method=POST"))
+	assert.Equal(t, []string{"GET /users", "GET /users/{id}", "POST /users"}, keys("/api/analyzer?path=/users"))
+	assert.Equal(t, []string{"POST /users"}, keys("/api/analyzer?method=post&path=/users"))
+	assert.Empty(t, keys("/api/analyzer?method=DELETE"))
+}
+
+func TestConditionalGet(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	process := func(url string) {
+		req := httptest.NewRequest("GET", url, nil)
+		a.ProcessRequest("GET", url, req, &http.Response{StatusCode: 200}, nil, []byte(`{"id":1}`))
+	}
+	process("https://example.com/api/users")
+	handler := NewServer(a).Handler()
+	get := func(path string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, path := range []string{"/api/openapi.json", "/api/postman.json", "/api/analyzer"} {
+		w := get(path, nil)
+		require.Equal(t, http.StatusOK, w.Code, path)
+		etag := w.Header().Get("ETag")
+		require.NotEmpty(t, etag, path)
+		assert.Equal(t, etag, get(path, nil).Header().Get("ETag"), "%s: same ETag for the same state", path)
+
+		// Matching ETag
+		w = get(path, map[string]string{"If-None-Match": etag})
+		assert.Equal(t, http.StatusNotModified, w.Code, path)
+		assert.Empty(t, w.Body.String(), path)
+		assert.Equal(t, http.StatusNotModified, get(path, map[string]string{"If-None-Match": `"other", ` + etag}).Code, path)
+
+		// Mismatching ETag
+		w = get(path, map[string]string{"If-None-Match": `W/"0123456789abcdef"`})
+		assert.Equal(t, http.StatusOK, w.Code, path)
+		assert.True(t, json.Valid(w.Body.Bytes()), path)
+	}
+
+	// A state change invalidates the ETag
+	etag := get("/api/openapi.json", nil).Header().Get("ETag")
+	process("https://example.com/api/orders")
+	w := get("/api/openapi.json", map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+	assert.Contains(t, w.Body.String(), "/api/orders")
+
+	// Responses are compressed for clients accepting gzip
+	w = get("/api/openapi.json", map[string]string{"Accept-Encoding": "br, gzip"})
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+	reader, err := gzip.NewReader(w.Body)
+	require.NoError(t, err)
+	body, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, get("/api/openapi.json", nil).Body.String(), string(body))
+
+	w = get("/api/openapi.json", map[string]string{"Accept-Encoding": "gzip;q=0"})
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.True(t, json.Valid(w.Body.Bytes()))
+}
+
+func TestHandleSave(t *testing.T) {
+	tmpDir := t.TempDir()
+	a := NewAnalyzer(tmpDir, 3600)
+	defer a.Stop()
+	s := NewServer(a)
+
+	w := httptest.NewRecorder()
+	s.handleSave(w, httptest.NewRequest("GET", "/api/save", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	// A forced save writes immediately, even when nothing changed
+	w = httptest.NewRecorder()
+	s.handleSave(w, httptest.NewRequest("POST", "/api/save", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"saved"}`, w.Body.String())
+	assert.FileExists(t, filepath.Join(tmpDir, "analyzer.json"))
+
+	// Write errors are reported
+	a = NewAnalyzer(filepath.Join(tmpDir, "missing"), 3600)
+	defer a.Stop()
+	w = httptest.NewRecorder()
+	NewServer(a).handleSave(w, httptest.NewRequest("POST", "/api/save", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestHandleHealth(t *testing.T) {
+	decode := func(s *Server) map[string]interface{} {
+		w := httptest.NewRecorder()
+		s.handleHealth(w, httptest.NewRequest("GET", "/api/health", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		var body map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body
+	}
+
+	// Without a backend health check the analyzer is always healthy
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	a.SetProxyConfig(9876, "http://127.0.0.1:1")
+	assert.Equal(t, map[string]interface{}{"status": "healthy"}, decode(NewServer(a)))
+
+	// Unreachable backend
+	a.SetBackendHealthCheck("/health")
+	body := decode(NewServer(a))
+	assert.Equal(t, "degraded", body["status"])
+	backend := body["backend"].(map[string]interface{})
+	assert.Equal(t, false, backend["reachable"])
+	assert.Equal(t, "http://127.0.0.1:1/health", backend["url"])
+	assert.NotEmpty(t, backend["error"])
+	assert.NotContains(t, backend, "lastProxiedAt")
+
+	// Reachable backend
+	var probes int
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		probes++
+		assert.Equal(t, http.MethodHead, r.Method)
+		assert.Equal(t, "/health", r.URL.Path)
+	}))
+	defer backendServer.Close()
+
+	a = NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	a.SetProxyConfig(9876, backendServer.URL)
+	a.SetBackendHealthCheck("health")
+	a.RecordProxied(http.StatusBadGateway)
+	assert.NotContains(t, decode(NewServer(a))["backend"], "lastProxiedAt", "gateway errors don't reach the backend")
+	a.RecordProxied(http.StatusOK)
+
+	body = decode(NewServer(a))
+	assert.Equal(t, "healthy", body["status"])
+	backend = body["backend"].(map[string]interface{})
+	assert.Equal(t, true, backend["reachable"])
+	assert.Contains(t, backend, "lastProxiedAt")
+
+	// Results are cached between health checks
+	decode(NewServer(a))
+	assert.Equal(t, 1, probes)
+}
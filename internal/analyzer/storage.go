@@ -0,0 +1,132 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// Storage types for persisting the analyzer state
+const (
+	StorageTypeFile   = "file"   // A single analyzer.json file
+	StorageTypeSQLite = "sqlite" // A SQLite database
+	StorageTypeS3     = "s3"     // An analyzer.json object in S3 compatible object storage
+)
+
+// StorageOptions configures the state store opened by OpenStateStore
+type StorageOptions struct {
+	Type string   // One of the storage types, defaults to file
+	Path string   // Directory holding analyzer.json, or the SQLite database file
+	S3   S3Config // Object storage settings for the s3 type
+}
+
+// StateStore persists the analyzer endpoints between runs
+type StateStore interface {
+	// Load returns the saved endpoints, or nil if there is no usable saved state
+	Load() (map[string]*EndpointData, error)
+	// Save persists the endpoints. changed holds the keys of the endpoints
+	// modified since the previous save, which a store may use to only write
+	// those. It returns the size of the persisted state in bytes.
+	Save(endpoints map[string]*EndpointData, changed []string) (int64, error)
+	// Location returns the configured storage path
+	Location() string
+	// Close releases the resources held by the store
+	Close() error
+}
+
+// OpenStateStore opens the state store configured by options
+func OpenStateStore(options StorageOptions) (StateStore, error) {
+	switch options.Type {
+	case "", StorageTypeFile:
+		return NewFileStateStore(options.Path), nil
+	case StorageTypeSQLite:
+		return NewSQLiteStateStore(options.Path)
+	case StorageTypeS3:
+		return NewS3StateStore(context.Background(), options.S3)
+	default:
+		return nil, fmt.Errorf("unknown storage type %q", options.Type)
+	}
+}
+
+// FileStateStore stores the analyzer state in an analyzer.json file
+type FileStateStore struct {
+	dir  string // Directory holding analyzer.json
+	path string // Path of analyzer.json
+}
+
+// NewFileStateStore creates a store saving analyzer.json in the given directory
+func NewFileStateStore(dir string) *FileStateStore {
+	if dir == "" {
+		dir = "."
+	}
+	return &FileStateStore{dir: dir, path: filepath.Join(dir, "analyzer.json")}
+}
+
+// Load loads the endpoints from analyzer.json if it exists and version matches
+func (s *FileStateStore) Load() (map[string]*EndpointData, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			slog.Info("No saved state found", "location", s.path)
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return decodeState(data, s.path)
+}
+
+// Save writes all endpoints to analyzer.json
+func (s *FileStateStore) Save(endpoints map[string]*EndpointData, changed []string) (int64, error) {
+	jsonData, err := encodeState(endpoints)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.WriteFile(s.path, jsonData, 0644); err != nil {
+		return 0, fmt.Errorf("failed to write state: %w", err)
+	}
+	return int64(len(jsonData)), nil
+}
+
+// Location returns the directory holding analyzer.json
+func (s *FileStateStore) Location() string {
+	return s.dir
+}
+
+// Close does nothing for the file store
+func (s *FileStateStore) Close() error {
+	return nil
+}
+
+// encodeState encodes the endpoints as analyzer.json
+func encodeState(endpoints map[string]*EndpointData) ([]byte, error) {
+	state := PersistedState{
+		Version:   SchemaVersion,
+		Endpoints: endpoints,
+	}
+
+	jsonData, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode state: %w", err)
+	}
+	return jsonData, nil
+}
+
+// decodeState decodes the endpoints from analyzer.json read from source,
+// returning nil if the state was saved by a different version
+func decodeState(data []byte, source string) (map[string]*EndpointData, error) {
+	var state PersistedState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", source, err)
+	}
+
+	// Only load if version matches
+	if state.Version != SchemaVersion {
+		slog.Info("Saved state version mismatch", "found", state.Version, "expected", SchemaVersion)
+		return nil, nil
+	}
+	return state.Endpoints, nil
+}
@@ -0,0 +1,141 @@
+package analyzer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3 request settings
+const (
+	s3MaxAttempts    = 5                // Attempts per request, including the first one
+	s3RequestTimeout = 30 * time.Second // Timeout of a request including its retries
+)
+
+// s3MaxBackoff is the longest delay between retries of a failed request
+var s3MaxBackoff = 20 * time.Second
+
+// S3Config configures the S3 state store. Credentials are taken from the
+// standard AWS environment variables, shared config files or the instance role.
+type S3Config struct {
+	Bucket   string // Bucket holding the state
+	Prefix   string // Key prefix of the state object, e.g. "docurift/"
+	Region   string // Bucket region, defaults to the AWS environment configuration
+	Endpoint string // Endpoint of S3 compatible storage such as MinIO, uses path-style addressing
+}
+
+// S3StateStore stores the analyzer state as an analyzer.json object in S3
+type S3StateStore struct {
+	client *s3.Client
+	bucket string
+	key    string
+}
+
+// NewS3StateStore creates a store saving analyzer.json in the configured bucket
+func NewS3StateStore(ctx context.Context, cfg S3Config) (*S3StateStore, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("s3 storage requires a bucket")
+	}
+
+	options := []func(*awsconfig.LoadOptions) error{
+		awsconfig.WithRetryer(func() aws.Retryer {
+			return retry.NewStandard(func(o *retry.StandardOptions) {
+				o.MaxAttempts = s3MaxAttempts
+				o.MaxBackoff = s3MaxBackoff
+			})
+		}),
+	}
+	if cfg.Region != "" {
+		options = append(options, awsconfig.WithRegion(cfg.Region))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+			o.UsePathStyle = true
+			// S3 compatible stores don't all support the default checksums
+			o.RequestChecksumCalculation = aws.RequestChecksumCalculationWhenRequired
+			o.ResponseChecksumValidation = aws.ResponseChecksumValidationWhenRequired
+		}
+	})
+
+	return &S3StateStore{
+		client: client,
+		bucket: cfg.Bucket,
+		key:    cfg.Prefix + "analyzer.json",
+	}, nil
+}
+
+// Load loads the endpoints from the analyzer.json object if it exists and version matches
+func (s *S3StateStore) Load() (map[string]*EndpointData, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s3RequestTimeout)
+	defer cancel()
+
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			slog.Info("No saved state found", "location", s.Location())
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get %s: %w", s.Location(), err)
+	}
+	defer out.Body.Close()
+
+	data, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", s.Location(), err)
+	}
+	return decodeState(data, s.Location())
+}
+
+// Save writes all endpoints to the analyzer.json object
+func (s *S3StateStore) Save(endpoints map[string]*EndpointData, changed []string) (int64, error) {
+	jsonData, err := encodeState(endpoints)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s3RequestTimeout)
+	defer cancel()
+
+	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(s.key),
+		Body:          bytes.NewReader(jsonData),
+		ContentLength: aws.Int64(int64(len(jsonData))),
+		ContentType:   aws.String("application/json"),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to put %s: %w", s.Location(), err)
+	}
+	return int64(len(jsonData)), nil
+}
+
+// Location returns the S3 URI of the analyzer.json object
+func (s *S3StateStore) Location() string {
+	return "s3://" + s.bucket + "/" + strings.TrimPrefix(s.key, "/")
+}
+
+// Close does nothing for the S3 store
+func (s *S3StateStore) Close() error {
+	return nil
+}
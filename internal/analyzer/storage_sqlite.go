@@ -0,0 +1,624 @@
+package analyzer
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite" // Registers the "sqlite" database/sql driver
+)
+
+// sqliteSchema creates the tables of the SQLite state store. Schema stores are
+// identified by their name and, for response stores, the status code (0 for
+// request stores).
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS meta (
+	key   TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS endpoints (
+	key                  TEXT PRIMARY KEY,
+	method               TEXT NOT NULL,
+	url                  TEXT NOT NULL,
+	request_content_type TEXT NOT NULL DEFAULT '',
+	request_count        INTEGER NOT NULL DEFAULT 0,
+	last_seen            INTEGER NOT NULL DEFAULT 0,
+	protocols            TEXT NOT NULL DEFAULT '',
+	tls                  INTEGER NOT NULL DEFAULT 0
+);
+CREATE TABLE IF NOT EXISTS responses (
+	endpoint_key TEXT NOT NULL,
+	status       INTEGER NOT NULL,
+	content_type TEXT NOT NULL DEFAULT '',
+	count        INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (endpoint_key, status)
+);
+CREATE TABLE IF NOT EXISTS paths (
+	endpoint_key TEXT NOT NULL,
+	store        TEXT NOT NULL,
+	status       INTEGER NOT NULL,
+	path         TEXT NOT NULL,
+	optional     INTEGER NOT NULL,
+	nullable     INTEGER NOT NULL DEFAULT 0,
+	repeated     INTEGER NOT NULL DEFAULT 0,
+	presence     INTEGER NOT NULL DEFAULT 0,
+	seen         INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (endpoint_key, store, status, path)
+);
+CREATE TABLE IF NOT EXISTS stores (
+	endpoint_key TEXT NOT NULL,
+	store        TEXT NOT NULL,
+	status       INTEGER NOT NULL,
+	observations INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (endpoint_key, store, status)
+);
+CREATE TABLE IF NOT EXISTS arrays (
+	endpoint_key TEXT NOT NULL,
+	store        TEXT NOT NULL,
+	status       INTEGER NOT NULL,
+	path         TEXT NOT NULL,
+	elements     INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (endpoint_key, store, status, path)
+);
+CREATE TABLE IF NOT EXISTS truncations (
+	endpoint_key TEXT NOT NULL,
+	store        TEXT NOT NULL,
+	status       INTEGER NOT NULL,
+	path         TEXT NOT NULL,
+	PRIMARY KEY (endpoint_key, store, status, path)
+);
+CREATE TABLE IF NOT EXISTS examples (
+	endpoint_key TEXT NOT NULL,
+	store        TEXT NOT NULL,
+	status       INTEGER NOT NULL,
+	path         TEXT NOT NULL,
+	position     INTEGER NOT NULL,
+	value        TEXT NOT NULL,
+	PRIMARY KEY (endpoint_key, store, status, path, position)
+);
+CREATE TABLE IF NOT EXISTS changes (
+	endpoint_key TEXT NOT NULL,
+	position     INTEGER NOT NULL,
+	time         INTEGER NOT NULL,
+	kind         TEXT NOT NULL,
+	location     TEXT NOT NULL DEFAULT '',
+	field        TEXT NOT NULL DEFAULT '',
+	detail       TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY (endpoint_key, position)
+);
+`
+
+// Names of the schema stores in the SQLite state store
+const (
+	sqliteRequestHeaders  = "request_headers"
+	sqliteRequestPayload  = "request_payload"
+	sqliteURLParameters   = "url_parameters"
+	sqlitePathParams      = "path_params"
+	sqliteCookies         = "cookies"
+	sqliteTracing         = "tracing"
+	sqliteResponseHeaders = "response_headers"
+	sqliteResponsePayload = "response_payload"
+	sqliteSetCookies      = "set_cookies"
+
+	// sqliteRequestBodyPrefix is followed by the media type in the names of
+	// the stores of request bodies other than JSON
+	sqliteRequestBodyPrefix = "request_body:"
+)
+
+// SQLiteStateStore stores the analyzer state in a SQLite database with tables
+// for endpoints, schema paths and examples, so only changed endpoints are
+// written on each save
+type SQLiteStateStore struct {
+	db     *sql.DB
+	path   string
+	synced bool // Whether the database holds exactly the endpoints known to the analyzer
+}
+
+// NewSQLiteStateStore opens or creates a SQLite state store at path
+func NewSQLiteStateStore(path string) (*SQLiteStateStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	// SQLite allows a single writer; serializing access avoids busy errors
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create tables in %s: %w", path, err)
+	}
+	if err := migrateSQLiteSchema(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate tables in %s: %w", path, err)
+	}
+	return &SQLiteStateStore{db: db, path: path}, nil
+}
+
+// migrateSQLiteSchema adds the columns missing from databases created by
+// older versions
+func migrateSQLiteSchema(db *sql.DB) error {
+	columns := []struct {
+		table, name, definition string
+	}{
+		{"paths", "nullable", "INTEGER NOT NULL DEFAULT 0"},
+		{"paths", "repeated", "INTEGER NOT NULL DEFAULT 0"},
+		{"paths", "presence", "INTEGER NOT NULL DEFAULT 0"},
+		{"paths", "seen", "INTEGER NOT NULL DEFAULT 0"},
+		{"endpoints", "request_count", "INTEGER NOT NULL DEFAULT 0"},
+		{"endpoints", "last_seen", "INTEGER NOT NULL DEFAULT 0"},
+		{"endpoints", "protocols", "TEXT NOT NULL DEFAULT ''"},
+		{"endpoints", "tls", "INTEGER NOT NULL DEFAULT 0"},
+		{"responses", "count", "INTEGER NOT NULL DEFAULT 0"},
+	}
+	for _, column := range columns {
+		var count int
+		err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, column.table, column.name).Scan(&count)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			if _, err := db.Exec(`ALTER TABLE ` + column.table + ` ADD COLUMN ` + column.name + ` ` + column.definition); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Load reconstructs the endpoints from the database if the version matches
+func (s *SQLiteStateStore) Load() (map[string]*EndpointData, error) {
+	var version string
+	err := s.db.QueryRow(`SELECT value FROM meta WHERE key = 'version'`).Scan(&version)
+	if err == sql.ErrNoRows {
+		slog.Info("No saved state found", "location", s.path)
+		s.synced = true
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Only load if version matches
+	if version != SchemaVersion {
+		slog.Info("Saved state version mismatch", "found", version, "expected", SchemaVersion)
+		return nil, nil
+	}
+
+	endpoints := make(map[string]*EndpointData)
+	rows, err := s.db.Query(`SELECT key, method, url, request_content_type, request_count, last_seen, protocols, tls FROM endpoints`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		endpoint := &EndpointData{
+			RequestHeaders:   &SchemaStore{Examples: make(map[string][]interface{}), Optional: make(map[string]bool)},
+			RequestPayload:   &SchemaStore{Examples: make(map[string][]interface{}), Optional: make(map[string]bool)},
+			URLParameters:    &SchemaStore{Examples: make(map[string][]interface{}), Optional: make(map[string]bool)},
+			PathParams:       &SchemaStore{Examples: make(map[string][]interface{}), Optional: make(map[string]bool)},
+			Cookies:          &SchemaStore{Examples: make(map[string][]interface{}), Optional: make(map[string]bool)},
+			Tracing:          &SchemaStore{Examples: make(map[string][]interface{}), Optional: make(map[string]bool)},
+			ResponseStatuses: make(map[int]*ResponseData),
+		}
+		var key, protocols string
+		var lastSeen int64
+		if err := rows.Scan(&key, &endpoint.Method, &endpoint.URL, &endpoint.RequestContentType, &endpoint.RequestCount, &lastSeen, &protocols, &endpoint.TLS); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if lastSeen != 0 {
+			endpoint.LastSeen = time.Unix(0, lastSeen).UTC()
+		}
+		if protocols != "" {
+			endpoint.Protocols = strings.Split(protocols, ",")
+		}
+		endpoints[key] = endpoint
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.Query(`SELECT endpoint_key, status, content_type, count FROM responses`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var key, contentType string
+		var status int
+		var count int64
+		if err := rows.Scan(&key, &status, &contentType, &count); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if endpoint, exists := endpoints[key]; exists {
+			endpoint.ResponseStatuses[status] = &ResponseData{
+				Headers:     &SchemaStore{Examples: make(map[string][]interface{}), Optional: make(map[string]bool)},
+				Payload:     &SchemaStore{Examples: make(map[string][]interface{}), Optional: make(map[string]bool)},
+				SetCookies:  &SchemaStore{Examples: make(map[string][]interface{}), Optional: make(map[string]bool)},
+				ContentType: contentType,
+				Count:       count,
+			}
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.Query(`SELECT endpoint_key, store, status, observations FROM stores`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var key, name string
+		var status int
+		var observations int64
+		if err := rows.Scan(&key, &name, &status, &observations); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if store := sqliteSchemaStore(endpoints[key], name, status); store != nil {
+			store.Observations = observations
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.Query(`SELECT endpoint_key, store, status, path, elements FROM arrays`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var key, name, path string
+		var status int
+		var elements int64
+		if err := rows.Scan(&key, &name, &status, &path, &elements); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if store := sqliteSchemaStore(endpoints[key], name, status); store != nil {
+			if store.Elements == nil {
+				store.Elements = make(map[string]int64)
+			}
+			store.Elements[path] = elements
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.Query(`SELECT endpoint_key, store, status, path FROM truncations`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var key, name, path string
+		var status int
+		if err := rows.Scan(&key, &name, &status, &path); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if store := sqliteSchemaStore(endpoints[key], name, status); store != nil {
+			store.setTruncated(path)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.Query(`SELECT endpoint_key, store, status, path, optional, nullable, repeated, presence, seen FROM paths`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var key, name, path string
+		var status int
+		var optional, nullable, repeated bool
+		var presence int64
+		var seen int
+		if err := rows.Scan(&key, &name, &status, &path, &optional, &nullable, &repeated, &presence, &seen); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if store := sqliteSchemaStore(endpoints[key], name, status); store != nil {
+			store.Examples[path] = make([]interface{}, 0)
+			store.Optional[path] = optional
+			if nullable {
+				if store.Nullable == nil {
+					store.Nullable = make(map[string]bool)
+				}
+				store.Nullable[path] = true
+			}
+			if repeated {
+				if store.Repeated == nil {
+					store.Repeated = make(map[string]bool)
+				}
+				store.Repeated[path] = true
+			}
+			if presence > 0 {
+				if store.Presence == nil {
+					store.Presence = make(map[string]int64)
+				}
+				store.Presence[path] = presence
+			}
+			if seen > 0 {
+				if store.Seen == nil {
+					store.Seen = make(map[string]int)
+				}
+				store.Seen[path] = seen
+			}
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.Query(`SELECT endpoint_key, time, kind, location, field, detail FROM changes ORDER BY position`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var key string
+		var changeTime int64
+		var change SchemaChange
+		if err := rows.Scan(&key, &changeTime, &change.Kind, &change.Location, &change.Field, &change.Detail); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if endpoint, exists := endpoints[key]; exists {
+			change.Time = time.Unix(0, changeTime).UTC()
+			endpoint.Changes = append(endpoint.Changes, change)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.Query(`SELECT endpoint_key, store, status, path, value FROM examples ORDER BY position`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key, name, path, encoded string
+		var status int
+		if err := rows.Scan(&key, &name, &status, &path, &encoded); err != nil {
+			return nil, err
+		}
+		var value interface{}
+		if err := json.Unmarshal([]byte(encoded), &value); err != nil {
+			return nil, fmt.Errorf("failed to decode example of %s: %w", path, err)
+		}
+		if store := sqliteSchemaStore(endpoints[key], name, status); store != nil {
+			store.Examples[path] = append(store.Examples[path], value)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	s.synced = true
+	return endpoints, nil
+}
+
+// sqliteSchemaStore returns the schema store of an endpoint with the given name
+// and status, or nil if the endpoint or response doesn't exist
+func sqliteSchemaStore(endpoint *EndpointData, name string, status int) *SchemaStore {
+	if endpoint == nil {
+		return nil
+	}
+	switch name {
+	case sqliteRequestHeaders:
+		return endpoint.RequestHeaders
+	case sqliteRequestPayload:
+		return endpoint.RequestPayload
+	case sqliteURLParameters:
+		return endpoint.URLParameters
+	case sqlitePathParams:
+		return endpoint.PathParams
+	case sqliteCookies:
+		return endpoint.Cookies
+	case sqliteTracing:
+		return endpoint.Tracing
+	}
+	if mediaType, found := strings.CutPrefix(name, sqliteRequestBodyPrefix); found {
+		if endpoint.RequestBodies == nil {
+			endpoint.RequestBodies = make(map[string]*SchemaStore)
+		}
+		store, exists := endpoint.RequestBodies[mediaType]
+		if !exists {
+			store = &SchemaStore{Examples: make(map[string][]interface{}), Optional: make(map[string]bool)}
+			endpoint.RequestBodies[mediaType] = store
+		}
+		return store
+	}
+
+	response, exists := endpoint.ResponseStatuses[status]
+	if !exists {
+		return nil
+	}
+	switch name {
+	case sqliteResponseHeaders:
+		return response.Headers
+	case sqliteResponsePayload:
+		return response.Payload
+	case sqliteSetCookies:
+		return response.SetCookies
+	}
+	return nil
+}
+
+// Save upserts the changed endpoints in a single transaction. All endpoints are
+// written if the database held a different state before.
+func (s *SQLiteStateStore) Save(endpoints map[string]*EndpointData, changed []string) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if !s.synced {
+		// Replace a state that could not be loaded, e.g. from another version
+		for _, table := range []string{"endpoints", "responses", "stores", "arrays", "truncations", "paths", "examples", "changes"} {
+			if _, err := tx.Exec(`DELETE FROM ` + table); err != nil {
+				return 0, err
+			}
+		}
+		changed = make([]string, 0, len(endpoints))
+		for key := range endpoints {
+			changed = append(changed, key)
+		}
+	}
+
+	for _, key := range changed {
+		endpoint, exists := endpoints[key]
+		if !exists {
+			continue
+		}
+		if err := saveSQLiteEndpoint(tx, key, endpoint); err != nil {
+			return 0, fmt.Errorf("failed to save endpoint %s: %w", key, err)
+		}
+	}
+
+	if _, err := tx.Exec(`INSERT INTO meta (key, value) VALUES ('version', ?)
+		ON CONFLICT (key) DO UPDATE SET value = excluded.value`, SchemaVersion); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	s.synced = true
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return 0, nil
+	}
+	return info.Size(), nil
+}
+
+// saveSQLiteEndpoint replaces the rows of an endpoint
+func saveSQLiteEndpoint(tx *sql.Tx, key string, endpoint *EndpointData) error {
+	var lastSeen int64
+	if !endpoint.LastSeen.IsZero() {
+		lastSeen = endpoint.LastSeen.UnixNano()
+	}
+	if _, err := tx.Exec(`INSERT INTO endpoints (key, method, url, request_content_type, request_count, last_seen, protocols, tls) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET method = excluded.method, url = excluded.url,
+		request_content_type = excluded.request_content_type, request_count = excluded.request_count,
+		last_seen = excluded.last_seen, protocols = excluded.protocols, tls = excluded.tls`,
+		key, endpoint.Method, endpoint.URL, endpoint.RequestContentType, endpoint.RequestCount, lastSeen,
+		strings.Join(endpoint.Protocols, ","), endpoint.TLS); err != nil {
+		return err
+	}
+	for _, table := range []string{"responses", "stores", "arrays", "truncations", "paths", "examples", "changes"} {
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE endpoint_key = ?`, key); err != nil {
+			return err
+		}
+	}
+	for position, change := range endpoint.Changes {
+		if _, err := tx.Exec(`INSERT INTO changes (endpoint_key, position, time, kind, location, field, detail) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			key, position, change.Time.UnixNano(), change.Kind, change.Location, change.Field, change.Detail); err != nil {
+			return err
+		}
+	}
+
+	stores := map[string]*SchemaStore{
+		sqliteRequestHeaders: endpoint.RequestHeaders,
+		sqliteRequestPayload: endpoint.RequestPayload,
+		sqliteURLParameters:  endpoint.URLParameters,
+		sqlitePathParams:     endpoint.PathParams,
+		sqliteCookies:        endpoint.Cookies,
+		sqliteTracing:        endpoint.Tracing,
+	}
+	for mediaType, store := range endpoint.RequestBodies {
+		stores[sqliteRequestBodyPrefix+mediaType] = store
+	}
+	for name, store := range stores {
+		if err := saveSQLiteSchemaStore(tx, key, name, 0, store); err != nil {
+			return err
+		}
+	}
+
+	for status, response := range endpoint.ResponseStatuses {
+		if _, err := tx.Exec(`INSERT INTO responses (endpoint_key, status, content_type, count) VALUES (?, ?, ?, ?)`,
+			key, status, response.ContentType, response.Count); err != nil {
+			return err
+		}
+		stores := map[string]*SchemaStore{
+			sqliteResponseHeaders: response.Headers,
+			sqliteResponsePayload: response.Payload,
+			sqliteSetCookies:      response.SetCookies,
+		}
+		for name, store := range stores {
+			if err := saveSQLiteSchemaStore(tx, key, name, status, store); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// saveSQLiteSchemaStore inserts the paths and examples of a schema store
+func saveSQLiteSchemaStore(tx *sql.Tx, key, name string, status int, store *SchemaStore) error {
+	if store == nil {
+		return nil
+	}
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	if store.Observations > 0 {
+		if _, err := tx.Exec(`INSERT INTO stores (endpoint_key, store, status, observations) VALUES (?, ?, ?, ?)`,
+			key, name, status, store.Observations); err != nil {
+			return err
+		}
+	}
+	for path, elements := range store.Elements {
+		if _, err := tx.Exec(`INSERT INTO arrays (endpoint_key, store, status, path, elements) VALUES (?, ?, ?, ?, ?)`,
+			key, name, status, path, elements); err != nil {
+			return err
+		}
+	}
+	for path := range store.Truncated {
+		if _, err := tx.Exec(`INSERT INTO truncations (endpoint_key, store, status, path) VALUES (?, ?, ?, ?)`,
+			key, name, status, path); err != nil {
+			return err
+		}
+	}
+	for path, examples := range store.Examples {
+		if _, err := tx.Exec(`INSERT INTO paths (endpoint_key, store, status, path, optional, nullable, repeated, presence, seen) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			key, name, status, path, store.Optional[path], store.Nullable[path], store.Repeated[path], store.Presence[path], store.Seen[path]); err != nil {
+			return err
+		}
+		for position, example := range examples {
+			encoded, err := json.Marshal(example)
+			if err != nil {
+				return fmt.Errorf("failed to encode example of %s: %w", path, err)
+			}
+			if _, err := tx.Exec(`INSERT INTO examples (endpoint_key, store, status, path, position, value) VALUES (?, ?, ?, ?, ?, ?)`,
+				key, name, status, path, position, string(encoded)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Location returns the path of the database file
+func (s *SQLiteStateStore) Location() string {
+	return s.path
+}
+
+// Close closes the database
+func (s *SQLiteStateStore) Close() error {
+	return s.db.Close()
+}
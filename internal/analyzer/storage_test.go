@@ -0,0 +1,430 @@
+package analyzer
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stateStoreBackend opens a state store in a test directory
+type stateStoreBackend struct {
+	name       string
+	open       func(t *testing.T, dir string) StateStore
+	setVersion func(t *testing.T, dir, version string)
+}
+
+var stateStoreBackends = []stateStoreBackend{
+	{
+		name: StorageTypeFile,
+		open: func(t *testing.T, dir string) StateStore {
+			return NewFileStateStore(dir)
+		},
+		setVersion: func(t *testing.T, dir, version string) {
+			path := filepath.Join(dir, "analyzer.json")
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			var state map[string]interface{}
+			require.NoError(t, json.Unmarshal(data, &state))
+			state["version"] = version
+			data, err = json.Marshal(state)
+			require.NoError(t, err)
+			require.NoError(t, os.WriteFile(path, data, 0644))
+		},
+	},
+	{
+		name: StorageTypeSQLite,
+		open: func(t *testing.T, dir string) StateStore {
+			store, err := NewSQLiteStateStore(filepath.Join(dir, "docurift.db"))
+			require.NoError(t, err)
+			return store
+		},
+		setVersion: func(t *testing.T, dir, version string) {
+			db, err := sql.Open("sqlite", filepath.Join(dir, "docurift.db"))
+			require.NoError(t, err)
+			defer db.Close()
+			_, err = db.Exec(`UPDATE meta SET value = ? WHERE key = 'version'`, version)
+			require.NoError(t, err)
+		},
+	},
+	{
+		name: StorageTypeS3,
+		open: func(t *testing.T, dir string) StateStore {
+			return newTestS3StateStore(t, fakeS3ForDir(t, dir))
+		},
+		setVersion: func(t *testing.T, dir, version string) {
+			fake := fakeS3ForDir(t, dir)
+			var state map[string]interface{}
+			require.NoError(t, json.Unmarshal(fake.object("/docs/state/analyzer.json"), &state))
+			state["version"] = version
+			data, err := json.Marshal(state)
+			require.NoError(t, err)
+			fake.setObject("/docs/state/analyzer.json", data)
+		},
+	},
+}
+
+// fakeS3 is a minimal S3 server supporting path-style GetObject and PutObject
+type fakeS3 struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	objects  map[string][]byte
+	puts     int
+	failPuts int // Number of following PutObject requests answered with an error
+}
+
+func newFakeS3(t *testing.T) *fakeS3 {
+	fake := &fakeS3{objects: make(map[string][]byte)}
+	fake.Server = httptest.NewServer(http.HandlerFunc(fake.serveHTTP))
+	t.Cleanup(fake.Close)
+	return fake
+}
+
+func (f *fakeS3) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		f.puts++
+		if f.failPuts > 0 {
+			f.failPuts--
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`<Error><Code>InternalError</Code><Message>We encountered an internal error.</Message></Error>`))
+			return
+		}
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.objects[r.URL.Path] = data
+	case http.MethodGet:
+		data, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`))
+			return
+		}
+		w.Write(data)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeS3) object(path string) []byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.objects[path]
+}
+
+func (f *fakeS3) failNextPuts(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failPuts = n
+}
+
+func (f *fakeS3) putCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.puts
+}
+
+func (f *fakeS3) setObject(path string, data []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.objects[path] = data
+}
+
+var (
+	fakeS3sMu sync.Mutex
+	fakeS3s   = make(map[string]*fakeS3)
+)
+
+// fakeS3ForDir returns the fake S3 server standing in for a test directory
+func fakeS3ForDir(t *testing.T, dir string) *fakeS3 {
+	fakeS3sMu.Lock()
+	defer fakeS3sMu.Unlock()
+	if fake, ok := fakeS3s[dir]; ok {
+		return fake
+	}
+	fake := newFakeS3(t)
+	fakeS3s[dir] = fake
+	return fake
+}
+
+// newTestS3StateStore creates an S3 store for the docs bucket of a fake S3 server
+func newTestS3StateStore(t *testing.T, fake *fakeS3) *S3StateStore {
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+
+	store, err := NewS3StateStore(context.Background(), S3Config{
+		Bucket:   "docs",
+		Prefix:   "state/",
+		Region:   "us-east-1",
+		Endpoint: fake.URL,
+	})
+	require.NoError(t, err)
+	return store
+}
+
+// recordingStateStore records the changed endpoint keys passed to Save
+type recordingStateStore struct {
+	StateStore
+	changed [][]string
+}
+
+func (s *recordingStateStore) Save(endpoints map[string]*EndpointData, changed []string) (int64, error) {
+	keys := append([]string(nil), changed...)
+	sort.Strings(keys)
+	s.changed = append(s.changed, keys)
+	return s.StateStore.Save(endpoints, changed)
+}
+
+// processPersistenceRequests sends requests covering all parts of the endpoint data
+func processPersistenceRequests(a *Analyzer) {
+	req := httptest.NewRequest("POST", "https://example.com/api/orders?source=web", bytes.NewReader(nil))
+	req.Header.Set("Content-Type", "application/vnd.shop+json")
+	req.Header.Set("X-Tenant", "acme")
+	req.Header.Set("Cookie", "theme=dark")
+	resp := &http.Response{
+		StatusCode: 201,
+		Header: http.Header{
+			"Content-Type": []string{"application/json"},
+			"Location":     []string{"/api/orders/1"},
+			"Set-Cookie":   []string{"cart=1; Path=/"},
+		},
+	}
+	reqBody := []byte(`{"items":[{"sku":"A-1","qty":2}],"note":null,"gift":false}`)
+	a.ProcessRequest("POST", "https://example.com/api/orders", req, resp, reqBody, []byte(`{"id":1,"total":9.5}`))
+	// Adds change log entries
+	a.ProcessRequest("POST", "https://example.com/api/orders", req, resp, reqBody, []byte(`{"id":2,"total":"9.50","coupon":"X"}`))
+
+	req = httptest.NewRequest("POST", "https://example.com/api/orders", nil)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	a.ProcessRequest("POST", "https://example.com/api/orders", req, &http.Response{StatusCode: 201}, []byte("sku=A-1&qty=2"), nil)
+
+	req = httptest.NewRequest("GET", "https://example.com/api/orders/1", nil)
+	a.ProcessRequest("GET", "https://example.com/api/orders/1", req, &http.Response{StatusCode: 200}, nil, []byte(`["a","b"]`))
+}
+
+func TestStateStores(t *testing.T) {
+	for _, backend := range stateStoreBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			t.Run("Save and Load State", func(t *testing.T) {
+				dir := t.TempDir()
+				a1 := NewAnalyzerWithStore(backend.open(t, dir), 3600)
+				processPersistenceRequests(a1)
+				a1.SetProcessingLimits(1, defaultMaxPaths, defaultMaxArrayItems)
+				req := httptest.NewRequest("GET", "https://example.com/api/orders/1", nil)
+				a1.ProcessRequest("GET", "https://example.com/api/orders/1", req, &http.Response{StatusCode: 200}, nil, []byte(`{"customer":{"name":"Ann"}}`))
+				require.NoError(t, a1.Save())
+				want, err := json.Marshal(a1.GetData())
+				require.NoError(t, err)
+				a1.Stop()
+
+				a2 := NewAnalyzerWithStore(backend.open(t, dir), 3600)
+				defer a2.Stop()
+				got, err := json.Marshal(a2.GetData())
+				require.NoError(t, err)
+				assert.JSONEq(t, string(want), string(got))
+				// Reservoir sampling continues from the restored counts
+				assert.Equal(t, 2, a2.GetData()["POST /api/orders"].ResponseStatuses[201].Payload.Seen["id"])
+				assert.Equal(t, map[string]bool{"customer": true}, a2.GetData()["GET /api/orders/{id}"].ResponseStatuses[200].Payload.Truncated)
+
+				// Loaded endpoints keep collecting data
+				processPersistenceRequests(a2)
+				assert.Len(t, a2.GetData(), 2)
+			})
+
+			t.Run("Incremental Save", func(t *testing.T) {
+				dir := t.TempDir()
+				store := &recordingStateStore{StateStore: backend.open(t, dir)}
+				a1 := NewAnalyzerWithStore(store, 3600)
+				processPersistenceRequests(a1)
+				require.NoError(t, a1.Save())
+
+				req := httptest.NewRequest("GET", "https://example.com/api/orders/2", nil)
+				a1.ProcessRequest("GET", "https://example.com/api/orders/2", req, &http.Response{StatusCode: 404}, nil, nil)
+				a1.SetCaptureErrors(true)
+				a1.ProcessRequest("GET", "https://example.com/api/orders/2", req, &http.Response{StatusCode: 404}, nil, []byte(`{"error":"not found"}`))
+				require.NoError(t, a1.Save())
+				require.NoError(t, a1.Save())
+				a1.Stop()
+
+				assert.Equal(t, [][]string{
+					{"GET /api/orders/{id}", "POST /api/orders"},
+					{"GET /api/orders/{id}"},
+					nil,
+				}, store.changed)
+
+				a2 := NewAnalyzerWithStore(backend.open(t, dir), 3600)
+				defer a2.Stop()
+				data := a2.GetData()
+				assert.Len(t, data, 2)
+				assert.Contains(t, data["GET /api/orders/{id}"].ResponseStatuses, 404)
+				assert.Equal(t, []interface{}{"not found"}, data["GET /api/orders/{id}"].ResponseStatuses[404].Payload.Examples["error"])
+				assert.Equal(t, []interface{}{"A-1"}, data["POST /api/orders"].RequestPayload.Examples["items[].sku"])
+			})
+
+			t.Run("Version Mismatch", func(t *testing.T) {
+				dir := t.TempDir()
+				a1 := NewAnalyzerWithStore(backend.open(t, dir), 3600)
+				processPersistenceRequests(a1)
+				require.NoError(t, a1.Save())
+				a1.Stop()
+				backend.setVersion(t, dir, "0.9")
+
+				a2 := NewAnalyzerWithStore(backend.open(t, dir), 3600)
+				assert.Empty(t, a2.GetData(), "Expected no endpoints to be loaded due to version mismatch")
+
+				// The outdated state is replaced on the next save
+				req := httptest.NewRequest("GET", "https://example.com/api/health", nil)
+				a2.ProcessRequest("GET", "https://example.com/api/health", req, &http.Response{StatusCode: 200}, nil, []byte(`{"ok":true}`))
+				require.NoError(t, a2.Save())
+				a2.Stop()
+
+				a3 := NewAnalyzerWithStore(backend.open(t, dir), 3600)
+				defer a3.Stop()
+				data := a3.GetData()
+				assert.Len(t, data, 1)
+				assert.Contains(t, data, "GET /api/health")
+			})
+		})
+	}
+}
+
+func TestOpenStateStore(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := OpenStateStore(StorageOptions{Path: dir})
+	require.NoError(t, err)
+	assert.IsType(t, &FileStateStore{}, store)
+	assert.Equal(t, dir, store.Location())
+
+	store, err = OpenStateStore(StorageOptions{Type: StorageTypeSQLite, Path: filepath.Join(dir, "docurift.db")})
+	require.NoError(t, err)
+	assert.IsType(t, &SQLiteStateStore{}, store)
+	assert.NoError(t, store.Close())
+
+	// A file that is not a SQLite database
+	corrupted := filepath.Join(dir, "corrupted.db")
+	require.NoError(t, os.WriteFile(corrupted, []byte("invalid database file contents, definitely not sqlite"), 0644))
+	_, err = OpenStateStore(StorageOptions{Type: StorageTypeSQLite, Path: corrupted})
+	assert.Error(t, err)
+
+	store, err = OpenStateStore(StorageOptions{Type: StorageTypeS3, S3: S3Config{Bucket: "docs", Prefix: "state/", Region: "us-east-1"}})
+	require.NoError(t, err)
+	assert.IsType(t, &S3StateStore{}, store)
+	assert.Equal(t, "s3://docs/state/analyzer.json", store.Location())
+
+	_, err = OpenStateStore(StorageOptions{Type: StorageTypeS3})
+	assert.Error(t, err)
+
+	_, err = OpenStateStore(StorageOptions{Type: "postgres", Path: dir})
+	assert.Error(t, err)
+}
+
+func TestSQLiteStateStoreMigration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "docurift.db")
+
+	// A database created before the nullable and counter columns were added
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE endpoints (
+		key                  TEXT PRIMARY KEY,
+		method               TEXT NOT NULL,
+		url                  TEXT NOT NULL,
+		request_content_type TEXT NOT NULL DEFAULT ''
+	)`)
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE paths (
+		endpoint_key TEXT NOT NULL,
+		store        TEXT NOT NULL,
+		status       INTEGER NOT NULL,
+		path         TEXT NOT NULL,
+		optional     INTEGER NOT NULL,
+		PRIMARY KEY (endpoint_key, store, status, path)
+	)`)
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE responses (
+		endpoint_key TEXT NOT NULL,
+		status       INTEGER NOT NULL,
+		content_type TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY (endpoint_key, status)
+	)`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	store, err := NewSQLiteStateStore(path)
+	require.NoError(t, err)
+	a := NewAnalyzerWithStore(store, 3600)
+	processPersistenceRequests(a)
+	req := httptest.NewRequest("GET", "https://example.com/api/orders?tag=a&tag=b", nil)
+	a.ProcessRequest("GET", "https://example.com/api/orders", req, &http.Response{StatusCode: 200}, nil, []byte(`[]`))
+	require.NoError(t, a.Save())
+	a.Stop()
+
+	store, err = NewSQLiteStateStore(path)
+	require.NoError(t, err)
+	endpoints, err := store.Load()
+	require.NoError(t, err)
+	require.NoError(t, store.Close())
+	assert.True(t, endpoints["POST /api/orders"].RequestPayload.Nullable["note"])
+	assert.False(t, endpoints["POST /api/orders"].RequestPayload.Nullable["gift"])
+	assert.Equal(t, int64(3), endpoints["POST /api/orders"].RequestCount)
+	assert.Equal(t, int64(3), endpoints["POST /api/orders"].ResponseStatuses[201].Count)
+	assert.Equal(t, []string{"HTTP/1.1"}, endpoints["POST /api/orders"].Protocols)
+	assert.True(t, endpoints["POST /api/orders"].TLS)
+	assert.False(t, endpoints["POST /api/orders"].LastSeen.IsZero())
+	assert.NotEmpty(t, endpoints["POST /api/orders"].Changes)
+	assert.True(t, endpoints["GET /api/orders"].URLParameters.Repeated["tag"])
+	assert.Equal(t, 2, endpoints["POST /api/orders"].ResponseStatuses[201].Payload.Seen["id"])
+}
+
+func TestS3StateStoreFailures(t *testing.T) {
+	backoff := s3MaxBackoff
+	s3MaxBackoff = time.Millisecond
+	defer func() { s3MaxBackoff = backoff }()
+
+	fake := newFakeS3(t)
+	a := NewAnalyzerWithStore(newTestS3StateStore(t, fake), 3600)
+	defer a.Stop()
+	processPersistenceRequests(a)
+
+	// Transient errors are retried
+	fake.failNextPuts(2)
+	require.NoError(t, a.Save())
+	assert.Equal(t, 3, fake.putCount())
+	assert.NotEmpty(t, fake.object("/docs/state/analyzer.json"))
+	assert.Equal(t, int64(0), a.GetConfig()["storageFailures"])
+
+	// A save failing all attempts is counted and retried on the next tick
+	processPersistenceRequests(a)
+	fake.failNextPuts(s3MaxAttempts)
+	err := a.Save()
+	require.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "s3://docs/state/analyzer.json"), err.Error())
+	assert.Equal(t, int64(1), a.GetConfig()["storageFailures"])
+
+	a.saveState()
+	assert.Equal(t, int64(1), a.GetConfig()["storageFailures"])
+	assert.Equal(t, 3+s3MaxAttempts+1, fake.putCount())
+}
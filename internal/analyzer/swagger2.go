@@ -0,0 +1,326 @@
+package analyzer
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Swagger2 represents a Swagger 2.0 (OpenAPI 2) specification for tools that
+// don't support OpenAPI 3
+type Swagger2 struct {
+	Swagger     string                      `json:"swagger"`
+	Info        Info                        `json:"info"`
+	Host        string                      `json:"host,omitempty"`
+	BasePath    string                      `json:"basePath,omitempty"`
+	Schemes     []string                    `json:"schemes,omitempty"`
+	Consumes    []string                    `json:"consumes,omitempty"`
+	Produces    []string                    `json:"produces,omitempty"`
+	Paths       map[string]Swagger2PathItem `json:"paths"`
+	Definitions map[string]Swagger2Schema   `json:"definitions,omitempty"`
+	Tags        []Tag                       `json:"tags,omitempty"`
+}
+
+type Swagger2PathItem struct {
+	Get    *Swagger2Operation `json:"get,omitempty"`
+	Post   *Swagger2Operation `json:"post,omitempty"`
+	Put    *Swagger2Operation `json:"put,omitempty"`
+	Delete *Swagger2Operation `json:"delete,omitempty"`
+}
+
+type Swagger2Operation struct {
+	Summary     string                      `json:"summary"`
+	OperationID string                      `json:"operationId,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Consumes    []string                    `json:"consumes,omitempty"`
+	Produces    []string                    `json:"produces,omitempty"`
+	Parameters  []Swagger2Parameter         `json:"parameters,omitempty"`
+	Responses   map[string]Swagger2Response `json:"responses"`
+	Deprecated  bool                        `json:"deprecated,omitempty"`
+}
+
+// Swagger2Parameter is a body parameter with a schema or another parameter
+// with its type given directly
+type Swagger2Parameter struct {
+	Name        string          `json:"name"`
+	In          string          `json:"in"`
+	Required    bool            `json:"required"`
+	Description string          `json:"description,omitempty"`
+	Schema      *Swagger2Schema `json:"schema,omitempty"`
+	Type        string          `json:"type,omitempty"`
+	Format      string          `json:"format,omitempty"`
+	Pattern     string          `json:"pattern,omitempty"`
+	Enum        []interface{}   `json:"enum,omitempty"`
+	Example     interface{}     `json:"x-example,omitempty"`
+
+	// Items and CollectionFormat describe array parameters, e.g. repeated
+	// query parameters
+	Items            *Swagger2Schema `json:"items,omitempty"`
+	CollectionFormat string          `json:"collectionFormat,omitempty"`
+}
+
+type Swagger2Response struct {
+	Description     string                    `json:"description"`
+	Schema          *Swagger2Schema           `json:"schema,omitempty"`
+	Headers         map[string]Swagger2Header `json:"headers,omitempty"`
+	OccurrenceCount int64                     `json:"x-occurrence-count,omitempty"`
+}
+
+type Swagger2Header struct {
+	Description string      `json:"description,omitempty"`
+	Type        string      `json:"type"`
+	Format      string      `json:"format,omitempty"`
+	Example     interface{} `json:"x-example,omitempty"`
+}
+
+// Swagger2Schema is a schema without the OpenAPI 3 fields: nullable becomes the
+// x-nullable extension, only the first example is kept and alternatives
+// (oneOf) are left out, accepting any value
+type Swagger2Schema struct {
+	Ref         string                    `json:"$ref,omitempty"`
+	Type        string                    `json:"type,omitempty"`
+	Format      string                    `json:"format,omitempty"`
+	Properties  map[string]Swagger2Schema `json:"properties,omitempty"`
+	Items       *Swagger2Schema           `json:"items,omitempty"`
+	Required    []string                  `json:"required,omitempty"`
+	Description string                    `json:"description,omitempty"`
+	Example     interface{}               `json:"example,omitempty"`
+	Enum        []interface{}             `json:"enum,omitempty"`
+	Nullable    bool                      `json:"x-nullable,omitempty"`
+}
+
+// GenerateSwagger2 generates a Swagger 2.0 specification from analyzer data by
+// converting the OpenAPI 3 specification. Request and response body schemas
+// are moved to definitions and referenced from body parameters and responses.
+// Cookie parameters can't be described in Swagger 2.0 and are left out, and a
+// response with several media types uses the schema of its JSON media type,
+// or else of the first one, while listing all of them in produces. Form data
+// becomes formData parameters unless the operation also accepts JSON. Swagger
+// 2.0 has a single server, given by host, basePath and schemes, which come from
+// the first server of the OpenAPI 3 specification.
+func (a *Analyzer) GenerateSwagger2() *Swagger2 {
+	openAPI := a.generateOpenAPI()
+	swagger := &Swagger2{
+		Swagger:     "2.0",
+		Info:        openAPI.Info,
+		Consumes:    []string{"application/json"},
+		Produces:    []string{"application/json"},
+		Paths:       make(map[string]Swagger2PathItem, len(openAPI.Paths)),
+		Definitions: make(map[string]Swagger2Schema),
+		Tags:        openAPI.Tags,
+	}
+	if len(openAPI.Servers) > 0 {
+		swagger.Host, swagger.BasePath, swagger.Schemes = swagger2Server(openAPI.Servers[0].URL)
+	}
+
+	// Convert in a fixed order so definition names are stable
+	for _, path := range sortedKeys(openAPI.Paths) {
+		pathItem := openAPI.Paths[path]
+		swagger.Paths[path] = Swagger2PathItem{
+			Get:    swagger.convertOperation("GET", path, pathItem.Get),
+			Post:   swagger.convertOperation("POST", path, pathItem.Post),
+			Put:    swagger.convertOperation("PUT", path, pathItem.Put),
+			Delete: swagger.convertOperation("DELETE", path, pathItem.Delete),
+		}
+	}
+	return swagger
+}
+
+// convertOperation converts an OpenAPI 3 operation, adding its body schemas to
+// the definitions
+func (s *Swagger2) convertOperation(method, path string, operation *Operation) *Swagger2Operation {
+	if operation == nil {
+		return nil
+	}
+	converted := &Swagger2Operation{
+		Summary:     operation.Summary,
+		OperationID: operation.OperationID,
+		Tags:        operation.Tags,
+		Responses:   make(map[string]Swagger2Response, len(operation.Responses)),
+		Deprecated:  operation.Deprecated,
+	}
+	name := definitionName(method, path)
+
+	for _, param := range operation.Parameters {
+		if param.In == "cookie" {
+			continue
+		}
+		parameter := Swagger2Parameter{
+			Name:        param.Name,
+			In:          param.In,
+			Required:    param.Required,
+			Description: param.Description,
+			Type:        swagger2ParameterType(param.Schema.Type),
+			Format:      param.Schema.Format,
+			Pattern:     param.Schema.Pattern,
+			Enum:        param.Schema.Enum,
+			Example:     firstExample(param.Schema),
+		}
+		if param.Schema.Type == "array" && param.Schema.Items != nil {
+			items := Swagger2Schema{Type: swagger2ParameterType(param.Schema.Items.Type)}
+			parameter.Type = "array"
+			parameter.Items = &items
+			parameter.CollectionFormat = "multi"
+		}
+		converted.Parameters = append(converted.Parameters, parameter)
+	}
+
+	if operation.RequestBody != nil && len(operation.RequestBody.Content) > 0 {
+		content := operation.RequestBody.Content
+		converted.Consumes = sortedKeys(content)
+		_, isJSON := content["application/json"]
+		if form, isForm := content[formURLEncoded]; isForm && !isJSON {
+			// Form fields are formData parameters, which can't be combined with a body parameter
+			for _, field := range sortedKeys(form.Schema.Properties) {
+				property := form.Schema.Properties[field]
+				converted.Parameters = append(converted.Parameters, Swagger2Parameter{
+					Name:    field,
+					In:      "formData",
+					Type:    swagger2ParameterType(property.Type),
+					Example: firstExample(property),
+				})
+			}
+		} else {
+			converted.Parameters = append(converted.Parameters, Swagger2Parameter{
+				Name:     "body",
+				In:       "body",
+				Required: operation.RequestBody.Required,
+				Schema:   s.addDefinition(name+"Request", bodySchema(content)),
+			})
+		}
+	}
+
+	produces := make(map[string]bool)
+	for status, response := range operation.Responses {
+		convertedResponse := Swagger2Response{Description: response.Description, OccurrenceCount: response.OccurrenceCount}
+		if len(response.Content) > 0 {
+			convertedResponse.Schema = s.addDefinition(name+"Response"+status, bodySchema(response.Content))
+			for mediaType := range response.Content {
+				produces[mediaType] = true
+			}
+		}
+		if len(response.Headers) > 0 {
+			convertedResponse.Headers = make(map[string]Swagger2Header, len(response.Headers))
+			for header, value := range response.Headers {
+				convertedResponse.Headers[header] = Swagger2Header{
+					Description: value.Description,
+					Type:        swagger2ParameterType(value.Schema.Type),
+					Format:      value.Schema.Format,
+					Example:     firstExample(value.Schema),
+				}
+			}
+		}
+		converted.Responses[status] = convertedResponse
+	}
+	if len(produces) > 0 {
+		converted.Produces = sortedKeys(produces)
+	}
+	return converted
+}
+
+// addDefinition adds a schema to the definitions under a name not used yet and
+// returns a reference to it
+func (s *Swagger2) addDefinition(name string, schema Schema) *Swagger2Schema {
+	unique := name
+	for i := 2; ; i++ {
+		if _, exists := s.Definitions[unique]; !exists {
+			break
+		}
+		unique = name + strconv.Itoa(i)
+	}
+	s.Definitions[unique] = swagger2Schema(schema)
+	return &Swagger2Schema{Ref: "#/definitions/" + unique}
+}
+
+// bodySchema returns the schema of the JSON media type of a body, or else of
+// the first media type
+func bodySchema(content map[string]MediaType) Schema {
+	if mediaType, exists := content["application/json"]; exists {
+		return mediaType.Schema
+	}
+	return content[sortedKeys(content)[0]].Schema
+}
+
+// swagger2Server returns the host, base path and schemes of a server URL. A
+// URL that isn't absolute or has variables can't be expressed in Swagger 2.0
+// and is left out, so the API is served from the host of the documentation.
+func swagger2Server(serverURL string) (string, string, []string) {
+	u, err := url.Parse(serverURL)
+	if err != nil || u.Host == "" || strings.ContainsAny(serverURL, "{}") {
+		return "", "", nil
+	}
+	var schemes []string
+	if u.Scheme == "http" || u.Scheme == "https" {
+		schemes = []string{u.Scheme}
+	}
+	basePath := strings.TrimSuffix(u.Path, "/")
+	return u.Host, basePath, schemes
+}
+
+// swagger2Schema converts an OpenAPI 3 schema
+func swagger2Schema(schema Schema) Swagger2Schema {
+	converted := Swagger2Schema{
+		Type:        schema.Type,
+		Format:      schema.Format,
+		Required:    schema.Required,
+		Description: schema.Description,
+		Example:     firstExample(schema),
+		Enum:        schema.Enum,
+		Nullable:    schema.Nullable,
+	}
+	if schema.Properties != nil {
+		converted.Properties = make(map[string]Swagger2Schema, len(schema.Properties))
+		for name, property := range schema.Properties {
+			converted.Properties[name] = swagger2Schema(property)
+		}
+	}
+	if schema.Items != nil {
+		items := swagger2Schema(*schema.Items)
+		converted.Items = &items
+	}
+	return converted
+}
+
+// swagger2ParameterType returns the type of a parameter or header, which is
+// required in Swagger 2.0 and limited to primitive types
+func swagger2ParameterType(schemaType string) string {
+	switch schemaType {
+	case "string", "number", "integer", "boolean":
+		return schemaType
+	}
+	return "string"
+}
+
+// firstExample returns the singular example of a schema or its first non-null example
+func firstExample(schema Schema) interface{} {
+	if schema.Example != nil {
+		return schema.Example
+	}
+	for _, example := range schema.Examples {
+		if example != nil {
+			return example
+		}
+	}
+	return nil
+}
+
+// definitionName builds a definition name from the method and path of an
+// operation, e.g. PostApiUsersIdOrders for POST /api/users/{id}/orders
+func definitionName(method, path string) string {
+	return pascalCase(strings.ToLower(method) + path)
+}
+
+// pascalCase joins the words of a string, separated by any character other
+// than a letter or digit, with their first letter capitalized
+func pascalCase(s string) string {
+	var name strings.Builder
+	for _, word := range strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		name.WriteString(string(runes))
+	}
+	return name.String()
+}
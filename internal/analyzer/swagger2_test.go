@@ -0,0 +1,154 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// validateSwagger2 validates a document against the Swagger 2.0 JSON schema
+func validateSwagger2(t *testing.T, document interface{}) {
+	compiler := jsonschema.NewCompiler()
+	// The Swagger 2.0 schema refers to the JSON Schema draft 4 meta schema
+	resources := map[string]string{
+		"http://json-schema.org/draft-04/schema": "testdata/jsonschema-draft-04.json",
+		"swagger2-schema.json":                   "testdata/swagger2-schema.json",
+	}
+	for url, path := range resources {
+		file, err := os.Open(path)
+		require.NoError(t, err)
+		defer file.Close()
+		require.NoError(t, compiler.AddResource(url, file))
+	}
+	schema, err := compiler.Compile("swagger2-schema.json")
+	require.NoError(t, err)
+
+	data, err := json.Marshal(document)
+	require.NoError(t, err)
+	var decoded interface{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.NoError(t, schema.Validate(decoded))
+}
+
+func TestGenerateSwagger2(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	a.SetCaptureErrors(true)
+
+	process := func(method, url string, headers http.Header, reqBody string, status int, respHeaders http.Header, respBody string) {
+		req := httptest.NewRequest(method, url, strings.NewReader(reqBody))
+		req.Header = headers
+		a.ProcessRequest(method, url, req, &http.Response{StatusCode: status, Header: respHeaders}, []byte(reqBody), []byte(respBody))
+	}
+	jsonHeaders := http.Header{"Content-Type": []string{"application/json"}}
+	process("GET", "http://example.com/api/users/1?verbose=true", http.Header{"X-Tenant": []string{"acme"}, "Cookie": []string{"lang=en"}},
+		"", 200, http.Header{"X-Rate-Limit": []string{"100"}}, `{"id":1,"name":"alice","nickname":null,"tags":["a"],"address":{"city":"Paris"}}`)
+	process("GET", "http://example.com/api/users/2", nil, "", 404, nil, `{"error":"not found"}`)
+	process("POST", "http://example.com/api/users", jsonHeaders, `{"name":"bob","roles":[{"id":1}]}`, 201, nil, `{"id":2}`)
+	process("PUT", "http://example.com/api/users/2", jsonHeaders, `[1,2]`, 204, nil, "")
+	process("DELETE", "http://example.com/api/users/123e4567-e89b-12d3-a456-426614174000", nil, "", 204, nil, "")
+
+	swagger := a.GenerateSwagger2()
+	validateSwagger2(t, swagger)
+	assert.Equal(t, "2.0", swagger.Swagger)
+
+	// Parameters have their type and example directly, except for the body
+	get := swagger.Paths["/api/users/{id}"].Get
+	require.NotNil(t, get)
+	var names []string
+	for _, param := range get.Parameters {
+		names = append(names, param.In+":"+param.Name)
+		assert.Nil(t, param.Schema)
+	}
+	assert.Equal(t, []string{"path:id", "query:verbose", "header:X-Tenant"}, names, "cookie parameters are left out")
+	assert.Equal(t, "integer", get.Parameters[0].Type)
+	assert.Equal(t, 1, get.Parameters[0].Example)
+	assert.Equal(t, "100", get.Responses["200"].Headers["X-Rate-Limit"].Example)
+
+	// Body schemas are referenced from the definitions
+	require.Equal(t, "#/definitions/GetApiUsersIdResponse200", get.Responses["200"].Schema.Ref)
+	assert.Equal(t, []string{"application/json"}, get.Produces)
+	user := swagger.Definitions["GetApiUsersIdResponse200"]
+	assert.Equal(t, "object", user.Type)
+	assert.Equal(t, "alice", user.Properties["name"].Example)
+	assert.True(t, user.Properties["nickname"].Nullable)
+	assert.Equal(t, "string", user.Properties["tags"].Items.Type)
+	assert.Equal(t, "Paris", user.Properties["address"].Properties["city"].Example)
+	assert.Contains(t, swagger.Definitions, "GetApiUsersIdResponse404")
+
+	post := swagger.Paths["/api/users"].Post
+	require.NotNil(t, post)
+	require.Len(t, post.Parameters, 1)
+	body := post.Parameters[0]
+	assert.Equal(t, "body", body.In)
+	assert.True(t, body.Required)
+	assert.Equal(t, "#/definitions/PostApiUsersRequest", body.Schema.Ref)
+	assert.Equal(t, []string{"application/json"}, post.Consumes)
+	assert.Equal(t, "object", swagger.Definitions["PostApiUsersRequest"].Properties["roles"].Items.Type)
+
+	put := swagger.Paths["/api/users/{id}"].Put
+	require.NotNil(t, put)
+	assert.Equal(t, "array", swagger.Definitions["PutApiUsersIdRequest"].Type)
+	assert.Contains(t, put.Responses, "204")
+
+	uuid := swagger.Paths["/api/users/{uuid}"].Delete.Parameters[0]
+	assert.Equal(t, "string", uuid.Type)
+	assert.Equal(t, "uuid", uuid.Format)
+}
+
+func TestHandleSwagger2(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	a.SetInfo(Info{Title: "Shop API", Version: "2.3.0", Contact: &Contact{Email: "api@example.com"}, License: &License{Name: "MIT"}})
+	a.SetServers([]APIServer{{URL: "https://api.example.com:8443/v1/"}, {URL: "http://staging.example.com"}})
+	req := httptest.NewRequest("GET", "https://example.com/api/users", nil)
+	a.ProcessRequest("GET", "https://example.com/api/users", req, &http.Response{StatusCode: 200}, nil, []byte(`[{"id":1}]`))
+
+	for _, name := range []string{"swagger.json", "swagger2.json"} {
+		w := httptest.NewRecorder()
+		NewServer(a).Handler().ServeHTTP(w, httptest.NewRequest("GET", "/api/"+name+"?download=1", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "attachment; filename="+name, w.Header().Get("Content-Disposition"))
+
+		var document map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &document))
+		assert.Equal(t, "2.0", document["swagger"])
+		assert.Equal(t, "api.example.com:8443", document["host"])
+		assert.Equal(t, "/v1", document["basePath"])
+		assert.Equal(t, []interface{}{"https"}, document["schemes"])
+		validateSwagger2(t, document)
+	}
+}
+
+func TestSwagger2Server(t *testing.T) {
+	tests := []struct {
+		url      string
+		host     string
+		basePath string
+		schemes  []string
+	}{
+		{"http://localhost:8080", "localhost:8080", "", []string{"http"}},
+		{"https://api.example.com/v2/", "api.example.com", "/v2", []string{"https"}},
+		{"/api", "", "", nil},
+		{"https://{region}.example.com", "", "", nil},
+	}
+	for _, tt := range tests {
+		host, basePath, schemes := swagger2Server(tt.url)
+		assert.Equal(t, tt.host, host, tt.url)
+		assert.Equal(t, tt.basePath, basePath, tt.url)
+		assert.Equal(t, tt.schemes, schemes, tt.url)
+	}
+}
+
+func TestDefinitionName(t *testing.T) {
+	assert.Equal(t, "GetApiUsersIdOrders", definitionName("GET", "/api/users/{id}/orders"))
+	assert.Equal(t, "PostPaymentMethods", definitionName("POST", "/payment-methods"))
+	assert.Equal(t, "Delete", definitionName("DELETE", "/"))
+}
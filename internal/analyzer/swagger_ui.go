@@ -3,6 +3,7 @@ package analyzer
 import (
 	"fmt"
 	"html/template"
+	"log/slog"
 	"net/http"
 )
 
@@ -13,11 +14,11 @@ const swaggerUITemplate = `<!DOCTYPE html>
     <meta name="viewport" content="width=device-width, initial-scale=1" />
     <meta name="description" content="SwaggerUI" />
     <title>SwaggerUI</title>
-    <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@4.5.0/swagger-ui.css" />
+    <link rel="stylesheet" href="{{.AssetsURL}}/swagger-ui.css" />
 </head>
 <body>
 <div id="swagger-ui"></div>
-<script src="https://unpkg.com/swagger-ui-dist@4.5.0/swagger-ui-bundle.js" crossorigin></script>
+<script src="{{.AssetsURL}}/swagger-ui-bundle.js" crossorigin></script>
 <script>
     window.onload = () => {
         window.ui = SwaggerUIBundle({
@@ -34,8 +35,28 @@ const swaggerUITemplate = `<!DOCTYPE html>
 </body>
 </html>`
 
-// handleSwaggerUI serves the Swagger UI HTML page
-func (s *Server) handleSwaggerUI(w http.ResponseWriter, r *http.Request) {
+// swaggerUIHandlers returns the handlers of the Swagger UI page and of its
+// assets under /swagger/. The page only loads the embedded assets, so it works
+// without network access. A build missing them logs an error when the server
+// starts and serves the page as an error naming the missing files.
+func (s *Server) swaggerUIHandlers() (http.HandlerFunc, http.Handler) {
+	fileSystem, err := getAssetsFileSystem(s.swaggerFS, "swagger-ui", "swagger-ui.css", "swagger-ui-bundle.js")
+	if err != nil {
+		slog.Error("Swagger UI is unavailable, run make swagger-ui and rebuild", "error", err)
+		page := func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, fmt.Sprintf("Swagger UI is not available in this build: %v", err), http.StatusInternalServerError)
+		}
+		return page, http.NotFoundHandler()
+	}
+
+	page := func(w http.ResponseWriter, r *http.Request) {
+		s.handleSwaggerUI(w, r, "/swagger")
+	}
+	return page, http.StripPrefix("/swagger/", http.FileServer(fileSystem))
+}
+
+// handleSwaggerUI serves the Swagger UI HTML page loading its assets from assetsURL
+func (s *Server) handleSwaggerUI(w http.ResponseWriter, r *http.Request, assetsURL string) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -48,7 +69,7 @@ func (s *Server) handleSwaggerUI(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "text/html")
-	if err := tmpl.Execute(w, nil); err != nil {
+	if err := tmpl.Execute(w, struct{ AssetsURL string }{assetsURL}); err != nil {
 		http.Error(w, fmt.Sprintf("Error executing template: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -0,0 +1,45 @@
+// TypeScript types of the API generated by DocuRift from observed traffic
+
+export type GetMixedResponse200 = GetMixedResponse200Object | number[];
+
+export interface GetMixedResponse200Object {
+  id?: number;
+}
+
+export type GetUserListResponse200 = GetUserListResponse200Item[];
+
+export interface GetUserListResponse200Item {
+  id: number;
+}
+
+export type GetUserList2Response200 = string[];
+
+export interface PostUsersRequest {
+  name: string;
+  tags: string[];
+}
+
+export interface PostUsersResponse201 {
+  id: number;
+}
+
+export interface GetUsersIdResponse200 {
+  id: number;
+  profile: GetUsersIdResponse200Profile;
+  scores: number[];
+  status: string;
+  "x-trace": string;
+}
+
+export interface GetUsersIdResponse200Profile {
+  bio: string | null;
+  links?: Record<string, unknown>[];
+}
+
+export type PutUsersIdRequest = PutUsersIdRequestItem[];
+
+export interface PutUsersIdRequestItem {
+  op: string;
+}
+
+export type PutUsersIdResponse200 = boolean;
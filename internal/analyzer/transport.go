@@ -0,0 +1,18 @@
+package analyzer
+
+import "net/http"
+
+// NewBackendTransport creates the transport used to forward requests to the
+// backend. With useHTTP2, requests are sent over HTTP/2 only: negotiated with
+// TLS for https:// backends and with prior knowledge over cleartext (h2c) for
+// http:// backends, which don't support upgrading from HTTP/1.1.
+func NewBackendTransport(useHTTP2 bool) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if useHTTP2 {
+		var protocols http.Protocols
+		protocols.SetHTTP2(true)
+		protocols.SetUnencryptedHTTP2(true)
+		transport.Protocols = &protocols
+	}
+	return transport
+}
@@ -0,0 +1,68 @@
+package analyzer
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vulcand/oxy/forward"
+)
+
+func TestBackendTransportH2C(t *testing.T) {
+	// A backend only speaking HTTP/2 over cleartext
+	backend := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Proto", r.Proto)
+		w.Write([]byte(`{"id":1}`))
+	}))
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+	backend.Config.Protocols = &protocols
+	backend.Start()
+	defer backend.Close()
+
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	fwd, err := forward.New(forward.RoundTripper(NewBackendTransport(true)))
+	require.NoError(t, err)
+
+	// Forward and record like the proxy does
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		req.URL.Scheme = "http"
+		req.URL.Host = backend.Listener.Addr().String()
+		recorder := httptest.NewRecorder()
+		fwd.ServeHTTP(recorder, req)
+		for name, values := range recorder.Header() {
+			w.Header()[name] = values
+		}
+		w.WriteHeader(recorder.Code)
+		w.Write(recorder.Body.Bytes())
+		resp := &http.Response{StatusCode: recorder.Code, Header: recorder.Header()}
+		a.ProcessRequest(req.Method, req.URL.String(), req, resp, nil, recorder.Body.Bytes())
+	}))
+	defer proxy.Close()
+
+	resp, err := http.Get(proxy.URL + "/users/1")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "HTTP/2.0", resp.Header.Get("X-Proto"))
+	assert.JSONEq(t, `{"id":1}`, string(body))
+
+	endpoint := a.GetData()["GET /users/{id}"]
+	require.NotNil(t, endpoint)
+	assert.Contains(t, endpoint.ResponseStatuses[200].Payload.Examples, "id")
+
+	// The default HTTP/1.1 transport can't reach the backend
+	req, err := http.NewRequest("GET", backend.URL+"/users/1", nil)
+	require.NoError(t, err)
+	if resp, err := NewBackendTransport(false).RoundTrip(req); err == nil {
+		resp.Body.Close()
+		t.Errorf("Expected HTTP/1.1 to fail, got status %d", resp.StatusCode)
+	}
+}
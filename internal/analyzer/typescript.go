@@ -0,0 +1,181 @@
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// typeScriptIdentifier matches property names that don't need quotes in TypeScript
+var typeScriptIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// typeScriptGenerator builds TypeScript declarations from OpenAPI schemas
+type typeScriptGenerator struct {
+	declarations []string
+	names        map[string]bool
+	enumUnions   bool // Whether strings with an enum are unions of their literals
+}
+
+// GenerateTypeScript generates TypeScript declarations of the request and
+// response bodies from analyzer data. Each body gets a type named like its
+// Swagger 2.0 definition, e.g. GetApiUsersIdResponse200, which is an interface
+// for objects and a type alias otherwise. Nested objects get interfaces of
+// their own named after their parent and property, properties that are not
+// always present are optional and nullable values include null. Strings are
+// typed as string, or with enumUnions as unions of the literals of their enum:
+// enums are inferred from the few values observed, so most fields would only
+// accept the values seen so far. Bodies without any observed field, e.g. of
+// 204 responses, are left out, as are request bodies if responsesOnly is set.
+func (a *Analyzer) GenerateTypeScript(responsesOnly, enumUnions bool) string {
+	openAPI := a.generateOpenAPI()
+	g := &typeScriptGenerator{names: make(map[string]bool), enumUnions: enumUnions}
+	operationNames := make(map[string]bool)
+
+	for _, path := range sortedKeys(openAPI.Paths) {
+		pathItem := openAPI.Paths[path]
+		for _, operation := range []struct {
+			method    string
+			operation *Operation
+		}{{"GET", pathItem.Get}, {"POST", pathItem.Post}, {"PUT", pathItem.Put}, {"DELETE", pathItem.Delete}} {
+			if operation.operation == nil {
+				continue
+			}
+			// Paths differing only in punctuation, e.g. /user-list and
+			// /user_list, get a counter after their name, e.g. GetUserList2
+			name := definitionName(operation.method, path)
+			unique := name
+			for i := 2; operationNames[unique]; i++ {
+				unique = name + strconv.Itoa(i)
+			}
+			operationNames[unique] = true
+			name = unique
+			if requestBody := operation.operation.RequestBody; !responsesOnly && requestBody != nil && len(requestBody.Content) > 0 {
+				g.declare(name+"Request", bodySchema(requestBody.Content))
+			}
+			for _, status := range sortedKeys(operation.operation.Responses) {
+				if content := operation.operation.Responses[status].Content; len(content) > 0 {
+					g.declare(name+"Response"+status, bodySchema(content))
+				}
+			}
+		}
+	}
+
+	var output strings.Builder
+	output.WriteString("// TypeScript types of the API generated by DocuRift from observed traffic\n")
+	for _, declaration := range g.declarations {
+		output.WriteString("\n")
+		output.WriteString(declaration)
+	}
+	return output.String()
+}
+
+// declare adds the declaration of a body schema, unless no field was observed
+func (g *typeScriptGenerator) declare(name string, schema Schema) {
+	if schema.Type == "object" && len(schema.Properties) == 0 && !schema.Nullable {
+		return
+	}
+	if schema.Type == "object" && len(schema.Properties) > 0 && !schema.Nullable {
+		g.declareInterface(name, schema)
+		return
+	}
+	name = g.reserve(name)
+	// Reserve the position so the alias comes before the interfaces it refers to
+	index := len(g.declarations)
+	g.declarations = append(g.declarations, "")
+	g.declarations[index] = fmt.Sprintf("export type %s = %s;\n", name, g.typeOf(name, schema))
+}
+
+// declareInterface adds an interface declaration of an object schema and
+// returns its name, which is made unique if already used
+func (g *typeScriptGenerator) declareInterface(name string, schema Schema) string {
+	name = g.reserve(name)
+	// Reserve the position so the interface comes before its nested interfaces
+	index := len(g.declarations)
+	g.declarations = append(g.declarations, "")
+
+	required := make(map[string]bool, len(schema.Required))
+	for _, property := range schema.Required {
+		required[property] = true
+	}
+
+	var declaration strings.Builder
+	fmt.Fprintf(&declaration, "export interface %s {\n", name)
+	for _, property := range sortedKeys(schema.Properties) {
+		key := property
+		if !typeScriptIdentifier.MatchString(key) {
+			key = strconv.Quote(key)
+		}
+		if !required[property] {
+			key += "?"
+		}
+		fmt.Fprintf(&declaration, "  %s: %s;\n", key, g.typeOf(name+pascalCase(property), schema.Properties[property]))
+	}
+	declaration.WriteString("}\n")
+	g.declarations[index] = declaration.String()
+	return name
+}
+
+// typeOf returns the TypeScript type of a schema, declaring interfaces named
+// after name for the objects it contains
+func (g *typeScriptGenerator) typeOf(name string, schema Schema) string {
+	var tsType string
+	if len(schema.OneOf) > 0 {
+		// Alternatives are named after their type, e.g. GetUsersResponse200Object
+		variants := make([]string, len(schema.OneOf))
+		for i, variant := range schema.OneOf {
+			variants[i] = g.typeOf(name+pascalCase(variant.Type), variant)
+		}
+		return strings.Join(variants, " | ")
+	}
+	switch schema.Type {
+	case "string":
+		tsType = "string"
+		if g.enumUnions && len(schema.Enum) > 0 {
+			literals := make([]string, 0, len(schema.Enum))
+			for _, value := range schema.Enum {
+				if str, ok := value.(string); ok {
+					literals = append(literals, jsString(str))
+				}
+			}
+			if len(literals) == len(schema.Enum) {
+				tsType = strings.Join(literals, " | ")
+			}
+		}
+	case "integer", "number":
+		tsType = "number"
+	case "boolean":
+		tsType = "boolean"
+	case "object":
+		if len(schema.Properties) == 0 {
+			tsType = "Record<string, unknown>"
+		} else {
+			tsType = g.declareInterface(name, schema)
+		}
+	case "array":
+		tsType = "unknown[]"
+		if schema.Items != nil {
+			items := g.typeOf(name+"Item", *schema.Items)
+			if strings.Contains(items, " | ") {
+				items = "(" + items + ")"
+			}
+			tsType = items + "[]"
+		}
+	default:
+		tsType = "unknown"
+	}
+	if schema.Nullable && tsType != "unknown" {
+		tsType += " | null"
+	}
+	return tsType
+}
+
+// reserve returns a declaration name not used yet, appending a counter to name if needed
+func (g *typeScriptGenerator) reserve(name string) string {
+	unique := name
+	for i := 2; g.names[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	g.names[unique] = true
+	return unique
+}
@@ -0,0 +1,157 @@
+package analyzer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateTypeScript(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+
+	process := func(method, url, reqBody string, status int, respBody string) {
+		req := httptest.NewRequest(method, url, strings.NewReader(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		a.ProcessRequest(method, url, req, &http.Response{StatusCode: status}, []byte(reqBody), []byte(respBody))
+	}
+	process("GET", "http://example.com/api/users/1", "", 200,
+		`{"id":1,"name":"alice","nickname":null,"tags":["a"],"address":{"city":"Paris"},"content-type":"json","roles":[{"id":1}]}`)
+	process("GET", "http://example.com/api/users/2", "", 200,
+		`{"id":2,"name":"bob","nickname":"bobby","tags":["b"],"address":{"city":"Rome","zip":"00100"},"content-type":"json","roles":[{"id":2}]}`)
+	process("POST", "http://example.com/api/users", `{"name":"carol","admin":true}`, 201, `[1,2]`)
+	process("DELETE", "http://example.com/api/users/1", "", 204, "")
+
+	// Fields missing from some bodies are optional
+	output := a.GenerateTypeScript(false, false)
+	assert.Contains(t, output, `export interface GetApiUsersIdResponse200 {
+  address: GetApiUsersIdResponse200Address;
+  "content-type": string;
+  id: number;
+  name: string;
+  nickname: string | null;
+  roles: GetApiUsersIdResponse200RolesItem[];
+  tags: string[];
+}
+`)
+	assert.Contains(t, output, `export interface GetApiUsersIdResponse200Address {
+  city: string;
+  zip?: string;
+}
+`)
+	assert.Contains(t, output, `export interface GetApiUsersIdResponse200RolesItem {
+  id: number;
+}
+`)
+	assert.Contains(t, output, `export interface PostApiUsersRequest {
+  admin: boolean;
+  name: string;
+}
+`)
+	assert.Contains(t, output, "export type PostApiUsersResponse201 = number[];\n")
+	assert.NotContains(t, output, "DeleteApiUsersIdResponse204", "empty bodies are left out")
+
+	// Interfaces come before the nested interfaces they refer to
+	assert.Less(t, strings.Index(output, "interface GetApiUsersIdResponse200 "), strings.Index(output, "interface GetApiUsersIdResponse200Address "))
+
+	// On request, strings with few distinct values are unions of their literals
+	output = a.GenerateTypeScript(false, true)
+	assert.Contains(t, output, `  name: "alice" | "bob";
+  nickname: "bobby" | null;
+`)
+	assert.Contains(t, output, `  tags: ("a" | "b")[];
+`)
+}
+
+func TestTypeScriptTypes(t *testing.T) {
+	g := &typeScriptGenerator{names: make(map[string]bool), enumUnions: true}
+	assert.Equal(t, "string | null", g.typeOf("Name", Schema{Type: "string", Nullable: true}))
+	assert.Equal(t, "unknown[]", g.typeOf("List", Schema{Type: "array"}))
+	assert.Equal(t, "(number | null)[]", g.typeOf("List", Schema{Type: "array", Items: &Schema{Type: "integer", Nullable: true}}))
+	assert.Equal(t, "Record<string, unknown>", g.typeOf("Map", Schema{Type: "object"}))
+	assert.Equal(t, "unknown", g.typeOf("Any", Schema{}))
+	assert.Equal(t, `"active" | "say \"hi\""`, g.typeOf("Status", Schema{Type: "string", Enum: []interface{}{"active", `say "hi"`}}))
+	assert.Equal(t, `("a" | "b" | null)[]`, g.typeOf("List", Schema{Type: "array", Items: &Schema{Type: "string", Enum: []interface{}{"a", "b"}, Nullable: true}}))
+	assert.Equal(t, "number", g.typeOf("Rating", Schema{Type: "integer", Enum: []interface{}{1.0, 2.0}}))
+	plain := &typeScriptGenerator{names: make(map[string]bool)}
+	assert.Equal(t, "string", plain.typeOf("Status", Schema{Type: "string", Enum: []interface{}{"active"}}))
+
+	// Required properties have no optional marker
+	user := Schema{
+		Type:       "object",
+		Properties: map[string]Schema{"id": {Type: "integer"}, "email": {Type: "string", Nullable: true}},
+		Required:   []string{"id"},
+	}
+	assert.Equal(t, "User", g.declareInterface("User", user))
+	assert.Equal(t, "export interface User {\n  email?: string | null;\n  id: number;\n}\n", g.declarations[len(g.declarations)-1])
+
+	// Names are made unique
+	assert.Equal(t, "User2", g.declareInterface("User", user))
+}
+
+// TestTypeScriptGolden compares the types generated from fixed traffic with
+// the golden file testdata/types.ts
+func TestTypeScriptGolden(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	process := func(method, url, reqBody string, status int, respBody string) {
+		req := httptest.NewRequest(method, url, strings.NewReader(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		a.ProcessRequest(method, url, req, &http.Response{StatusCode: status}, []byte(reqBody), []byte(respBody))
+	}
+	process("GET", "http://example.com/users/1", "", 200,
+		`{"id":1,"status":"active","profile":{"bio":null,"links":[{"url":"a"}]},"scores":[1.5],"x-trace":"t1"}`)
+	process("GET", "http://example.com/users/2", "", 200,
+		`{"id":2,"status":"it's \"banned\"","profile":{"bio":"hi","links":[]},"scores":[2],"x-trace":"t2"}`)
+	process("GET", "http://example.com/users/3", "", 404, `{"error":"not found"}`)
+	process("POST", "http://example.com/users", `{"name":"carol","tags":["x","y"],"settings":{}}`, 201, `{"id":3}`)
+	process("PUT", "http://example.com/users/1", `[{"op":"replace"}]`, 200, `true`)
+	process("DELETE", "http://example.com/users/1", "", 204, "")
+	// Different paths with the same type names
+	process("GET", "http://example.com/user-list", "", 200, `[{"id":1}]`)
+	process("GET", "http://example.com/user_list", "", 200, `["alice"]`)
+	process("GET", "http://example.com/mixed", "", 200, `{"id":1}`)
+	process("GET", "http://example.com/mixed", "", 200, `[1]`)
+
+	expected, err := os.ReadFile("testdata/types.ts")
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), a.GenerateTypeScript(false, false))
+
+	// Only response types
+	responses := a.GenerateTypeScript(true, false)
+	assert.NotContains(t, responses, "Request")
+	assert.Contains(t, responses, "export interface PostUsersResponse201 {")
+}
+
+func TestHandleTypeScript(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	req := httptest.NewRequest("GET", "http://example.com/api/users", nil)
+	a.ProcessRequest("GET", "http://example.com/api/users", req, &http.Response{StatusCode: 200}, nil, []byte(`[{"id":1}]`))
+
+	w := httptest.NewRecorder()
+	NewServer(a).Handler().ServeHTTP(w, httptest.NewRequest("GET", "/api/types.ts?download=1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/typescript", w.Header().Get("Content-Type"))
+	assert.Equal(t, "attachment; filename=types.ts", w.Header().Get("Content-Disposition"))
+	assert.Contains(t, w.Body.String(), "export type GetApiUsersResponse200 = GetApiUsersResponse200Item[];")
+	assert.Contains(t, w.Body.String(), "export interface GetApiUsersResponse200Item {\n  id: number;\n}")
+
+	req = httptest.NewRequest("POST", "http://example.com/api/users", strings.NewReader(`{"name":"alice"}`))
+	req.Header.Set("Content-Type", "application/json")
+	a.ProcessRequest("POST", "http://example.com/api/users", req, &http.Response{StatusCode: 201}, []byte(`{"name":"alice"}`), []byte(`{"id":1}`))
+	w = httptest.NewRecorder()
+	NewServer(a).Handler().ServeHTTP(w, httptest.NewRequest("GET", "/api/types.ts?responses_only=1", nil))
+	assert.Contains(t, w.Body.String(), "export interface PostApiUsersResponse201 {")
+	assert.NotContains(t, w.Body.String(), "PostApiUsersRequest")
+	assert.Contains(t, w.Body.String(), "  id: number;\n")
+
+	w = httptest.NewRecorder()
+	NewServer(a).Handler().ServeHTTP(w, httptest.NewRequest("GET", "/api/types.ts?enums=1", nil))
+	assert.Contains(t, w.Body.String(), "export interface PostApiUsersRequest {\n  name: \"alice\";\n}")
+}
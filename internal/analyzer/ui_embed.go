@@ -2,6 +2,7 @@ package analyzer
 
 import (
 	"embed"
+	"fmt"
 	"io/fs"
 	"net/http"
 )
@@ -9,12 +10,72 @@ import (
 //go:embed ui
 var uiFS embed.FS
 
+//go:embed swagger-ui
+var swaggerUIFS embed.FS
+
+//go:embed redoc
+var redocFS embed.FS
+
+// Versions of the embedded documentation viewers. Swagger UI is only served
+// from its embedded assets; ReDoc is loaded from a CDN if its assets are missing.
+const (
+	swaggerUIVersion = "4.15.5"
+	redocVersion     = "2.1.3"
+)
+
+// fallbackIndexHTML is served when the embedded UI assets are unavailable
+const fallbackIndexHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="utf-8" />
+    <title>DocuRift</title>
+</head>
+<body>
+<h1>DocuRift</h1>
+<p>The DocuRift UI is not available in this build. The generated documentation can still be accessed here:</p>
+<ul>
+    <li><a href="/api/openapi.json">OpenAPI specification</a></li>
+    <li><a href="/swagger">Swagger UI</a></li>
+    <li><a href="/redoc">ReDoc</a></li>
+</ul>
+</body>
+</html>`
+
 // getUIFileSystem returns a http.FileSystem for the embedded UI files
-func getUIFileSystem() http.FileSystem {
+func getUIFileSystem(fsys fs.FS) (http.FileSystem, error) {
 	// Get the subdirectory "ui" from the embedded filesystem
-	subFS, err := fs.Sub(uiFS, "ui")
+	subFS, err := fs.Sub(fsys, "ui")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fs.Stat(subFS, "index.html"); err != nil {
+		return nil, fmt.Errorf("UI assets not found: %w", err)
+	}
+	return http.FS(subFS), nil
+}
+
+// getAssetsFileSystem returns a http.FileSystem for the embedded assets of a
+// documentation viewer in dir, such as those fetched into the swagger-ui
+// directory with make swagger-ui, checking that the given files are present
+func getAssetsFileSystem(fsys fs.FS, dir string, names ...string) (http.FileSystem, error) {
+	subFS, err := fs.Sub(fsys, dir)
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+	for _, name := range names {
+		if _, err := fs.Stat(subFS, name); err != nil {
+			return nil, fmt.Errorf("%s assets not found: %w", dir, err)
+		}
+	}
+	return http.FS(subFS), nil
+}
+
+// handleFallbackIndex serves a minimal index page linking to the API documentation
+func handleFallbackIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" && r.URL.Path != "/index.html" {
+		http.NotFound(w, r)
+		return
 	}
-	return http.FS(subFS)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(fallbackIndexHTML))
 }
@@ -0,0 +1,196 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Severities of change log entries, from lowest to highest
+const (
+	SeverityInfo     = "info"     // Additions, e.g. a new endpoint or field
+	SeverityWarning  = "warning"  // Possible removals, e.g. a field that disappeared
+	SeverityBreaking = "breaking" // Changes breaking clients, e.g. a field changing type
+)
+
+// severityRanks orders the severities
+var severityRanks = map[string]int{SeverityInfo: 0, SeverityWarning: 1, SeverityBreaking: 2}
+
+// changeSeverity returns the severity of a kind of change
+func changeSeverity(kind string) string {
+	switch kind {
+	case ChangeFieldTypeChanged:
+		return SeverityBreaking
+	case ChangeFieldDisappeared:
+		return SeverityWarning
+	}
+	return SeverityInfo
+}
+
+// Webhook notifier settings
+const (
+	webhookQueueSize   = 1000             // Changes buffered before new ones are dropped
+	webhookMaxAttempts = 5                // Attempts per message, including the first one
+	webhookTimeout     = 10 * time.Second // Timeout of a single webhook request
+	webhookMaxLines    = 20               // Changes listed in the text of a message
+)
+
+// WebhookOptions configures the notifications of changes posted to a webhook
+type WebhookOptions struct {
+	URL         string        // Webhook URL, notifications are disabled if empty
+	Events      []string      // Kinds of changes notified, all if empty
+	MinSeverity string        // Lowest severity of notified changes, defaults to info
+	Debounce    time.Duration // Time changes are collected before being posted together
+}
+
+// WebhookChange is a change in a webhook message
+type WebhookChange struct {
+	ChangeLogEntry
+	Severity string `json:"severity"`
+}
+
+// WebhookMessage is the JSON payload posted to the webhook. Text summarizes
+// the changes for chat tools like Slack, which ignore the other fields.
+type WebhookMessage struct {
+	Text    string          `json:"text"`
+	Changes []WebhookChange `json:"changes"`
+}
+
+// webhookNotifier posts batches of changes to a webhook from its own
+// goroutine, so a slow or unreachable webhook never delays request analysis
+type webhookNotifier struct {
+	options     WebhookOptions
+	events      map[string]bool // Kinds of changes notified, all if empty
+	minSeverity string          // Lowest severity of notified changes
+	poster      *batchPoster[WebhookChange]
+}
+
+// newWebhookNotifier creates a notifier and starts its sending goroutine.
+// Changes are collected for the debounce window following the first one, then
+// posted in a single message.
+func newWebhookNotifier(options WebhookOptions) *webhookNotifier {
+	n := &webhookNotifier{
+		options:     options,
+		events:      make(map[string]bool, len(options.Events)),
+		minSeverity: options.MinSeverity,
+	}
+	if _, known := severityRanks[n.minSeverity]; !known {
+		n.minSeverity = SeverityInfo
+	}
+	for _, event := range options.Events {
+		n.events[event] = true
+	}
+	poster := &batchPoster[WebhookChange]{
+		url:           options.URL,
+		client:        &http.Client{Timeout: webhookTimeout},
+		flushInterval: options.Debounce,
+		maxAttempts:   webhookMaxAttempts,
+		backoff:       time.Second,
+		encode: func(batch []WebhookChange) ([]byte, error) {
+			return json.Marshal(WebhookMessage{Text: webhookText(batch), Changes: batch})
+		},
+		receiver:    "webhook",
+		description: "change notifications",
+	}
+	n.poster = poster.start(webhookQueueSize)
+	return n
+}
+
+// Add queues a change if it passes the event and severity filters. The change
+// is dropped if the queue is full.
+func (n *webhookNotifier) Add(entry ChangeLogEntry) {
+	if len(n.events) > 0 && !n.events[entry.Kind] {
+		return
+	}
+	severity := changeSeverity(entry.Kind)
+	if severityRanks[severity] < severityRanks[n.minSeverity] {
+		return
+	}
+	n.poster.Add(WebhookChange{ChangeLogEntry: entry, Severity: severity})
+}
+
+// Close posts the queued changes and stops the notifier
+func (n *webhookNotifier) Close() {
+	n.poster.Close()
+}
+
+// sameOptions checks if the notifier was created with the given options
+func (n *webhookNotifier) sameOptions(options WebhookOptions) bool {
+	return n.options.URL == options.URL &&
+		n.options.MinSeverity == options.MinSeverity &&
+		n.options.Debounce == options.Debounce &&
+		slices.Equal(n.options.Events, options.Events)
+}
+
+// webhookText summarizes a batch of changes, one line per change up to webhookMaxLines
+func webhookText(batch []WebhookChange) string {
+	var text strings.Builder
+	if len(batch) == 1 {
+		text.WriteString("DocuRift detected an API change:")
+	} else {
+		fmt.Fprintf(&text, "DocuRift detected %d API changes:", len(batch))
+	}
+	for i, change := range batch {
+		if i == webhookMaxLines {
+			fmt.Fprintf(&text, "\n… and %d more", len(batch)-i)
+			break
+		}
+		fmt.Fprintf(&text, "\n• [%s] %s %s: %s", change.Severity, change.Method, change.Path, change.Kind)
+		if change.Field != "" {
+			fmt.Fprintf(&text, " %s", change.Field)
+		}
+		if change.Location != "" {
+			fmt.Fprintf(&text, " (%s)", change.Location)
+		}
+		if change.Detail != "" {
+			fmt.Fprintf(&text, ": %s", change.Detail)
+		}
+	}
+	return text.String()
+}
+
+// SetWebhook configures the notifications of changes posted to a webhook. The
+// current notifier is kept if the options didn't change, e.g. on a reload of
+// the configuration, so changes still wait for their debounce window.
+// Otherwise it is replaced and posts its queued changes in the background,
+// without waiting for a slow or unreachable webhook.
+func (a *Analyzer) SetWebhook(options WebhookOptions) {
+	if previous := a.replaceWebhook(options); previous != nil {
+		go previous.Close()
+	}
+}
+
+// replaceWebhook replaces the webhook notifier unless its options didn't
+// change, returning the replaced notifier, if any
+func (a *Analyzer) replaceWebhook(options WebhookOptions) *webhookNotifier {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	previous := a.webhook
+	if previous != nil && previous.sameOptions(options) {
+		return nil
+	}
+	a.webhook = nil
+	if options.URL != "" {
+		a.webhook = newWebhookNotifier(options)
+	}
+	return previous
+}
+
+// notifyChanges queues changes of an endpoint for the webhook, if configured
+func (a *Analyzer) notifyChanges(method, path string, changes []SchemaChange) {
+	if len(changes) == 0 {
+		return
+	}
+	a.mu.RLock()
+	notifier := a.webhook
+	a.mu.RUnlock()
+	if notifier == nil {
+		return
+	}
+	for _, change := range changes {
+		notifier.Add(ChangeLogEntry{Method: method, Path: path, SchemaChange: change})
+	}
+}
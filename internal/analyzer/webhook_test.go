@@ -0,0 +1,163 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// webhookReceiver is a webhook endpoint recording the messages it receives
+type webhookReceiver struct {
+	mu       sync.Mutex
+	messages []WebhookMessage
+	failures atomic.Int32 // Number of requests to answer with 503 before accepting
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if r.failures.Add(-1) >= 0 {
+		http.Error(w, "busy", http.StatusServiceUnavailable)
+		return
+	}
+	var message WebhookMessage
+	if err := json.NewDecoder(req.Body).Decode(&message); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, message)
+}
+
+func (r *webhookReceiver) received() []WebhookMessage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]WebhookMessage(nil), r.messages...)
+}
+
+func TestWebhookNotifications(t *testing.T) {
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	a.SetWebhook(WebhookOptions{URL: server.URL, Debounce: 50 * time.Millisecond})
+	a.webhook.poster.backoff = time.Millisecond
+	receiver.failures.Store(1)
+
+	process := func(path, respBody string) {
+		req := httptest.NewRequest("GET", "https://example.com"+path, nil)
+		a.ProcessRequest("GET", "https://example.com"+path, req, &http.Response{StatusCode: 200}, nil, []byte(respBody))
+	}
+
+	// A burst of new endpoints results in a single message, delivered after a retry
+	start := time.Now()
+	process("/users", `[{"id":1}]`)
+	process("/orders", `[{"id":1,"total":9.5}]`)
+	process("/carts", `{"items":[]}`)
+	require.Eventually(t, func() bool { return len(receiver.received()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond, "changes are debounced")
+
+	message := receiver.received()[0]
+	require.Len(t, message.Changes, 3)
+	for i, path := range []string{"/users", "/orders", "/carts"} {
+		assert.Equal(t, "GET", message.Changes[i].Method)
+		assert.Equal(t, path, message.Changes[i].Path)
+		assert.Equal(t, ChangeEndpointFirstSeen, message.Changes[i].Kind)
+		assert.Equal(t, SeverityInfo, message.Changes[i].Severity)
+	}
+	assert.True(t, strings.HasPrefix(message.Text, "DocuRift detected 3 API changes:"), message.Text)
+	assert.Contains(t, message.Text, "• [info] GET /users: endpoint-first-seen")
+
+	// Schema changes are notified too
+	process("/orders", `[{"id":2,"total":"9.50"}]`)
+	require.Eventually(t, func() bool { return len(receiver.received()) == 2 }, 5*time.Second, 10*time.Millisecond)
+	message = receiver.received()[1]
+	require.Len(t, message.Changes, 1)
+	assert.Equal(t, ChangeFieldTypeChanged, message.Changes[0].Kind)
+	assert.Equal(t, SeverityBreaking, message.Changes[0].Severity)
+	assert.Equal(t, "DocuRift detected an API change:\n• [breaking] GET /orders: field-type-changed [].total (response 200): number -> string", message.Text)
+}
+
+func TestWebhookFilters(t *testing.T) {
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	a := NewAnalyzer(t.TempDir(), 3600)
+	a.SetCaptureErrors(true)
+	a.SetWebhook(WebhookOptions{
+		URL:         server.URL,
+		Events:      []string{ChangeNewStatusCode, ChangeFieldTypeChanged},
+		MinSeverity: SeverityBreaking,
+		Debounce:    time.Hour,
+	})
+
+	process := func(status int, respBody string) {
+		req := httptest.NewRequest("GET", "https://example.com/users/1", nil)
+		a.ProcessRequest("GET", "https://example.com/users/1", req, &http.Response{StatusCode: status}, nil, []byte(respBody))
+	}
+	process(200, `{"id":1,"name":"alice"}`)
+	process(200, `{"id":"u-1","name":"alice","email":"a@example.com"}`)
+	process(404, `{"error":"not found"}`)
+
+	// Stopping the analyzer posts the pending changes without waiting for the window
+	a.Stop()
+	messages := receiver.received()
+	require.Len(t, messages, 1)
+	require.Len(t, messages[0].Changes, 1)
+	assert.Equal(t, ChangeFieldTypeChanged, messages[0].Changes[0].Kind)
+	assert.Equal(t, "id", messages[0].Changes[0].Field)
+	assert.Equal(t, "number -> string", messages[0].Changes[0].Detail)
+}
+
+func TestWebhookReload(t *testing.T) {
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	options := WebhookOptions{URL: server.URL, Events: []string{ChangeEndpointFirstSeen}, Debounce: time.Hour}
+	a.SetWebhook(options)
+	notifier := a.webhook
+	req := httptest.NewRequest("GET", "https://example.com/users", nil)
+	a.ProcessRequest("GET", "https://example.com/users", req, &http.Response{StatusCode: 200}, nil, []byte(`[]`))
+
+	// Reloading the same options keeps the notifier and its pending changes
+	a.SetWebhook(WebhookOptions{URL: server.URL, Events: []string{ChangeEndpointFirstSeen}, Debounce: time.Hour})
+	assert.Same(t, notifier, a.webhook)
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, receiver.received(), "the debounce window is not cut short")
+
+	// Changed options replace the notifier without waiting for an unreachable webhook
+	unreachable := WebhookOptions{URL: "http://127.0.0.1:1", Debounce: 10 * time.Millisecond}
+	a.SetWebhook(unreachable)
+	require.NotSame(t, notifier, a.webhook)
+	require.Eventually(t, func() bool { return len(receiver.received()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	a.ProcessRequest("GET", "https://example.com/orders", httptest.NewRequest("GET", "https://example.com/orders", nil), &http.Response{StatusCode: 200}, nil, []byte(`[]`))
+	start := time.Now()
+	a.SetWebhook(options)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestWebhookDisabled(t *testing.T) {
+	a := NewAnalyzer(t.TempDir(), 3600)
+	defer a.Stop()
+	a.SetWebhook(WebhookOptions{URL: "http://127.0.0.1:1", Debounce: time.Hour})
+	a.SetWebhook(WebhookOptions{})
+	assert.Nil(t, a.webhook)
+
+	// Without a webhook changes are only recorded
+	req := httptest.NewRequest("GET", "https://example.com/users", nil)
+	a.ProcessRequest("GET", "https://example.com/users", req, &http.Response{StatusCode: 200}, nil, []byte(`[]`))
+	assert.Len(t, a.Changes(time.Time{}), 1)
+}
@@ -1,9 +1,24 @@
 package config
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/mail"
+	"net/url"
 	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/BurntSushi/toml"
 	"gopkg.in/yaml.v3"
 )
 
@@ -15,8 +30,15 @@ const (
 // Config represents the DocuRift configuration structure
 type Config struct {
 	Proxy struct {
-		Port       int    `yaml:"port"`
-		BackendURL string `yaml:"backend-url"`
+		Port        int    `yaml:"port"`
+		BackendURL  string `yaml:"backend-url"`
+		HTTP2       bool   `yaml:"http2"`
+		HealthCheck struct {
+			Enabled bool   `yaml:"enabled"`
+			Path    string `yaml:"path"`
+		} `yaml:"health-check"`
+		// RequireBackend refuses to start if the backend can't be reached
+		RequireBackend bool `yaml:"require-backend"`
 	} `yaml:"proxy"`
 
 	Analyzer struct {
@@ -24,11 +46,122 @@ type Config struct {
 		MaxExamples     int      `yaml:"max-examples"`
 		RedactedFields  []string `yaml:"redacted-fields"`
 		NoExampleFields []string `yaml:"no-example-fields"`
-		Storage         struct {
+		CaptureErrors   bool     `yaml:"capture-errors"`
+		AutoRedactPII   bool     `yaml:"auto-redact-pii"`
+		TraceHeaders    []string `yaml:"trace-headers"`
+		RedactCookies   *bool    `yaml:"redact-cookies"`
+		LenientJSON     bool     `yaml:"lenient-json"`
+		ExcludedHeaders []string `yaml:"excluded-headers"`
+		IncludedHeaders []string `yaml:"included-headers"`
+		NoBodyPaths     []string `yaml:"no-body-paths"`
+		Sampling        string   `yaml:"sampling"`
+		SampleRate      *float64 `yaml:"sample-rate"`
+		EnumThreshold   int      `yaml:"enum-threshold"`
+		// DeprecateAfter is a duration such as 720h after which operations not
+		// seen anymore are marked deprecated
+		DeprecateAfter string `yaml:"deprecate-after"`
+		// RemoteURL is the base URL of a central analyzer receiving captured requests
+		RemoteURL string `yaml:"remote-url"`
+		// PublicURL is the API URL documented in specifications instead of the backend URL
+		PublicURL string `yaml:"public-url"`
+		// SensitivePatterns maps additional regexes to their replacement values
+		SensitivePatterns map[string]string `yaml:"sensitive-patterns"`
+		// ResponseDescriptions maps "METHOD path status" to a response description
+		ResponseDescriptions map[string]string `yaml:"response-descriptions"`
+		Redaction            struct {
+			Strategy   string `yaml:"strategy"`
+			MaskLength int    `yaml:"mask-length"`
+			HashSalt   string `yaml:"hash-salt"`
+		} `yaml:"redaction"`
+		OpenAPI struct {
+			Info struct {
+				Title       string `yaml:"title"`
+				Version     string `yaml:"version"`
+				Description string `yaml:"description"`
+				Contact     struct {
+					Email string `yaml:"email"`
+				} `yaml:"contact"`
+				License struct {
+					Name string `yaml:"name"`
+				} `yaml:"license"`
+			} `yaml:"info"`
+			Servers []struct {
+				URL         string `yaml:"url"`
+				Description string `yaml:"description"`
+			} `yaml:"servers"`
+			// UseRefs moves repeated object schemas to components referenced with $ref
+			UseRefs bool `yaml:"use-refs"`
+		} `yaml:"openapi"`
+		Postman struct {
+			// BaseURL is the URL requests of the Postman collection are sent to
+			BaseURL string `yaml:"base-url"`
+		} `yaml:"postman"`
+		Limits struct {
+			MaxDepth      int `yaml:"max-depth"`
+			MaxPaths      int `yaml:"max-paths"`
+			MaxArrayItems int `yaml:"max-array-items"`
+		} `yaml:"limits"`
+		Auth struct {
+			Token       string   `yaml:"token"`
+			Username    string   `yaml:"username"`
+			Password    string   `yaml:"password"`
+			ExemptPaths []string `yaml:"exempt-paths"`
+		} `yaml:"auth"`
+		Storage struct {
+			Type      string `yaml:"type"`
 			Path      string `yaml:"path"`
 			Frequency int    `yaml:"frequency"`
+			Bucket    string `yaml:"bucket"`
+			Prefix    string `yaml:"prefix"`
+			Region    string `yaml:"region"`
+			Endpoint  string `yaml:"endpoint"`
 		} `yaml:"storage"`
 	} `yaml:"analyzer"`
+
+	Notifications struct {
+		// WebhookURL receives a JSON message when endpoints or schemas change
+		WebhookURL  string   `yaml:"webhook-url"`
+		Events      []string `yaml:"events"`
+		MinSeverity string   `yaml:"min-severity"`
+		Debounce    int      `yaml:"debounce"`
+	} `yaml:"notifications"`
+
+	Logging struct {
+		Level         string `yaml:"level"`
+		Format        string `yaml:"format"`
+		MaxBodyLength int    `yaml:"max-body-length"`
+	} `yaml:"logging"`
+}
+
+// RestoreStatic resets the settings that only take effect on restart, such as
+// ports and storage, to their values in current. It returns the names of the
+// settings that were changed, so a reload can warn about them.
+func (c *Config) RestoreStatic(current *Config) []string {
+	settings := []struct {
+		name    string
+		value   interface{} // Pointer to the setting in c
+		current interface{}
+	}{
+		{"proxy.port", &c.Proxy.Port, current.Proxy.Port},
+		{"proxy.backend-url", &c.Proxy.BackendURL, current.Proxy.BackendURL},
+		{"proxy.http2", &c.Proxy.HTTP2, current.Proxy.HTTP2},
+		{"proxy.health-check", &c.Proxy.HealthCheck, current.Proxy.HealthCheck},
+		{"proxy.require-backend", &c.Proxy.RequireBackend, current.Proxy.RequireBackend},
+		{"analyzer.port", &c.Analyzer.Port, current.Analyzer.Port},
+		{"analyzer.remote-url", &c.Analyzer.RemoteURL, current.Analyzer.RemoteURL},
+		{"analyzer.auth", &c.Analyzer.Auth, current.Analyzer.Auth},
+		{"analyzer.storage", &c.Analyzer.Storage, current.Analyzer.Storage},
+	}
+
+	var changed []string
+	for _, setting := range settings {
+		value := reflect.ValueOf(setting.value).Elem()
+		if !reflect.DeepEqual(value.Interface(), setting.current) {
+			changed = append(changed, setting.name)
+			value.Set(reflect.ValueOf(setting.current))
+		}
+	}
+	return changed
 }
 
 // validatePort checks if the port is within valid range
@@ -39,48 +172,473 @@ func validatePort(port int, service string) error {
 	return nil
 }
 
-// LoadConfig loads the configuration from the specified file path
+// Environment variables overriding configuration file values
+const (
+	EnvProxyPort    = "DOCURIFT_PROXY_PORT"
+	EnvAnalyzerPort = "DOCURIFT_ANALYZER_PORT"
+	EnvBackendURL   = "DOCURIFT_BACKEND_URL"
+	EnvMaxExamples  = "DOCURIFT_MAX_EXAMPLES"
+
+	// Aliases named after the configuration sections of the values, used
+	// when the variables above are not set
+	EnvProxyBackendURL     = "DOCURIFT_PROXY_BACKEND_URL"
+	EnvAnalyzerMaxExamples = "DOCURIFT_ANALYZER_MAX_EXAMPLES"
+)
+
+// envPrefix is the prefix of the environment variables read by DocuRift
+const envPrefix = "DOCURIFT_"
+
+// knownEnvVars are the DOCURIFT_* environment variables that are not reported
+// as unknown. DOCURIFT_BASE_URL is read by the generated contract tests.
+var knownEnvVars = map[string]bool{
+	EnvProxyPort:           true,
+	EnvAnalyzerPort:        true,
+	EnvBackendURL:          true,
+	EnvMaxExamples:         true,
+	EnvProxyBackendURL:     true,
+	EnvAnalyzerMaxExamples: true,
+	"DOCURIFT_BASE_URL":    true,
+}
+
+// Overrides holds configuration values set outside the configuration file,
+// e.g. by command line flags. Zero values leave the configured value unchanged.
+type Overrides struct {
+	ProxyPort    int
+	AnalyzerPort int
+	BackendURL   string
+	MaxExamples  int
+}
+
+// apply sets the overridden values on the configuration
+func (c *Config) apply(overrides Overrides) {
+	if overrides.ProxyPort != 0 {
+		c.Proxy.Port = overrides.ProxyPort
+	}
+	if overrides.AnalyzerPort != 0 {
+		c.Analyzer.Port = overrides.AnalyzerPort
+	}
+	if overrides.BackendURL != "" {
+		c.Proxy.BackendURL = overrides.BackendURL
+	}
+	if overrides.MaxExamples != 0 {
+		c.Analyzer.MaxExamples = overrides.MaxExamples
+	}
+}
+
+// envOverrides reads the overrides set by DOCURIFT_* environment variables
+func envOverrides() (Overrides, error) {
+	if unknown := unknownEnv(); len(unknown) > 0 {
+		slog.Warn("Ignoring unknown environment variables", "variables", strings.Join(unknown, ", "))
+	}
+
+	var overrides Overrides
+	intVars := []struct {
+		names  []string
+		target *int
+	}{
+		{[]string{EnvProxyPort}, &overrides.ProxyPort},
+		{[]string{EnvAnalyzerPort}, &overrides.AnalyzerPort},
+		{[]string{EnvMaxExamples, EnvAnalyzerMaxExamples}, &overrides.MaxExamples},
+	}
+	for _, variable := range intVars {
+		name, value := lookupEnv(variable.names...)
+		if value == "" {
+			continue
+		}
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return Overrides{}, fmt.Errorf("%s must be a number, got %q", name, value)
+		}
+		*variable.target = number
+	}
+	_, overrides.BackendURL = lookupEnv(EnvBackendURL, EnvProxyBackendURL)
+	return overrides, nil
+}
+
+// lookupEnv returns the name and value of the first of the environment
+// variables that is set to a non-empty value
+func lookupEnv(names ...string) (string, string) {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return name, value
+		}
+	}
+	return "", ""
+}
+
+// unknownEnv returns the sorted names of the DOCURIFT_* environment variables
+// that are set but not read by DocuRift, which are most likely misspelled
+func unknownEnv() []string {
+	var unknown []string
+	for _, variable := range os.Environ() {
+		name, _, _ := strings.Cut(variable, "=")
+		if strings.HasPrefix(name, envPrefix) && !knownEnvVars[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// LoadConfig loads the configuration from the specified file path, with values
+// overridden by the environment
 func LoadConfig(configPath string) (*Config, error) {
-	data, err := os.ReadFile(configPath)
+	return LoadConfigWithOverrides(configPath, Overrides{})
+}
+
+// LoadConfigWithOverrides loads the configuration from the specified file path,
+// which may be empty to configure DocuRift from flags and the environment only.
+// The file is parsed as JSON or TOML if its extension is .json or .toml, and as
+// YAML otherwise.
+// Flag overrides take precedence over environment variables, which take
+// precedence over the file; defaults are filled in and the merged result is
+// validated last.
+func LoadConfigWithOverrides(configPath string, flags Overrides) (*Config, error) {
+	var config Config
+	if configPath != "" {
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			return nil, fmt.Errorf("error reading config file: %w", err)
+		}
+		if err := parseConfig(configPath, data, &config); err != nil {
+			return nil, fmt.Errorf("error parsing config file: %w", err)
+		}
+	}
+
+	env, err := envOverrides()
 	if err != nil {
-		return nil, fmt.Errorf("error reading config file: %w", err)
+		return nil, err
 	}
+	config.apply(env)
+	config.apply(flags)
 
-	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("error parsing config file: %w", err)
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// parseConfig decodes a configuration file in the format given by its extension
+// and warns about the fields that are not configuration settings, which are
+// usually typos. JSON and TOML files are converted to YAML so every format
+// uses the yaml tags of Config.
+func parseConfig(configPath string, data []byte, config *Config) error {
+	var raw map[string]interface{}
+	var err error
+	format := strings.ToLower(filepath.Ext(configPath))
+	switch format {
+	case ".json":
+		if err = json.Unmarshal(data, &raw); err != nil {
+			var syntaxErr *json.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				line := bytes.Count(data[:syntaxErr.Offset], []byte("\n")) + 1
+				return fmt.Errorf("invalid JSON on line %d: %w", line, err)
+			}
+			return fmt.Errorf("invalid JSON: %w", err)
+		}
+	case ".toml":
+		if err = toml.Unmarshal(data, &raw); err != nil {
+			return fmt.Errorf("invalid TOML: %w", err)
+		}
+	default:
+		if err = yaml.Unmarshal(data, &raw); err != nil {
+			return fmt.Errorf("invalid YAML: %w", err)
+		}
+	}
+
+	if unknown := unknownFields(raw, reflect.TypeOf(Config{}), ""); len(unknown) > 0 {
+		slog.Warn("Ignoring unknown configuration fields", "file", configPath, "fields", strings.Join(unknown, ", "))
+	}
+
+	if format == ".json" || format == ".toml" {
+		if data, err = yaml.Marshal(raw); err != nil {
+			return err
+		}
+	}
+	return yaml.Unmarshal(data, config)
+}
+
+// unknownFields returns the dotted names of the fields of raw, a decoded
+// configuration section, that have no matching yaml tag in the struct type t
+func unknownFields(raw map[string]interface{}, t reflect.Type, prefix string) []string {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		fields[name] = t.Field(i).Type
+	}
+
+	var unknown []string
+	for key, value := range raw {
+		fieldType, exists := fields[key]
+		if !exists {
+			unknown = append(unknown, prefix+key)
+			continue
+		}
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		switch fieldType.Kind() {
+		case reflect.Struct:
+			if section, ok := value.(map[string]interface{}); ok {
+				unknown = append(unknown, unknownFields(section, fieldType, prefix+key+".")...)
+			}
+		case reflect.Slice:
+			if fieldType.Elem().Kind() != reflect.Struct {
+				continue
+			}
+			// TOML decodes arrays of tables as []map[string]interface{}
+			var items []map[string]interface{}
+			switch list := value.(type) {
+			case []map[string]interface{}:
+				items = list
+			case []interface{}:
+				for _, item := range list {
+					if section, ok := item.(map[string]interface{}); ok {
+						items = append(items, section)
+					}
+				}
+			}
+			for i, item := range items {
+				unknown = append(unknown, unknownFields(item, fieldType.Elem(), fmt.Sprintf("%s%s[%d].", prefix, key, i))...)
+			}
+		}
 	}
+	sort.Strings(unknown)
+	return unknown
+}
 
+// validate checks the configuration and fills in defaults for unset values
+func (c *Config) validate() error {
 	// Validate proxy port
-	if err := validatePort(config.Proxy.Port, "proxy"); err != nil {
-		return nil, err
+	if err := validatePort(c.Proxy.Port, "proxy"); err != nil {
+		return err
 	}
 
 	// Validate analyzer port
-	if err := validatePort(config.Analyzer.Port, "analyzer"); err != nil {
-		return nil, err
+	if err := validatePort(c.Analyzer.Port, "analyzer"); err != nil {
+		return err
 	}
 
 	// Check for port conflict
-	if config.Proxy.Port == config.Analyzer.Port {
-		return nil, fmt.Errorf("proxy and analyzer cannot use the same port (%d)", config.Proxy.Port)
+	if c.Proxy.Port == c.Analyzer.Port {
+		return fmt.Errorf("proxy and analyzer cannot use the same port (%d)", c.Proxy.Port)
 	}
 
 	// Validate other required fields
-	if config.Proxy.BackendURL == "" {
-		return nil, fmt.Errorf("backend-url is required")
+	if c.Proxy.BackendURL == "" {
+		return fmt.Errorf("backend-url is required")
+	}
+	if c.Analyzer.MaxExamples <= 0 {
+		return fmt.Errorf("max-examples must be greater than 0")
+	}
+
+	// Probe the backend root unless a health path is configured
+	if c.Proxy.HealthCheck.Path == "" {
+		c.Proxy.HealthCheck.Path = "/"
+	}
+
+	// Validate redaction strategy
+	switch c.Analyzer.Redaction.Strategy {
+	case "":
+		c.Analyzer.Redaction.Strategy = "redact"
+	case "redact", "mask", "hash":
+	default:
+		return fmt.Errorf("redaction strategy must be one of redact, mask or hash")
+	}
+	if c.Analyzer.Redaction.MaskLength < 0 {
+		return fmt.Errorf("redaction mask-length must not be negative")
+	}
+	if c.Analyzer.Redaction.MaskLength == 0 {
+		c.Analyzer.Redaction.MaskLength = 4
+	}
+
+	// Validate example sampling
+	switch c.Analyzer.Sampling {
+	case "":
+		c.Analyzer.Sampling = "first"
+	case "first", "reservoir":
+	default:
+		return fmt.Errorf("sampling must be one of first or reservoir")
+	}
+
+	// Validate the enum threshold
+	if c.Analyzer.EnumThreshold < 0 {
+		return fmt.Errorf("enum-threshold must not be negative")
 	}
-	if config.Analyzer.MaxExamples <= 0 {
-		return nil, fmt.Errorf("max-examples must be greater than 0")
+	if c.Analyzer.EnumThreshold == 0 {
+		c.Analyzer.EnumThreshold = 5
+	}
+
+	// Validate the deprecation threshold
+	if c.Analyzer.DeprecateAfter != "" {
+		if threshold, err := time.ParseDuration(c.Analyzer.DeprecateAfter); err != nil || threshold <= 0 {
+			return fmt.Errorf("deprecate-after must be a positive duration such as 720h, got %q", c.Analyzer.DeprecateAfter)
+		}
+	}
+
+	// Set defaults for the specification info
+	info := &c.Analyzer.OpenAPI.Info
+	if info.Title == "" {
+		info.Title = "API Documentation"
+	}
+	if info.Version == "" {
+		info.Version = "1.0.0"
+	}
+	if info.Contact.Email != "" {
+		if _, err := mail.ParseAddress(info.Contact.Email); err != nil {
+			return fmt.Errorf("openapi info contact email %q is not a valid email address", info.Contact.Email)
+		}
+	}
+
+	for _, server := range c.Analyzer.OpenAPI.Servers {
+		if server.URL == "" {
+			return fmt.Errorf("openapi server url is required")
+		}
+		if _, err := url.Parse(server.URL); err != nil {
+			return fmt.Errorf("openapi server url %q is not valid", server.URL)
+		}
+	}
+
+	// Validate the remote analyzer URL
+	if c.Analyzer.RemoteURL != "" {
+		remoteURL, err := url.Parse(c.Analyzer.RemoteURL)
+		if err != nil || (remoteURL.Scheme != "http" && remoteURL.Scheme != "https") || remoteURL.Host == "" {
+			return fmt.Errorf("remote-url must be an http or https URL")
+		}
+	}
+
+	// Validate the public API URL
+	if c.Analyzer.PublicURL != "" {
+		publicURL, err := url.Parse(c.Analyzer.PublicURL)
+		if err != nil || (publicURL.Scheme != "http" && publicURL.Scheme != "https") || publicURL.Host == "" {
+			return fmt.Errorf("public-url must be an http or https URL")
+		}
+	}
+
+	// Validate the Postman base URL
+	if c.Analyzer.Postman.BaseURL != "" {
+		baseURL, err := url.Parse(c.Analyzer.Postman.BaseURL)
+		if err != nil || (baseURL.Scheme != "http" && baseURL.Scheme != "https") || baseURL.Host == "" {
+			return fmt.Errorf("postman.base-url must be an http or https URL")
+		}
+	}
+
+	// Validate authentication
+	if (c.Analyzer.Auth.Username == "") != (c.Analyzer.Auth.Password == "") {
+		return fmt.Errorf("auth username and password must be set together")
+	}
+	for _, path := range c.Analyzer.Auth.ExemptPaths {
+		if !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("auth exempt path %q must start with /", path)
+		}
+	}
+
+	// Validate sensitive data patterns
+	for pattern := range c.Analyzer.SensitivePatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid sensitive pattern %q: %w", pattern, err)
+		}
+	}
+
+	// Validate the paths whose bodies are not captured
+	for _, pattern := range c.Analyzer.NoBodyPaths {
+		if !strings.HasPrefix(pattern, "/") {
+			return fmt.Errorf("no-body-paths entry %q must start with /", pattern)
+		}
+		if _, err := path.Match(pattern, "/"); err != nil {
+			return fmt.Errorf("invalid no-body-paths entry %q: %w", pattern, err)
+		}
+	}
+
+	// Analyze all requests unless a sample rate is set
+	if c.Analyzer.SampleRate == nil {
+		sampleRate := 1.0
+		c.Analyzer.SampleRate = &sampleRate
+	}
+	if rate := *c.Analyzer.SampleRate; rate < 0 || rate > 1 {
+		return fmt.Errorf("sample-rate must be between 0 and 1, got %v", rate)
+	}
+
+	// Redact cookie values unless explicitly disabled
+	if c.Analyzer.RedactCookies == nil {
+		redactCookies := true
+		c.Analyzer.RedactCookies = &redactCookies
+	}
+
+	// Validate processing limits; zero keeps the analyzer defaults
+	if c.Analyzer.Limits.MaxDepth < 0 || c.Analyzer.Limits.MaxPaths < 0 || c.Analyzer.Limits.MaxArrayItems < 0 {
+		return fmt.Errorf("limits must not be negative")
 	}
 
 	// Set defaults for storage if not specified
-	if config.Analyzer.Storage.Path == "" {
-		config.Analyzer.Storage.Path = "."
+	switch c.Analyzer.Storage.Type {
+	case "":
+		c.Analyzer.Storage.Type = "file"
+	case "file", "sqlite":
+	case "s3":
+		if c.Analyzer.Storage.Bucket == "" {
+			return fmt.Errorf("storage bucket is required for s3 storage")
+		}
+	default:
+		return fmt.Errorf("storage type must be one of file, sqlite or s3")
 	}
-	if config.Analyzer.Storage.Frequency <= 0 {
-		config.Analyzer.Storage.Frequency = 10
+	if c.Analyzer.Storage.Path == "" {
+		if c.Analyzer.Storage.Type == "sqlite" {
+			c.Analyzer.Storage.Path = "docurift.db"
+		} else {
+			c.Analyzer.Storage.Path = "."
+		}
+	}
+	if c.Analyzer.Storage.Frequency <= 0 {
+		c.Analyzer.Storage.Frequency = 10
 	}
 
-	return &config, nil
+	// Validate notifications
+	if c.Notifications.WebhookURL != "" {
+		webhookURL, err := url.Parse(c.Notifications.WebhookURL)
+		if err != nil || (webhookURL.Scheme != "http" && webhookURL.Scheme != "https") || webhookURL.Host == "" {
+			return fmt.Errorf("notifications webhook-url must be an http or https URL")
+		}
+	}
+	for _, event := range c.Notifications.Events {
+		switch event {
+		case "endpoint-first-seen", "new-status-code", "new-field", "field-type-changed", "field-disappeared":
+		default:
+			return fmt.Errorf("notifications event %q must be one of endpoint-first-seen, new-status-code, new-field, field-type-changed or field-disappeared", event)
+		}
+	}
+	switch c.Notifications.MinSeverity {
+	case "":
+		c.Notifications.MinSeverity = "info"
+	case "info", "warning", "breaking":
+	default:
+		return fmt.Errorf("notifications min-severity must be one of info, warning or breaking")
+	}
+	if c.Notifications.Debounce <= 0 {
+		c.Notifications.Debounce = 10
+	}
+
+	// Validate logging
+	switch c.Logging.Level {
+	case "":
+		c.Logging.Level = "info"
+	case "debug", "info", "warn", "error":
+	default:
+		return fmt.Errorf("logging level must be one of debug, info, warn or error")
+	}
+	switch c.Logging.Format {
+	case "":
+		c.Logging.Format = "text"
+	case "text", "json":
+	default:
+		return fmt.Errorf("logging format must be one of text or json")
+	}
+	if c.Logging.MaxBodyLength < 0 {
+		return fmt.Errorf("logging max-body-length must not be negative")
+	}
+	if c.Logging.MaxBodyLength == 0 {
+		c.Logging.MaxBodyLength = 1024
+	}
+
+	return nil
 }
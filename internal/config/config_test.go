@@ -1,7 +1,9 @@
 package config
 
 import (
+	"encoding/json"
 	"os"
+	"reflect"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -69,8 +71,27 @@ analyzer:
 	config, err = LoadConfig(tmpfile.Name())
 	assert.NoError(t, err)
 	assert.NotNil(t, config)
-	assert.Equal(t, ".", config.Analyzer.Storage.Path)     // Default path
-	assert.Equal(t, 10, config.Analyzer.Storage.Frequency) // Default frequency
+	assert.Equal(t, "file", config.Analyzer.Storage.Type)         // Default storage type
+	assert.Equal(t, "first", config.Analyzer.Sampling)            // Default sampling
+	assert.Equal(t, 5, config.Analyzer.EnumThreshold)             // Default enum threshold
+	assert.Equal(t, "info", config.Logging.Level)                 // Default log level
+	assert.Equal(t, "text", config.Logging.Format)                // Default log format
+	assert.Equal(t, 1024, config.Logging.MaxBodyLength)           // Default logged body length
+	assert.Equal(t, ".", config.Analyzer.Storage.Path)            // Default path
+	assert.Equal(t, 10, config.Analyzer.Storage.Frequency)        // Default frequency
+	assert.Equal(t, "redact", config.Analyzer.Redaction.Strategy) // Default redaction strategy
+	assert.Equal(t, 4, config.Analyzer.Redaction.MaskLength)      // Default mask length
+	assert.True(t, *config.Analyzer.RedactCookies)                // Cookies redacted by default
+	assert.Equal(t, 1.0, *config.Analyzer.SampleRate)             // All requests analyzed by default
+	assert.False(t, config.Proxy.HealthCheck.Enabled)             // Backend health check disabled by default
+	assert.Equal(t, "/", config.Proxy.HealthCheck.Path)           // Default health check path
+	assert.False(t, config.Proxy.RequireBackend)                  // Starts without a reachable backend by default
+	assert.Equal(t, "info", config.Notifications.MinSeverity)     // All changes notified by default
+	assert.Equal(t, 10, config.Notifications.Debounce)            // Default debounce window
+
+	// The specification info defaults to a generic title and version
+	assert.Equal(t, "API Documentation", config.Analyzer.OpenAPI.Info.Title)
+	assert.Equal(t, "1.0.0", config.Analyzer.OpenAPI.Info.Version)
 
 	// Test cases for invalid configurations
 	testCases := []struct {
@@ -163,6 +184,516 @@ analyzer:
 `,
 			errorMsg: "max-examples must be greater than 0",
 		},
+		{
+			name: "invalid redaction strategy",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+    redaction:
+        strategy: scramble
+`,
+			errorMsg: "redaction strategy must be one of redact, mask or hash",
+		},
+		{
+			name: "negative redaction mask length",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+    redaction:
+        strategy: mask
+        mask-length: -1
+`,
+			errorMsg: "redaction mask-length must not be negative",
+		},
+		{
+			name: "negative limits",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+    limits:
+        max-depth: -1
+`,
+			errorMsg: "limits must not be negative",
+		},
+		{
+			name: "invalid sensitive pattern",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+    sensitive-patterns:
+        "[unclosed": REPLACED
+`,
+			errorMsg: "invalid sensitive pattern",
+		},
+		{
+			name: "invalid sampling",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+    sampling: random
+`,
+			errorMsg: "sampling must be one of first or reservoir",
+		},
+		{
+			name: "sample rate above 1",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+    sample-rate: 1.5
+`,
+			errorMsg: "sample-rate must be between 0 and 1, got 1.5",
+		},
+		{
+			name: "negative enum threshold",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+    enum-threshold: -1
+`,
+			errorMsg: "enum-threshold must not be negative",
+		},
+		{
+			name: "invalid logging level",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+logging:
+    level: verbose
+`,
+			errorMsg: "logging level must be one of debug, info, warn or error",
+		},
+		{
+			name: "invalid logging format",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+logging:
+    format: xml
+`,
+			errorMsg: "logging format must be one of text or json",
+		},
+		{
+			name: "auth username without password",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+    auth:
+        username: admin
+`,
+			errorMsg: "auth username and password must be set together",
+		},
+		{
+			name: "relative auth exempt path",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+    auth:
+        token: s3cret
+        exempt-paths:
+            - api/health
+`,
+			errorMsg: `auth exempt path "api/health" must start with /`,
+		},
+		{
+			name: "auth",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+    auth:
+        token: s3cret
+        username: admin
+        password: pa55
+        exempt-paths:
+            - /api/health
+`,
+			errorMsg: "",
+		},
+		{
+			name: "invalid remote url",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+    remote-url: central-analyzer:9877
+`,
+			errorMsg: "remote-url must be an http or https URL",
+		},
+		{
+			name: "openapi info",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+    openapi:
+        info:
+            title: Shop API
+            version: 2.3.0
+            description: Orders and products
+            contact:
+                email: api@example.com
+            license:
+                name: MIT
+`,
+			errorMsg: "",
+		},
+		{
+			name: "invalid openapi contact email",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+    openapi:
+        info:
+            contact:
+                email: not-an-email
+`,
+			errorMsg: `openapi info contact email "not-an-email" is not a valid email address`,
+		},
+		{
+			name: "openapi servers",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+    openapi:
+        servers:
+            - url: https://api.example.com/v1
+              description: Production
+            - url: /v1
+`,
+			errorMsg: "",
+		},
+		{
+			name: "openapi server without url",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+    openapi:
+        servers:
+            - description: Production
+`,
+			errorMsg: "openapi server url is required",
+		},
+		{
+			name: "no body paths",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+    no-body-paths:
+        - /uploads
+        - /files/*
+`,
+			errorMsg: "",
+		},
+		{
+			name: "relative no body path",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+    no-body-paths:
+        - uploads
+`,
+			errorMsg: "no-body-paths entry \"uploads\" must start with /",
+		},
+		{
+			name: "malformed no body path",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+    no-body-paths:
+        - /files/[
+`,
+			errorMsg: "invalid no-body-paths entry \"/files/[\": syntax error in pattern",
+		},
+		{
+			name: "public url",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+    public-url: https://api.example.com/v1
+`,
+			errorMsg: "",
+		},
+		{
+			name: "invalid public url",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+    public-url: api.example.com
+`,
+			errorMsg: "public-url must be an http or https URL",
+		},
+		{
+			name: "postman base url",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+    postman:
+        base-url: https://staging.example.com:8443/v2
+`,
+			errorMsg: "",
+		},
+		{
+			name: "invalid postman base url",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+    postman:
+        base-url: localhost:8080
+`,
+			errorMsg: "postman.base-url must be an http or https URL",
+		},
+		{
+			name: "deprecate after",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+    deprecate-after: 720h
+`,
+			errorMsg: "",
+		},
+		{
+			name: "invalid deprecate after",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+    deprecate-after: 30d
+`,
+			errorMsg: `deprecate-after must be a positive duration such as 720h, got "30d"`,
+		},
+		{
+			name: "notifications",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+notifications:
+    webhook-url: https://hooks.slack.com/services/T000/B000/XXXX
+    events: [endpoint-first-seen, field-type-changed]
+    min-severity: warning
+    debounce: 30
+`,
+			errorMsg: "",
+		},
+		{
+			name: "invalid webhook url",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+notifications:
+    webhook-url: hooks.slack.com
+`,
+			errorMsg: "notifications webhook-url must be an http or https URL",
+		},
+		{
+			name: "invalid notification event",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+notifications:
+    events: [endpoint-removed]
+`,
+			errorMsg: `notifications event "endpoint-removed" must be one of`,
+		},
+		{
+			name: "invalid notification severity",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+notifications:
+    min-severity: critical
+`,
+			errorMsg: "notifications min-severity must be one of info, warning or breaking",
+		},
+		{
+			name: "remote url",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+    remote-url: http://central-analyzer:9877
+`,
+			errorMsg: "",
+		},
+		{
+			name: "invalid storage type",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+    storage:
+        type: postgres
+`,
+			errorMsg: "storage type must be one of file, sqlite or s3",
+		},
+		{
+			name: "s3 storage without bucket",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+    storage:
+        type: s3
+        region: eu-west-1
+`,
+			errorMsg: "storage bucket is required for s3 storage",
+		},
+		{
+			name: "s3 storage",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+    storage:
+        type: s3
+        bucket: docs
+        prefix: docurift/
+        region: eu-west-1
+        endpoint: http://localhost:9000
+`,
+			errorMsg: "",
+		},
+		{
+			name: "sqlite storage",
+			config: `
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+    storage:
+        type: sqlite
+`,
+			errorMsg: "", // Should not error, should use the default database path
+		},
 		{
 			name: "invalid storage frequency",
 			config: `
@@ -199,7 +730,313 @@ analyzer:
 				if tc.name == "invalid storage frequency" {
 					assert.Equal(t, 10, config.Analyzer.Storage.Frequency)
 				}
+				if tc.name == "auth" {
+					assert.Equal(t, "s3cret", config.Analyzer.Auth.Token)
+					assert.Equal(t, "admin", config.Analyzer.Auth.Username)
+					assert.Equal(t, "pa55", config.Analyzer.Auth.Password)
+					assert.Equal(t, []string{"/api/health"}, config.Analyzer.Auth.ExemptPaths)
+				}
+				if tc.name == "openapi info" {
+					info := config.Analyzer.OpenAPI.Info
+					assert.Equal(t, "Shop API", info.Title)
+					assert.Equal(t, "2.3.0", info.Version)
+					assert.Equal(t, "Orders and products", info.Description)
+					assert.Equal(t, "api@example.com", info.Contact.Email)
+					assert.Equal(t, "MIT", info.License.Name)
+				}
+				if tc.name == "openapi servers" {
+					servers := config.Analyzer.OpenAPI.Servers
+					assert.Len(t, servers, 2)
+					assert.Equal(t, "https://api.example.com/v1", servers[0].URL)
+					assert.Equal(t, "Production", servers[0].Description)
+					assert.Equal(t, "/v1", servers[1].URL)
+				}
+				if tc.name == "public url" {
+					assert.Equal(t, "https://api.example.com/v1", config.Analyzer.PublicURL)
+				}
+				if tc.name == "postman base url" {
+					assert.Equal(t, "https://staging.example.com:8443/v2", config.Analyzer.Postman.BaseURL)
+				}
+				if tc.name == "deprecate after" {
+					assert.Equal(t, "720h", config.Analyzer.DeprecateAfter)
+				}
+				if tc.name == "notifications" {
+					assert.Equal(t, "https://hooks.slack.com/services/T000/B000/XXXX", config.Notifications.WebhookURL)
+					assert.Equal(t, []string{"endpoint-first-seen", "field-type-changed"}, config.Notifications.Events)
+					assert.Equal(t, "warning", config.Notifications.MinSeverity)
+					assert.Equal(t, 30, config.Notifications.Debounce)
+				}
+				if tc.name == "remote url" {
+					assert.Equal(t, "http://central-analyzer:9877", config.Analyzer.RemoteURL)
+				}
+				if tc.name == "sqlite storage" {
+					assert.Equal(t, "docurift.db", config.Analyzer.Storage.Path)
+				}
+				if tc.name == "s3 storage" {
+					assert.Equal(t, "docs", config.Analyzer.Storage.Bucket)
+					assert.Equal(t, "docurift/", config.Analyzer.Storage.Prefix)
+					assert.Equal(t, "eu-west-1", config.Analyzer.Storage.Region)
+					assert.Equal(t, "http://localhost:9000", config.Analyzer.Storage.Endpoint)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadConfigWithOverrides(t *testing.T) {
+	configFile := t.TempDir() + "/config.yaml"
+	fileConfig := `
+proxy:
+    port: 9876
+    backend-url: http://file:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+`
+	if err := os.WriteFile(configFile, []byte(fileConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	flags := Overrides{ProxyPort: 7000, AnalyzerPort: 7001, BackendURL: "http://flag:8080", MaxExamples: 30}
+	env := map[string]string{
+		EnvProxyPort:    "8000",
+		EnvAnalyzerPort: "8001",
+		EnvBackendURL:   "http://env:8080",
+		EnvMaxExamples:  "20",
+	}
+
+	testCases := []struct {
+		name                string
+		configPath          string
+		env                 bool
+		flags               bool
+		expectedProxyPort   int
+		expectedAnalyzer    int
+		expectedBackendURL  string
+		expectedMaxExamples int
+	}{
+		{"file only", configFile, false, false, 9876, 9877, "http://file:8080", 10},
+		{"env overrides file", configFile, true, false, 8000, 8001, "http://env:8080", 20},
+		{"flags override file", configFile, false, true, 7000, 7001, "http://flag:8080", 30},
+		{"flags override env and file", configFile, true, true, 7000, 7001, "http://flag:8080", 30},
+		{"env without file", "", true, false, 8000, 8001, "http://env:8080", 20},
+		{"flags without file", "", false, true, 7000, 7001, "http://flag:8080", 30},
+		{"flags override env without file", "", true, true, 7000, 7001, "http://flag:8080", 30},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for name, value := range env {
+				if !tc.env {
+					value = ""
+				}
+				t.Setenv(name, value)
+			}
+			overrides := Overrides{}
+			if tc.flags {
+				overrides = flags
+			}
+
+			config, err := LoadConfigWithOverrides(tc.configPath, overrides)
+			assert.NoError(t, err)
+			if assert.NotNil(t, config) {
+				assert.Equal(t, tc.expectedProxyPort, config.Proxy.Port)
+				assert.Equal(t, tc.expectedAnalyzer, config.Analyzer.Port)
+				assert.Equal(t, tc.expectedBackendURL, config.Proxy.BackendURL)
+				assert.Equal(t, tc.expectedMaxExamples, config.Analyzer.MaxExamples)
+				assert.Equal(t, "file", config.Analyzer.Storage.Type) // Defaults are still filled in
+			}
+		})
+	}
+
+	t.Run("single override", func(t *testing.T) {
+		t.Setenv(EnvBackendURL, "http://env:8080")
+		config, err := LoadConfigWithOverrides(configFile, Overrides{ProxyPort: 7000})
+		assert.NoError(t, err)
+		assert.Equal(t, 7000, config.Proxy.Port)
+		assert.Equal(t, 9877, config.Analyzer.Port)
+		assert.Equal(t, "http://env:8080", config.Proxy.BackendURL)
+		assert.Equal(t, 10, config.Analyzer.MaxExamples)
+	})
+
+	t.Run("merged result is validated", func(t *testing.T) {
+		_, err := LoadConfigWithOverrides(configFile, Overrides{AnalyzerPort: 9876})
+		assert.EqualError(t, err, "proxy and analyzer cannot use the same port (9876)")
+
+		_, err = LoadConfigWithOverrides("", Overrides{ProxyPort: 7000, AnalyzerPort: 7001, MaxExamples: 10})
+		assert.EqualError(t, err, "backend-url is required")
+	})
+
+	t.Run("invalid env value", func(t *testing.T) {
+		t.Setenv(EnvProxyPort, "eighty")
+		_, err := LoadConfigWithOverrides(configFile, Overrides{})
+		assert.EqualError(t, err, `DOCURIFT_PROXY_PORT must be a number, got "eighty"`)
+	})
+
+	t.Run("section-qualified aliases", func(t *testing.T) {
+		t.Setenv(EnvProxyBackendURL, "http://alias:8080")
+		t.Setenv(EnvAnalyzerMaxExamples, "25")
+		config, err := LoadConfigWithOverrides(configFile, Overrides{})
+		assert.NoError(t, err)
+		assert.Equal(t, "http://alias:8080", config.Proxy.BackendURL)
+		assert.Equal(t, 25, config.Analyzer.MaxExamples)
+
+		// The shorter names win when both are set
+		t.Setenv(EnvBackendURL, "http://env:8080")
+		t.Setenv(EnvMaxExamples, "20")
+		config, err = LoadConfigWithOverrides(configFile, Overrides{})
+		assert.NoError(t, err)
+		assert.Equal(t, "http://env:8080", config.Proxy.BackendURL)
+		assert.Equal(t, 20, config.Analyzer.MaxExamples)
+	})
+
+	t.Run("invalid alias value", func(t *testing.T) {
+		t.Setenv(EnvAnalyzerMaxExamples, "many")
+		_, err := LoadConfigWithOverrides(configFile, Overrides{})
+		assert.EqualError(t, err, `DOCURIFT_ANALYZER_MAX_EXAMPLES must be a number, got "many"`)
+	})
+
+	t.Run("unknown variables", func(t *testing.T) {
+		t.Setenv("DOCURIFT_PROXY_PROT", "8000")
+		t.Setenv("DOCURIFT_BACKEND", "http://env:8080")
+		t.Setenv("DOCURIFT_BASE_URL", "http://localhost:9876")
+		t.Setenv(EnvProxyBackendURL, "http://alias:8080")
+		assert.Equal(t, []string{"DOCURIFT_BACKEND", "DOCURIFT_PROXY_PROT"}, unknownEnv())
+
+		// Unknown variables are ignored
+		config, err := LoadConfigWithOverrides(configFile, Overrides{})
+		assert.NoError(t, err)
+		assert.Equal(t, 9876, config.Proxy.Port)
+		assert.Equal(t, "http://alias:8080", config.Proxy.BackendURL)
+	})
+}
+
+func TestRestoreStatic(t *testing.T) {
+	load := func(content string) *Config {
+		configFile := t.TempDir() + "/config.yaml"
+		if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		config, err := LoadConfig(configFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return config
+	}
+	current := load(`
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 10
+    redacted-fields:
+        - password
+    storage:
+        path: /tmp
+`)
+
+	next := load(`
+proxy:
+    port: 9876
+    backend-url: http://localhost:8080
+analyzer:
+    port: 9877
+    max-examples: 20
+    redacted-fields:
+        - password
+        - token
+    storage:
+        path: /tmp
+`)
+	assert.Empty(t, next.RestoreStatic(current))
+	assert.Equal(t, 20, next.Analyzer.MaxExamples)
+
+	next = load(`
+proxy:
+    port: 9000
+    backend-url: http://localhost:8081
+    http2: true
+analyzer:
+    port: 9877
+    max-examples: 20
+    auth:
+        token: s3cret
+    storage:
+        path: /var/lib/docurift
+`)
+	assert.Equal(t, []string{"proxy.port", "proxy.backend-url", "proxy.http2", "analyzer.auth", "analyzer.storage"}, next.RestoreStatic(current))
+	assert.False(t, next.Proxy.HTTP2)
+	assert.Equal(t, 9876, next.Proxy.Port)
+	assert.Equal(t, "http://localhost:8080", next.Proxy.BackendURL)
+	assert.Empty(t, next.Analyzer.Auth.Token)
+	assert.Equal(t, "/tmp", next.Analyzer.Storage.Path)
+	assert.Equal(t, 20, next.Analyzer.MaxExamples) // Dynamic settings are kept
+}
+
+func TestLoadConfigFormats(t *testing.T) {
+	expected, err := LoadConfig("testdata/config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "Shop API", expected.Analyzer.OpenAPI.Info.Title)
+	assert.Equal(t, "Production", expected.Analyzer.OpenAPI.Servers[0].Description)
+	assert.True(t, expected.Proxy.HealthCheck.Enabled)
+
+	// Every format is parsed into the same configuration, with the same defaults
+	for _, file := range []string{"testdata/config.json", "testdata/config.toml"} {
+		t.Run(file, func(t *testing.T) {
+			config, err := LoadConfig(file)
+			if err != nil {
+				t.Fatal(err)
 			}
+			assert.Equal(t, expected, config)
 		})
 	}
+
+	t.Run("Invalid JSON", func(t *testing.T) {
+		configFile := t.TempDir() + "/config.json"
+		if err := os.WriteFile(configFile, []byte("{\n  \"proxy\": {\"port\": 9876,}\n}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadConfig(configFile)
+		assert.ErrorContains(t, err, "error parsing config file: invalid JSON on line 2")
+	})
+
+	t.Run("Invalid TOML", func(t *testing.T) {
+		configFile := t.TempDir() + "/config.toml"
+		if err := os.WriteFile(configFile, []byte("[proxy\nport = 9876\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadConfig(configFile)
+		assert.ErrorContains(t, err, "error parsing config file: invalid TOML")
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		configFile := t.TempDir() + "/config.toml"
+		if err := os.WriteFile(configFile, []byte("[proxy]\nport = 9876\n[analyzer]\nport = 9876\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadConfig(configFile)
+		assert.EqualError(t, err, "proxy and analyzer cannot use the same port (9876)")
+	})
+}
+
+func TestUnknownFields(t *testing.T) {
+	data, err := os.ReadFile("testdata/unknown.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{
+		"analyzer.max-exmaples",
+		"analyzer.openapi.servers[0].descripton",
+		"loging",
+	}, unknownFields(raw, reflect.TypeOf(Config{}), ""))
+
+	// Unknown fields are ignored, so the typo leaves max-examples unset
+	_, err = LoadConfig("testdata/unknown.json")
+	assert.EqualError(t, err, "max-examples must be greater than 0")
 }